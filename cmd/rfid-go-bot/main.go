@@ -7,13 +7,17 @@ import (
 	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
 	"syscall"
 
 	"new_era_go/internal/gobot/cache"
 	"new_era_go/internal/gobot/config"
 	"new_era_go/internal/gobot/erp"
+	"new_era_go/internal/gobot/health"
+	"new_era_go/internal/gobot/history"
 	"new_era_go/internal/gobot/httpapi"
 	"new_era_go/internal/gobot/ipc"
+	"new_era_go/internal/gobot/notify"
 	"new_era_go/internal/gobot/reader"
 	"new_era_go/internal/gobot/service"
 	"new_era_go/internal/gobot/telegram"
@@ -28,6 +32,9 @@ func main() {
 	if err := config.LoadDotEnv(envFile); err != nil {
 		log.Fatalf("env load failed: %v", err)
 	}
+	if len(os.Args) > 1 && os.Args[1] == "config" {
+		os.Exit(runConfigCommand(os.Args[2:]))
+	}
 
 	cfg, err := config.Load()
 	if err != nil {
@@ -40,11 +47,24 @@ func main() {
 		closeLog = redirectBotLogs()
 		defer closeLog()
 	}
+	logConfigNotes(cfg, "config")
 
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	sigCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
+	// ctx outlives the signal so the shutdown sequence can still notify;
+	// serveCtx only covers the HTTP and IPC servers.
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	serveCtx, stopServers := context.WithCancel(ctx)
+	defer stopServers()
+	var servers sync.WaitGroup
 
 	erpClient := erp.New(cfg.ERPURL, cfg.ERPAPIKey, cfg.ERPAPISecret, cfg.RequestTimeout)
+	erpClient.SetBreaker(erp.NewBreaker(erp.BreakerConfig{
+		FailureThreshold: cfg.ERPBreakerFailures,
+		OpenTimeout:      cfg.ERPBreakerOpen,
+		HalfOpenProbes:   cfg.ERPBreakerProbes,
+	}))
 	cacheStore := cache.New()
 	svc := service.New(cfg, erpClient, cacheStore)
 
@@ -54,22 +74,40 @@ func main() {
 	var scanner *reader.Manager
 	var tgScanner telegram.Scanner
 	if useSDKScanner {
-		scanner = reader.New(cfg, func(epc string) {
-			svc.HandleEPC(context.Background(), epc, "sdk")
+		scanner = reader.New(cfg, func(epc, endpoint string) {
+			svc.HandleReaderEPC(context.Background(), epc, "sdk", endpoint)
 		}, nil)
+		scanner.SetEvents(svc.Events())
+		scanner.SetStateFile(cfg.ReaderStateFile)
 		tgScanner = scanner
 	}
 
 	tg := telegram.New(cfg.BotToken, cfg.RequestTimeout, cfg.PollTimeout, svc, tgScanner)
-	svc.SetNotifier(tg)
+	tg.SetAccessList(cfg.TelegramAdmins, cfg.TelegramOperators, cfg.TelegramViewers)
+	if scanner != nil {
+		tg.SetReaderConfig(scanner)
+		tg.SetAntennaMonitor(scanner)
+	}
+	hub := notify.NewHub(notify.Config{
+		Window:     cfg.NotifyWindow,
+		RatePerSec: cfg.NotifyRatePerSec,
+		QueueSize:  cfg.NotifyQueueSize,
+	}, tg)
+	svc.SetNotifier(hub)
 	if scanner != nil {
-		scanner.SetNotifier(tg.Notify)
+		scanner.SetNotifier(hub.For(notify.CategoryReader))
 	}
 
-	if err := svc.Bootstrap(ctx); err != nil {
+	if err := svc.Bootstrap(sigCtx); err != nil {
 		log.Printf("[bot] startup cache refresh failed: %v", err)
 	} else {
-		tg.Notify("Bot ishga tushdi. Cache ERPNext'dan yuklandi.")
+		hub.NotifyCategory(notify.CategorySystem, "Bot ishga tushdi. Cache ERPNext'dan yuklandi.")
+		queued, dropped, err := svc.RestorePending(cfg.PendingFile)
+		if err != nil {
+			log.Printf("[bot] pending restore failed: %v", err)
+		} else if queued+dropped > 0 {
+			log.Printf("[bot] pending restore: queued=%d dropped=%d", queued, dropped)
+		}
 	}
 
 	if cfg.ScanDefaultActive {
@@ -79,7 +117,41 @@ func main() {
 		}
 	}
 
+	var hist *history.Store
+	if cfg.HistoryEnabled {
+		hist, err = history.Open(history.Config{
+			Dir:       cfg.HistoryDir,
+			Retention: cfg.HistoryRetention,
+			MaxBytes:  cfg.HistoryMaxBytes,
+			ReadDedup: cfg.HistoryReadDedup,
+		})
+		if err != nil {
+			log.Printf("[bot] history disabled: %v", err)
+		}
+	}
+	// History gets its own context: shutdown stops it after the submit
+	// drain, so the drain's submit events are written before exit.
+	histCtx, stopHistory := context.WithCancel(context.Background())
+	histDone := make(chan struct{})
+	if hist != nil {
+		go func() {
+			defer close(histDone)
+			hist.Run(histCtx, svc.Events())
+		}()
+	} else {
+		close(histDone)
+	}
+	stopRecorders := func() {
+		stopHistory()
+		<-histDone
+	}
+	if hist != nil {
+		tg.SetReports(hist, cfg.ReportDailyAt)
+	}
+
 	svc.Run(ctx)
+	go (&reloader{cur: cfg, svc: svc, scanner: scanner, tg: tg}).run(ctx)
+	go hub.Run(ctx)
 	go tg.Run(ctx)
 
 	if cfg.AutoScan && scanner != nil {
@@ -87,7 +159,7 @@ func main() {
 			log.Printf("[bot] auto scan start failed: %v", err)
 		} else {
 			svc.SetScanActive(true, "auto_scan")
-			tg.Notify("Auto scan boshlandi.")
+			hub.NotifyCategory(notify.CategorySystem, "Auto scan boshlandi.")
 		}
 	}
 
@@ -97,18 +169,46 @@ func main() {
 	}
 	if cfg.IPCEnabled && cfg.IPCSocket != "" {
 		ipcServer := ipc.New(cfg.IPCSocket, svc, ipcScanner)
+		ipcServer.SetHistory(hist)
+		if scanner != nil {
+			ipcServer.SetReaderConfig(scanner)
+		}
+		servers.Add(1)
 		go func() {
-			if err := ipcServer.Run(ctx); err != nil {
+			defer servers.Done()
+			if err := ipcServer.Run(serveCtx); err != nil {
 				log.Printf("[bot] ipc server failed: %v", err)
 				stop()
 			}
 		}()
 	}
 
+	monitor := health.NewMonitor()
+	monitor.AddLive(svc.CheckWorkers)
+	monitor.AddReady(svc.CheckRefresh)
+	monitor.AddReady(svc.CheckQueue)
+	if scanner != nil {
+		monitor.AddReady(scanner.CheckReader)
+	}
+
+	var httpScanner httpapi.Scanner
+	if scanner != nil {
+		httpScanner = scanner
+	}
 	if cfg.HTTPEnabled && strings.TrimSpace(cfg.HTTPAddr) != "" {
-		httpServer := httpapi.New(cfg.HTTPAddr, cfg.WebhookSecret, svc, scanner)
+		httpServer := httpapi.New(cfg.HTTPAddr, cfg.WebhookSecret, svc, httpScanner)
+		httpServer.SetAuth(httpapi.NewAuth(cfg.HTTPAPIKeys, cfg.HTTPAuthSkew))
+		httpServer.SetTLS(cfg.HTTPTLSCert, cfg.HTTPTLSKey)
+		httpServer.SetHistory(hist)
+		httpServer.SetHealth(monitor)
+		if scanner != nil {
+			httpServer.SetReaderConfig(scanner)
+			httpServer.SetAntennaMonitor(scanner)
+		}
+		servers.Add(1)
 		go func() {
-			if err := httpServer.Run(ctx); err != nil {
+			defer servers.Done()
+			if err := httpServer.Run(serveCtx); err != nil {
 				log.Printf("[bot] http server failed: %v", err)
 				stop()
 			}
@@ -123,7 +223,11 @@ func main() {
 		stop()
 	}
 
-	<-ctx.Done()
+	<-sigCtx.Done()
+	shutdown(cfg, svc, scanner, hub, stopRecorders, func() {
+		stopServers()
+		servers.Wait()
+	})
 }
 
 func shouldShowTUI() bool {
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/reader"
+	"new_era_go/internal/gobot/service"
+	"new_era_go/internal/gobot/telegram"
+)
+
+const configPollInterval = 2 * time.Second
+
+// reloader re-reads the config on SIGHUP or when the config file changes and
+// applies the fields listed as reloadable in config.Diff.
+type reloader struct {
+	cur     config.Config
+	svc     *service.Service
+	scanner *reader.Manager
+	tg      *telegram.Bot
+}
+
+func (r *reloader) run(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	var poll <-chan time.Time
+	lastMod := modTime(r.cur.File)
+	if r.cur.File != "" {
+		t := time.NewTicker(configPollInterval)
+		defer t.Stop()
+		poll = t.C
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			r.reload(ctx, "SIGHUP")
+		case <-poll:
+			mod := modTime(r.cur.File)
+			if mod.IsZero() || mod.Equal(lastMod) {
+				continue
+			}
+			lastMod = mod
+			r.reload(ctx, "file change")
+		}
+	}
+}
+
+func (r *reloader) reload(ctx context.Context, reason string) {
+	next, err := config.LoadFile(r.cur.File)
+	if err != nil {
+		log.Printf("[bot] config reload (%s) rejected, keeping current config: %v", reason, err)
+		return
+	}
+	logConfigNotes(next, "config reload ("+reason+")")
+
+	apply, restart := r.cur.Diff(next)
+	if len(restart) > 0 {
+		log.Printf("[bot] config reload (%s): restart required for %s", reason, strings.Join(restart, ", "))
+	}
+	if len(apply) == 0 {
+		log.Printf("[bot] config reload (%s): nothing to apply", reason)
+		return
+	}
+
+	r.svc.ApplyTuning(service.Tuning{
+		RefreshInterval:     next.RefreshInterval,
+		RefreshMaxBackoff:   next.RefreshMaxBackoff,
+		SubmitRetry:         next.SubmitRetry,
+		SubmitRetryDelay:    next.SubmitRetryDelay,
+		SubmitRetryMaxDelay: next.SubmitRetryMaxDelay,
+	})
+	r.cur.RefreshInterval = next.RefreshInterval
+	r.cur.RefreshMaxBackoff = next.RefreshMaxBackoff
+	r.cur.SubmitRetry = next.SubmitRetry
+	r.cur.SubmitRetryDelay = next.SubmitRetryDelay
+	r.cur.SubmitRetryMaxDelay = next.SubmitRetryMaxDelay
+
+	r.tg.SetAccessList(next.TelegramAdmins, next.TelegramOperators, next.TelegramViewers)
+	r.cur.TelegramAdmins = next.TelegramAdmins
+	r.cur.TelegramOperators = next.TelegramOperators
+	r.cur.TelegramViewers = next.TelegramViewers
+
+	// Only a changed inventory is pushed, so tuning set at runtime through
+	// /reader/config survives reloads that touch other fields.
+	if r.scanner != nil && slices.Contains(apply, "ReaderInventory") {
+		if err := r.scanner.SetInventory(ctx, next.ReaderInventory); err != nil {
+			log.Printf("[bot] config reload (%s): %v", reason, err)
+		}
+	}
+	r.cur.ReaderInventory = next.ReaderInventory
+
+	log.Printf("[bot] config reloaded (%s): %s", reason, strings.Join(apply, ", "))
+}
+
+// logConfigNotes logs clamped legacy values and the file keys that an
+// environment variable overrides, so a file edit that has no effect is
+// explained in the log.
+func logConfigNotes(cfg config.Config, what string) {
+	for _, w := range cfg.Warnings {
+		log.Printf("[bot] %s warning: %s", what, w)
+	}
+	if len(cfg.Shadowed) > 0 {
+		log.Printf("[bot] %s: file keys overridden by environment: %s", what, strings.Join(cfg.Shadowed, ", "))
+	}
+}
+
+func modTime(path string) time.Time {
+	if path == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// runConfigCommand implements "rfid-go-bot config check [file]".
+func runConfigCommand(args []string) int {
+	if len(args) == 0 || args[0] != "check" {
+		fmt.Fprintln(os.Stderr, "usage: rfid-go-bot config check [file]")
+		return 2
+	}
+	path := os.Getenv("BOT_CONFIG_FILE")
+	if len(args) > 1 {
+		path = args[1]
+	}
+
+	cfg, err := config.LoadFile(path)
+	if err != nil {
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			fmt.Fprintf(os.Stderr, "config invalid (%d problem(s)):\n", len(verr.Problems))
+			for _, p := range verr.Problems {
+				fmt.Fprintf(os.Stderr, "  %s\n", p)
+			}
+			return 1
+		}
+		fmt.Fprintf(os.Stderr, "config error: %v\n", err)
+		return 1
+	}
+
+	source := "environment only"
+	if cfg.File != "" {
+		source = cfg.File + " + environment"
+	}
+	fmt.Printf("config OK (%s)\n", source)
+	for _, w := range cfg.Warnings {
+		fmt.Printf("  warning: %s\n", w)
+	}
+	if len(cfg.Shadowed) > 0 {
+		fmt.Printf("  overridden by environment: %s\n", strings.Join(cfg.Shadowed, ", "))
+	}
+	fmt.Printf("  erp=%s scan_backend=%s http=%s ipc=%s\n", cfg.ERPURL, cfg.ScanBackend, orDash(cfg.HTTPAddr), orDash(cfg.IPCSocket))
+	inv := cfg.ReaderInventory
+	fmt.Printf("  reader q=%d session=%d power=%d scan_time=%d antennas=%v\n", inv.Q, inv.Session, inv.Power, inv.ScanTime, inv.Antennas)
+	return 0
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
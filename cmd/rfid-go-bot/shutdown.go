@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/notify"
+	"new_era_go/internal/gobot/reader"
+	"new_era_go/internal/gobot/service"
+)
+
+const notifyFlushTimeout = 5 * time.Second
+
+// shutdown stops the bot in order: the reader first, then ingest and the
+// submit queue (drained for cfg.ShutdownDrain, leftovers saved to
+// cfg.PendingFile), then the recorders (history flushed and closed), then
+// the HTTP and IPC servers, and finally the shutdown notice.
+func shutdown(cfg config.Config, svc *service.Service, scanner *reader.Manager, hub *notify.Hub, stopRecorders, stopServers func()) {
+	log.Printf("[bot] shutting down: draining submit queue for up to %s", cfg.ShutdownDrain)
+	if scanner != nil {
+		scanner.Stop()
+	}
+
+	drainCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownDrain)
+	sum := svc.Drain(drainCtx)
+	cancel()
+
+	saveErr := service.SavePending(cfg.PendingFile, sum.Remaining)
+	if saveErr != nil {
+		log.Printf("[bot] pending save failed, %d EPC(s) lost: %v: %s", len(sum.Remaining), saveErr, strings.Join(sum.Remaining, ","))
+	}
+
+	stopRecorders()
+	stopServers()
+
+	log.Printf("[bot] shutdown complete: %s", sum)
+	if !cfg.ShutdownNotify {
+		return
+	}
+	hub.NotifyCategory(notify.CategorySystem, shutdownText(sum, saveErr))
+	flushCtx, cancel := context.WithTimeout(context.Background(), notifyFlushTimeout)
+	defer cancel()
+	if err := hub.Flush(flushCtx); err != nil {
+		log.Printf("[bot] shutdown notification not sent: %v", err)
+	}
+}
+
+func shutdownText(sum service.DrainSummary, saveErr error) string {
+	text := fmt.Sprintf("Bot to'xtatildi. Navbat: yuborildi=%d, topilmadi=%d, xato=%d.", sum.Submitted, sum.NotFound, sum.Failed)
+	switch {
+	case len(sum.Remaining) == 0:
+	case saveErr != nil:
+		text += fmt.Sprintf(" %d EPC saqlanmadi: %v", len(sum.Remaining), saveErr)
+	default:
+		text += fmt.Sprintf(" %d EPC saqlandi, keyingi ishga tushishda yuboriladi.", len(sum.Remaining))
+	}
+	if sum.TimedOut {
+		text += " Navbat vaqtida tugamadi."
+	}
+	return text
+}
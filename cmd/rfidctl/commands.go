@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+	"new_era_go/internal/regions"
+	"new_era_go/sdk"
+)
+
+func runDiscover(ctx context.Context, env *cliEnv, args []string) error {
+	fs := newFlagSet(env, "discover", "discover [flags]")
+	ports := fs.String("ports", "", "comma-separated TCP ports to probe (default discovery ports)")
+	targets := fs.String("targets", strings.Join(envList("BOT_READER_SCAN_TARGETS"), ","), "CIDRs, ranges or addresses to sweep instead of the local subnets")
+	exclude := fs.String("exclude", strings.Join(envList("BOT_READER_SCAN_EXCLUDE"), ","), "CIDRs, ranges or addresses never probed")
+	ifaces := fs.String("iface", strings.Join(envList("BOT_READER_SCAN_INTERFACES"), ","), "comma-separated interfaces to sweep")
+	force := fs.Bool("force-sweep", false, "sweep the LAN even when a known reader answers")
+	verifyOnly := fs.Bool("verify-only", false, "list only endpoints that answered the reader protocol")
+	timeout := fs.Duration("timeout", 25*time.Second, "overall scan timeout")
+	format := fs.String("format", "text", "output format: text, jsonl or csv")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	out, err := newPrinter(env.stdout, *format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	opts := scanOptions()
+	opts.ForceSweep = *force
+	opts.Targets = splitList(*targets)
+	opts.Exclude = splitList(*exclude)
+	opts.Interfaces = splitList(*ifaces)
+	if *ports != "" {
+		opts.Ports = nil
+		for _, part := range splitList(*ports) {
+			port, err := strconv.Atoi(part)
+			if err != nil || port < 1 || port > 65535 {
+				return usagef("invalid port %q", part)
+			}
+			opts.Ports = append(opts.Ports, port)
+		}
+	}
+
+	scanCtx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	candidates, scanErr := sdk.NewClient().Discover(scanCtx, opts)
+	verified := 0
+	for _, c := range candidates {
+		if c.Verified {
+			verified++
+		} else if *verifyOnly {
+			continue
+		}
+		address := ""
+		if c.Verified {
+			address = fmt.Sprintf("0x%02X", c.ReaderAddress)
+		}
+		if err := out.print(record{
+			{"host", c.Host},
+			{"port", c.Port},
+			{"verified", c.Verified},
+			{"reader_address", address},
+			{"protocol", c.Protocol},
+			{"score", c.Score},
+			{"known", c.Known},
+			{"label", c.Label},
+			{"mac", c.MAC},
+			{"reason", c.Reason},
+		}); err != nil {
+			return err
+		}
+	}
+	if scanErr != nil && len(candidates) == 0 {
+		return scanErr
+	}
+	if verified == 0 {
+		return fmt.Errorf("no verified reader found")
+	}
+	return nil
+}
+
+func runInfo(ctx context.Context, env *cliEnv, args []string) error {
+	var common commonFlags
+	fs := newFlagSet(env, "info", "info [flags]")
+	common.register(fs)
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	info, err := client.ReaderInfo(ctx)
+	if err != nil {
+		return err
+	}
+	endpoint, _ := client.Endpoint()
+	return out.print(append(record{
+		{"endpoint", endpoint.Address()},
+		{"firmware", info.Firmware},
+		{"type", fmt.Sprintf("0x%02X", info.Type)},
+		{"protocols", fmt.Sprintf("0x%02X", info.Protocols)},
+	}, settingsRecord(info)...))
+}
+
+func runConfig(ctx context.Context, env *cliEnv, args []string) error {
+	if len(args) == 0 || (args[0] != "get" && args[0] != "set") {
+		return usagef("usage: rfidctl config get|set [flags]")
+	}
+	action := args[0]
+	var common commonFlags
+	fs := newFlagSet(env, "config "+action, "config "+action+" [flags]")
+	common.register(fs)
+	power := fs.Int("power", 0, "RF output power, 0-30 dBm")
+	scanTime := fs.Int("scan-time", 0, "inventory scan time in 100 ms units")
+	antennas := fs.String("antennas", "", "enabled antenna ports, e.g. 1,2")
+	if err := parse(fs, args[1:], 0); err != nil {
+		return err
+	}
+	set := setFlags(fs)
+	if action == "get" && (set["power"] || set["scan-time"] || set["antennas"]) {
+		return usagef("config get takes no settings; use config set")
+	}
+	if action == "set" && !set["power"] && !set["scan-time"] && !set["antennas"] {
+		return usagef("config set needs --power, --scan-time or --antennas")
+	}
+	var mask byte
+	if set["antennas"] {
+		var err error
+		if mask, err = parseAntennas(*antennas); err != nil {
+			return usageError{err.Error()}
+		}
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if set["power"] {
+		if err := client.SetOutputPower(ctx, *power); err != nil {
+			return fmt.Errorf("set power: %w", err)
+		}
+	}
+	if set["scan-time"] {
+		if err := client.SetScanTime(ctx, *scanTime); err != nil {
+			return fmt.Errorf("set scan time: %w", err)
+		}
+	}
+	if set["antennas"] {
+		if err := client.SetAntennas(ctx, mask); err != nil {
+			return fmt.Errorf("set antennas: %w", err)
+		}
+	}
+	info, err := client.ReaderInfo(ctx)
+	if err != nil {
+		return err
+	}
+	return out.print(settingsRecord(info))
+}
+
+func runRegion(ctx context.Context, env *cliEnv, args []string) error {
+	if len(args) == 0 || (args[0] != "list" && args[0] != "set") {
+		return usagef("usage: rfidctl region list | region set <code>")
+	}
+	action := args[0]
+	var common commonFlags
+	usage, maxArgs := "region list [flags]", 0
+	if action == "set" {
+		usage, maxArgs = "region set [flags] <code>", 1
+	}
+	fs := newFlagSet(env, "region "+action, usage)
+	common.register(fs)
+	if err := parse(fs, args[1:], maxArgs); err != nil {
+		return err
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	if action == "list" {
+		for _, region := range regions.Catalog {
+			row := record{{"code", region.Code}, {"name", region.Name}, {"band", region.Band}, {"reader_band", ""}, {"channels", ""}}
+			if low, high, err := region.RangeKHz(); err == nil {
+				if band, minCh, maxCh, err := reader18.BandForRange(low, high); err == nil {
+					row[3].value = band.Name
+					row[4].value = fmt.Sprintf("%d-%d", minCh, maxCh)
+				}
+			}
+			if err := out.print(row); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if fs.NArg() != 1 {
+		return usagef("region set needs a region code (see region list)")
+	}
+	if _, ok := regions.Lookup(fs.Arg(0)); !ok {
+		return usagef("unknown region %q (see region list)", fs.Arg(0))
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	if err := client.SetRegion(ctx, fs.Arg(0)); err != nil {
+		return err
+	}
+	info, err := client.ReaderInfo(ctx)
+	if err != nil {
+		return err
+	}
+	return out.print(settingsRecord(info))
+}
+
+func runInventory(ctx context.Context, env *cliEnv, args []string) error {
+	var common commonFlags
+	fs := newFlagSet(env, "inventory", "inventory [flags]")
+	common.register(fs)
+	duration := fs.Duration("duration", 10*time.Second, "how long to read")
+	antennas := fs.String("antennas", "", "antenna ports to cycle, e.g. 1,2 (default 1)")
+	power := fs.Int("power", 0, "RF output power, 0-30 dBm (default keeps the SDK default)")
+	qValue := fs.Int("q", 0, "Gen2 Q value, 0-15")
+	session := fs.Int("session", 0, "Gen2 session, 0-3")
+	scanTime := fs.Int("scan-time", 0, "scan time per round in 100 ms units")
+	unique := fs.Bool("unique", false, "print each EPC only the first time it is read")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *duration <= 0 {
+		return usagef("--duration must be positive")
+	}
+
+	set := setFlags(fs)
+	cfg := sdk.DefaultInventoryConfig()
+	if set["antennas"] {
+		mask, err := parseAntennas(*antennas)
+		if err != nil {
+			return usageError{err.Error()}
+		}
+		cfg.AntennaMask = mask
+	}
+	for _, f := range []struct {
+		name     string
+		value    int
+		min, max int
+		dst      *byte
+	}{
+		{"power", *power, 0, 30, &cfg.OutputPower},
+		{"q", *qValue, 0, 15, &cfg.QValue},
+		{"session", *session, 0, 3, &cfg.Session},
+		{"scan-time", *scanTime, 1, 255, &cfg.ScanTime},
+	} {
+		if !set[f.name] {
+			continue
+		}
+		if f.value < f.min || f.value > f.max {
+			return usagef("--%s must be %d-%d", f.name, f.min, f.max)
+		}
+		*f.dst = byte(f.value)
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	cfg.ReaderAddress = client.Stats().ReaderAddr
+	client.SetInventoryConfig(cfg)
+	if err := client.StartInventory(ctx); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(*duration)
+	defer timer.Stop()
+	start := time.Now()
+	reads := 0
+	var runErr error
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case <-timer.C:
+			break loop
+		case tag := <-client.Tags():
+			reads++
+			if *unique && !tag.IsNew {
+				continue
+			}
+			if err := out.print(record{
+				{"time", tag.When.UTC().Format(time.RFC3339Nano)},
+				{"epc", tag.EPC},
+				{"antenna", tag.Antenna},
+				{"rssi", tag.RSSI},
+				{"new", tag.IsNew},
+				{"round", tag.Rounds},
+			}); err != nil {
+				runErr = err
+				break loop
+			}
+		case err := <-client.Errors():
+			fmt.Fprintf(env.stderr, "rfidctl inventory: %v\n", err)
+			if !client.Stats().Running {
+				runErr = err
+				break loop
+			}
+		}
+	}
+	_ = client.StopInventory()
+	stats := client.Stats()
+	fmt.Fprintf(env.stderr, "rfidctl inventory: %d reads, %d unique tags, %d rounds in %s\n",
+		reads, stats.UniqueTags, stats.Rounds, time.Since(start).Round(time.Millisecond))
+	return runErr
+}
+
+func runRead(ctx context.Context, env *cliEnv, args []string) error {
+	var common commonFlags
+	fs := newFlagSet(env, "read", "read --epc <hex> [flags]")
+	common.register(fs)
+	epc := fs.String("epc", "", "EPC (hex) of the tag to read")
+	bankName := fs.String("bank", "tid", "memory bank: reserved, epc, tid or user")
+	ptr := fs.Int("ptr", 0, "first word to read")
+	words := fs.Int("words", 6, "number of words to read, 1-120")
+	password := fs.String("password", "00000000", "access password (8 hex digits)")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *epc == "" {
+		return usagef("--epc is required")
+	}
+	if err := reader18.CheckReadWords(*words); err != nil {
+		return usageError{err.Error()}
+	}
+	bank, err := sdk.ParseMemoryBank(*bankName)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	pwd, err := parsePassword(*password)
+	if err != nil {
+		return err
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	data, err := client.ReadMemory(ctx, *epc, bank, *ptr, *words, pwd)
+	if err != nil {
+		return err
+	}
+	return out.print(record{
+		{"epc", strings.ToUpper(*epc)},
+		{"bank", bank.String()},
+		{"ptr", *ptr},
+		{"words", len(data) / 2},
+		{"data", strings.ToUpper(hex.EncodeToString(data))},
+	})
+}
+
+func runWriteEPC(ctx context.Context, env *cliEnv, args []string) error {
+	var common commonFlags
+	fs := newFlagSet(env, "write-epc", "write-epc --epc <hex> [flags]")
+	common.register(fs)
+	epc := fs.String("epc", "", "new EPC (hex, whole words)")
+	password := fs.String("password", "00000000", "access password (8 hex digits)")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *epc == "" {
+		return usagef("--epc is required")
+	}
+	pwd, err := parsePassword(*password)
+	if err != nil {
+		return err
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := client.WriteEPC(ctx, *epc, pwd); err != nil {
+		return err
+	}
+	return out.print(record{{"epc", strings.ToUpper(*epc)}, {"written", true}})
+}
+
+func runRaw(ctx context.Context, env *cliEnv, args []string) error {
+	var common commonFlags
+	fs := newFlagSet(env, "raw", "raw [flags] <hex frame>")
+	common.register(fs)
+	wait := fs.Duration("wait", time.Second, "how long to collect answers")
+	if err := parse(fs, args, 64); err != nil {
+		return err
+	}
+	packet, err := hex.DecodeString(strings.Join(fs.Args(), ""))
+	if err != nil || len(packet) == 0 {
+		return usagef("raw needs a hex frame, e.g. 04 00 21 D9 6A")
+	}
+	out, err := newPrinter(env.stdout, common.format)
+	if err != nil {
+		return usageError{err.Error()}
+	}
+	client, err := common.connect(ctx, env)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	responses, err := client.Raw(ctx, packet, *wait)
+	if err != nil {
+		return err
+	}
+	if len(responses) == 0 {
+		return fmt.Errorf("no answer within %s", *wait)
+	}
+	for _, r := range responses {
+		if err := out.print(record{
+			{"address", fmt.Sprintf("0x%02X", r.Address)},
+			{"command", fmt.Sprintf("0x%02X", r.Command)},
+			{"status", fmt.Sprintf("0x%02X", r.Status)},
+			{"data", strings.ToUpper(hex.EncodeToString(r.Data))},
+			{"raw", strings.ToUpper(hex.EncodeToString(r.Raw))},
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// settingsRecord is the reader-side configuration shared by info, config and region.
+func settingsRecord(info sdk.ReaderInfo) record {
+	band := info.Band
+	if band == "" {
+		band = fmt.Sprintf("unknown(%d)", info.BandID)
+	}
+	return record{
+		{"power", info.Power},
+		{"scan_time_ms", int(info.ScanTime) * 100},
+		{"band", band},
+		{"channels", fmt.Sprintf("%d-%d", info.MinChannel, info.MaxChannel)},
+		{"min_khz", info.MinFreqKHz},
+		{"max_khz", info.MaxFreqKHz},
+	}
+}
+
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// parseAntennas turns "1,2" into an antenna bitmask.
+func parseAntennas(raw string) (byte, error) {
+	var mask byte
+	for _, part := range splitList(raw) {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 8 {
+			return 0, fmt.Errorf("invalid antenna %q (1-8)", part)
+		}
+		mask |= 1 << (port - 1)
+	}
+	if mask == 0 {
+		return 0, fmt.Errorf("no antenna given")
+	}
+	return mask, nil
+}
+
+func parsePassword(raw string) (uint32, error) {
+	value, err := strconv.ParseUint(strings.TrimPrefix(strings.ToLower(raw), "0x"), 16, 32)
+	if err != nil {
+		return 0, usagef("invalid password %q (8 hex digits)", raw)
+	}
+	return uint32(value), nil
+}
+
+func splitList(raw string) []string {
+	var items []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
@@ -0,0 +1,199 @@
+// Command rfidctl drives a reader from scripts through the sdk package:
+// discovery, reader info and settings, timed inventory, tag memory access
+// and raw frames, with text, JSONL or CSV output.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/sdk"
+)
+
+// Exit codes.
+const (
+	exitOK     = 0
+	exitFailed = 1 // the reader or the network failed the request
+	exitUsage  = 2 // bad subcommand, flag or argument
+)
+
+// usageError marks errors that should print usage and exit with exitUsage.
+type usageError struct{ msg string }
+
+func (e usageError) Error() string { return e.msg }
+
+func usagef(format string, args ...any) error {
+	return usageError{fmt.Sprintf(format, args...)}
+}
+
+type command struct {
+	name    string
+	summary string
+	run     func(ctx context.Context, env *cliEnv, args []string) error
+}
+
+var commands = []command{
+	{"discover", "scan the LAN for readers", runDiscover},
+	{"info", "show firmware, band, power and scan time", runInfo},
+	{"inventory", "read tags for --duration and print each read", runInventory},
+	{"config", "get or set power, scan time and antennas", runConfig},
+	{"region", "list regions or set the reader's region", runRegion},
+	{"read", "read words from one memory bank of a tag", runRead},
+	{"write-epc", "write a new EPC to the single tag in the field", runWriteEPC},
+	{"raw", "send a hex frame and print every answer", runRaw},
+}
+
+// cliEnv carries the process streams so subcommands never touch os.Std*.
+type cliEnv struct {
+	stdout io.Writer
+	stderr io.Writer
+}
+
+func main() {
+	envFile := os.Getenv("BOT_ENV_FILE")
+	if envFile == "" {
+		envFile = ".env"
+	}
+	if err := config.LoadDotEnv(envFile); err != nil {
+		fmt.Fprintf(os.Stderr, "rfidctl: env load warning: %v\n", err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	code := run(ctx, os.Args[1:], os.Stdout, os.Stderr)
+	stop()
+	os.Exit(code)
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	env := &cliEnv{stdout: stdout, stderr: stderr}
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+		printUsage(stderr)
+		if len(args) == 0 {
+			return exitUsage
+		}
+		return exitOK
+	}
+	for _, cmd := range commands {
+		if cmd.name != args[0] {
+			continue
+		}
+		err := cmd.run(ctx, env, args[1:])
+		var usage usageError
+		switch {
+		case err == nil:
+			return exitOK
+		case errors.Is(err, flag.ErrHelp):
+			return exitOK
+		case errors.As(err, &usage):
+			fmt.Fprintf(stderr, "rfidctl %s: %v\n", cmd.name, err)
+			return exitUsage
+		}
+		fmt.Fprintf(stderr, "rfidctl %s: %v\n", cmd.name, err)
+		return exitFailed
+	}
+	fmt.Fprintf(stderr, "rfidctl: unknown command %q\n", args[0])
+	printUsage(stderr)
+	return exitUsage
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "usage: rfidctl <command> [flags]")
+	fmt.Fprintln(w, "")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w, "")
+	fmt.Fprintln(w, "Without --host the reader is found like the bot does: known readers first, then a LAN sweep.")
+	fmt.Fprintf(w, "exit status: %d ok, %d failed, %d usage error\n", exitOK, exitFailed, exitUsage)
+}
+
+// commonFlags are shared by every subcommand that talks to a reader.
+type commonFlags struct {
+	host    string
+	port    int
+	timeout time.Duration
+	format  string
+}
+
+func newFlagSet(env *cliEnv, name, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("rfidctl "+name, flag.ContinueOnError)
+	fs.SetOutput(env.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(env.stderr, "usage: rfidctl %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func (c *commonFlags) register(fs *flag.FlagSet) {
+	port, _ := strconv.Atoi(strings.TrimSpace(os.Getenv("BOT_READER_PORT")))
+	fs.StringVar(&c.host, "host", strings.TrimSpace(os.Getenv("BOT_READER_HOST")), "reader host (default BOT_READER_HOST, else discover)")
+	fs.IntVar(&c.port, "port", port, "reader TCP port (default BOT_READER_PORT)")
+	fs.DurationVar(&c.timeout, "timeout", 25*time.Second, "connect/discovery timeout")
+	fs.StringVar(&c.format, "format", "text", "output format: text, jsonl or csv")
+}
+
+// parse parses args and rejects positional arguments beyond maxArgs.
+func parse(fs *flag.FlagSet, args []string, maxArgs int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usageError{err.Error()}
+	}
+	if fs.NArg() > maxArgs {
+		return usagef("unexpected argument %q", fs.Arg(maxArgs))
+	}
+	return nil
+}
+
+// scanOptions mirrors the bot's discovery settings.
+func scanOptions() sdk.ScanOptions {
+	opts := sdk.DefaultScanOptions()
+	opts.KnownFile = "logs/known_readers.json"
+	if path, set := os.LookupEnv("BOT_READER_KNOWN_FILE"); set {
+		opts.KnownFile = strings.TrimSpace(path)
+	}
+	opts.Targets = envList("BOT_READER_SCAN_TARGETS")
+	opts.Exclude = envList("BOT_READER_SCAN_EXCLUDE")
+	opts.Interfaces = envList("BOT_READER_SCAN_INTERFACES")
+	return opts
+}
+
+// connect opens a client on --host/--port, or on the best discovered reader.
+func (c *commonFlags) connect(ctx context.Context, env *cliEnv) (*sdk.Client, error) {
+	if c.host != "" && c.port <= 0 {
+		return nil, usagef("--port is required with --host")
+	}
+	client := sdk.NewClient()
+	connectCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	if c.host != "" {
+		endpoint := sdk.Endpoint{Host: c.host, Port: c.port}
+		if err := client.Connect(connectCtx, endpoint, c.timeout); err != nil {
+			return nil, fmt.Errorf("connect %s: %w", endpoint.Address(), err)
+		}
+		return client, nil
+	}
+	chosen, err := client.QuickConnect(connectCtx, scanOptions())
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("no reader: %w", err)
+	}
+	fmt.Fprintf(env.stderr, "rfidctl: using %s\n", sdk.Endpoint{Host: chosen.Host, Port: chosen.Port}.Address())
+	return client, nil
+}
+
+func envList(key string) []string {
+	return splitList(os.Getenv(key))
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+)
+
+// fakeReader answers reader-info and read-data commands on loopback and
+// rejects everything else.
+func fakeReader(t *testing.T) string {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 256)
+				for {
+					n, err := conn.Read(buf)
+					if err != nil {
+						return
+					}
+					if n < 5 {
+						continue
+					}
+					payload := []byte{reader18.StatusCmdError}
+					switch buf[2] {
+					case reader18.CmdGetReaderInfo:
+						payload = []byte{reader18.StatusSuccess, 0x02, 0x05, 0x09, 0x03, 0x4E, 0x00, 0x1A, 0x0A}
+					case reader18.CmdReadData:
+						payload = []byte{reader18.StatusSuccess, 0xE2, 0x80, 0x11, 0x05}
+					}
+					if _, err := conn.Write(reader18.BuildCommand(0x00, buf[2], payload)); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
+
+// runCtl runs rfidctl with the reader environment cleared so flags decide.
+func runCtl(t *testing.T, args ...string) (int, string, string) {
+	t.Helper()
+	t.Setenv("BOT_READER_HOST", "")
+	t.Setenv("BOT_READER_PORT", "")
+	var stdout, stderr bytes.Buffer
+	code := run(context.Background(), args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "usage: rfidctl"},
+		{[]string{"bogus"}, `unknown command "bogus"`},
+		{[]string{"info", "--nope"}, "flag provided but not defined"},
+		{[]string{"info", "extra"}, `unexpected argument "extra"`},
+		{[]string{"info", "--format", "xml"}, `unknown format "xml"`},
+		{[]string{"info", "--host", "127.0.0.1"}, "--port is required"},
+		{[]string{"config"}, "config get|set"},
+		{[]string{"config", "get", "--power", "20"}, "takes no settings"},
+		{[]string{"config", "set"}, "needs --power"},
+		{[]string{"config", "set", "--antennas", "9"}, `invalid antenna "9"`},
+		{[]string{"region", "set"}, "needs a region code"},
+		{[]string{"region", "set", "XX"}, `unknown region "XX"`},
+		{[]string{"inventory", "--duration", "0s"}, "--duration must be positive"},
+		{[]string{"inventory", "--q", "16"}, "--q must be 0-15"},
+		{[]string{"read"}, "--epc is required"},
+		{[]string{"read", "--epc", "E200", "--words", "121"}, "word count must be 1-120, got 121"},
+		{[]string{"read", "--epc", "E200", "--bank", "4"}, "bank"},
+		{[]string{"write-epc", "--epc", "E200", "--password", "xyz"}, `invalid password "xyz"`},
+		{[]string{"raw", "zz"}, "raw needs a hex frame"},
+	} {
+		code, stdout, stderr := runCtl(t, tc.args...)
+		if code != exitUsage || stdout != "" || !strings.Contains(stderr, tc.want) {
+			t.Fatalf("%q: exit %d stdout %q stderr %q, want %q", tc.args, code, stdout, stderr, tc.want)
+		}
+	}
+}
+
+func TestRunHelpExitsOK(t *testing.T) {
+	for _, args := range [][]string{{"help"}, {"--help"}, {"info", "-h"}, {"read", "-h"}} {
+		if code, _, stderr := runCtl(t, args...); code != exitOK || !strings.Contains(stderr, "usage: rfidctl") {
+			t.Fatalf("%q: exit %d stderr %q", args, code, stderr)
+		}
+	}
+}
+
+func TestRunFailsWhenReaderIsUnreachable(t *testing.T) {
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+	ln.Close()
+
+	code, _, stderr := runCtl(t, "info", "--host", "127.0.0.1", "--port", port, "--timeout", "500ms")
+	if code != exitFailed || !strings.Contains(stderr, "connect 127.0.0.1:"+port) {
+		t.Fatalf("exit %d stderr %q", code, stderr)
+	}
+}
+
+func TestRunRegionListNeedsNoReader(t *testing.T) {
+	code, stdout, _ := runCtl(t, "region", "list", "--format", "csv")
+	if code != exitOK {
+		t.Fatalf("exit %d", code)
+	}
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(rows) < 2 {
+		t.Fatalf("csv %q: %v", stdout, err)
+	}
+	if strings.Join(rows[0], ",") != "code,name,band,reader_band,channels" {
+		t.Fatalf("header = %q", rows[0])
+	}
+}
+
+func TestRunInfoAndReadAgainstReader(t *testing.T) {
+	port := fakeReader(t)
+
+	code, stdout, stderr := runCtl(t, "info", "--host", "127.0.0.1", "--port", port, "--format", "jsonl")
+	if code != exitOK {
+		t.Fatalf("info: exit %d stderr %q", code, stderr)
+	}
+	var info map[string]any
+	if err := json.Unmarshal([]byte(stdout), &info); err != nil {
+		t.Fatalf("info: %v\n%s", err, stdout)
+	}
+	if info["endpoint"] != "127.0.0.1:"+port || info["firmware"] != "2.5" || info["band"] != "EU" || info["power"] != float64(26) {
+		t.Fatalf("unexpected info: %v", info)
+	}
+	if !strings.HasPrefix(stdout, `{"endpoint":`) {
+		t.Fatalf("fields out of order: %s", stdout)
+	}
+
+	code, stdout, stderr = runCtl(t, "read", "--host", "127.0.0.1", "--port", port, "--epc", "e2000017", "--words", "2")
+	if code != exitOK {
+		t.Fatalf("read: exit %d stderr %q", code, stderr)
+	}
+	if want := "epc=E2000017 bank=tid ptr=0 words=2 data=E2801105\n"; stdout != want {
+		t.Fatalf("read = %q, want %q", stdout, want)
+	}
+
+	code, stdout, stderr = runCtl(t, "raw", "--host", "127.0.0.1", "--port", port, "--wait", "200ms", "--format", "csv", "04", "00", "21", "D9", "6A")
+	if code != exitOK {
+		t.Fatalf("raw: exit %d stderr %q", code, stderr)
+	}
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil || len(rows) != 2 || rows[1][1] != "0x21" || rows[1][2] != "0x00" {
+		t.Fatalf("raw csv %q: %v", stdout, err)
+	}
+}
+
+func TestPrinterFormats(t *testing.T) {
+	r := record{{"epc", "E200"}, {"label", "dock door"}, {"empty", ""}, {"rssi", -61}}
+
+	var text bytes.Buffer
+	p, _ := newPrinter(&text, "text")
+	_ = p.print(r)
+	if want := "epc=E200 label=\"dock door\" empty=\"\" rssi=-61\n"; text.String() != want {
+		t.Fatalf("text = %q, want %q", text.String(), want)
+	}
+
+	var jsonl bytes.Buffer
+	p, _ = newPrinter(&jsonl, "jsonl")
+	_ = p.print(r)
+	if want := `{"epc":"E200","label":"dock door","empty":"","rssi":-61}` + "\n"; jsonl.String() != want {
+		t.Fatalf("jsonl = %q, want %q", jsonl.String(), want)
+	}
+
+	var out bytes.Buffer
+	p, _ = newPrinter(&out, "csv")
+	_ = p.print(r)
+	_ = p.print(r)
+	if want := "epc,label,empty,rssi\nE200,dock door,,-61\nE200,dock door,,-61\n"; out.String() != want {
+		t.Fatalf("csv = %q, want %q", out.String(), want)
+	}
+
+	if _, err := newPrinter(&out, "yaml"); err == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
+
+func TestParseAntennasAndPassword(t *testing.T) {
+	if mask, err := parseAntennas("1, 3,8"); err != nil || mask != 0x85 {
+		t.Fatalf("parseAntennas = %#x, %v", mask, err)
+	}
+	for _, raw := range []string{"", "0", "9", "a"} {
+		if _, err := parseAntennas(raw); err == nil {
+			t.Fatalf("parseAntennas(%q) accepted", raw)
+		}
+	}
+	if pwd, err := parsePassword("0x0000ABCD"); err != nil || pwd != 0xABCD {
+		t.Fatalf("parsePassword = %#x, %v", pwd, err)
+	}
+	if _, err := parsePassword("123456789"); err == nil {
+		t.Fatal("33-bit password accepted")
+	}
+}
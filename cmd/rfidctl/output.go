@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// field is one named value of an output record; records keep field order so
+// CSV columns and JSON keys come out the same way every time.
+type field struct {
+	key   string
+	value any
+}
+
+type record []field
+
+// printer writes records as text (key=value), JSONL or CSV. The CSV header
+// is taken from the first record.
+type printer struct {
+	w      io.Writer
+	format string
+	csv    *csv.Writer
+	header bool
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	switch format {
+	case "text", "jsonl":
+		return &printer{w: w, format: format}, nil
+	case "csv":
+		return &printer{w: w, format: format, csv: csv.NewWriter(w)}, nil
+	}
+	return nil, fmt.Errorf("unknown format %q (text, jsonl, csv)", format)
+}
+
+func (p *printer) print(r record) error {
+	switch p.format {
+	case "jsonl":
+		line, err := r.marshalJSON()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.w, "%s\n", line)
+		return err
+	case "csv":
+		if !p.header {
+			keys := make([]string, len(r))
+			for i, f := range r {
+				keys[i] = f.key
+			}
+			_ = p.csv.Write(keys)
+			p.header = true
+		}
+		values := make([]string, len(r))
+		for i, f := range r {
+			values[i] = fmt.Sprint(f.value)
+		}
+		_ = p.csv.Write(values)
+		p.csv.Flush()
+		return p.csv.Error()
+	}
+	parts := make([]string, len(r))
+	for i, f := range r {
+		value := fmt.Sprint(f.value)
+		if value == "" || strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		parts[i] = f.key + "=" + value
+	}
+	_, err := fmt.Fprintln(p.w, strings.Join(parts, " "))
+	return err
+}
+
+func (r record) marshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range r {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, _ := json.Marshal(f.key)
+		value, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.key, err)
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
@@ -2,52 +2,338 @@ package main
 
 import (
 	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io"
 	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"new_era_go/internal/discovery"
 )
 
+// Exit codes, for site-commissioning scripts.
+const (
+	exitFound    = 0 // at least one verified reader
+	exitNotFound = 1 // scan ran, no verified reader
+	exitFailed   = 2 // bad flags or the scan itself failed
+)
+
+type options struct {
+	scan       discovery.ScanOptions
+	timeout    time.Duration
+	verifyOnly bool
+	format     string
+}
+
+// ifaceInfo is one local IPv4 address; candidates on its subnet report it.
+type ifaceInfo struct {
+	Name   string       `json:"name"`
+	CIDR   string       `json:"cidr"`
+	prefix netip.Prefix `json:"-"`
+}
+
+type candidateRow struct {
+	Host          string  `json:"host"`
+	Port          int     `json:"port"`
+	Verified      bool    `json:"verified"`
+	Protocol      string  `json:"protocol,omitempty"`
+	ReaderAddress string  `json:"reader_address,omitempty"`
+	Score         int     `json:"score"`
+	Reason        string  `json:"reason"`
+	Banner        string  `json:"banner,omitempty"`
+	MAC           string  `json:"mac,omitempty"`
+	ProbeMS       float64 `json:"probe_ms"`
+	Interface     string  `json:"interface,omitempty"` // empty when routed
+}
+
+type report struct {
+	StartedAt  time.Time      `json:"started_at"`
+	DurationMS int64          `json:"duration_ms"`
+	Options    map[string]any `json:"options"`
+	Interfaces []ifaceInfo    `json:"interfaces"`
+	Error      string         `json:"error,omitempty"`
+	Verified   int            `json:"verified"`
+	Candidates []candidateRow `json:"candidates"`
+}
+
 func main() {
-	printLocalInterfaces()
-	fmt.Println("")
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	opts := discovery.DefaultOptions()
-	ctx, cancel := context.WithTimeout(context.Background(), 25*time.Second)
+func run(args []string, stdout, stderr io.Writer) int {
+	opts, err := parseFlags(args, stderr)
+	if err != nil {
+		if err == flag.ErrHelp {
+			return exitFound
+		}
+		fmt.Fprintf(stderr, "scancheck: %v\n", err)
+		return exitFailed
+	}
+	if opts.format == "text" {
+		opts.scan.Progress = func(p discovery.ScanProgress) { printProgress(stderr, p) }
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.timeout)
 	defer cancel()
 
+	rep := report{
+		StartedAt:  time.Now().UTC(),
+		Options:    optionSummary(opts),
+		Interfaces: localInterfaces(),
+		Candidates: []candidateRow{},
+	}
 	start := time.Now()
-	candidates, err := discovery.Scan(ctx, opts)
-	dur := time.Since(start)
+	candidates, scanErr := discovery.Scan(ctx, opts.scan)
+	rep.DurationMS = time.Since(start).Milliseconds()
+	if opts.format == "text" {
+		fmt.Fprintln(stderr)
+	}
+	if scanErr != nil {
+		rep.Error = scanErr.Error()
+		if opts.format == "csv" {
+			fmt.Fprintf(stderr, "scancheck: scan: %v\n", scanErr)
+		}
+	}
+	for _, c := range candidates {
+		if c.Verified {
+			rep.Verified++
+		} else if opts.verifyOnly {
+			continue
+		}
+		rep.Candidates = append(rep.Candidates, toRow(c, rep.Interfaces))
+	}
+
+	switch opts.format {
+	case "json":
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(rep)
+	case "csv":
+		err = writeCSV(stdout, rep.Candidates)
+	default:
+		writeText(stdout, rep)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "scancheck: write output: %v\n", err)
+		return exitFailed
+	}
+
+	switch {
+	case rep.Verified > 0:
+		return exitFound
+	case scanErr != nil && len(candidates) == 0:
+		return exitFailed
+	}
+	return exitNotFound
+}
+
+func parseFlags(args []string, stderr io.Writer) (options, error) {
+	defaults := discovery.DefaultOptions()
+	fs := flag.NewFlagSet("scancheck", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, "usage: scancheck [flags]")
+		fmt.Fprintf(stderr, "exit status: %d verified reader found, %d none found, %d error\n", exitFound, exitNotFound, exitFailed)
+		fs.PrintDefaults()
+	}
+
+	ports := fs.String("ports", joinInts(defaults.Ports), "comma-separated TCP ports to probe")
+	targets := fs.String("targets", "", "CIDRs, ranges (10.20.0.5-40) or addresses to sweep instead of the local subnets")
+	exclude := fs.String("exclude", "", "CIDRs, ranges or addresses never probed")
+	ifaces := fs.String("iface", "", "comma-separated interfaces to sweep (default all)")
+	timeout := fs.Duration("timeout", 25*time.Second, "overall scan timeout")
+	probeTimeout := fs.Duration("probe-timeout", defaults.Timeout, "per-endpoint connect timeout")
+	concurrency := fs.Int("concurrency", defaults.Concurrency, "parallel probes")
+	verifyOnly := fs.Bool("verify-only", false, "list only endpoints that answered the reader protocol")
+	asJSON := fs.Bool("json", false, "write a JSON report to stdout")
+	asCSV := fs.Bool("csv", false, "write candidates as CSV to stdout")
+	if err := fs.Parse(args); err != nil {
+		return options{}, err
+	}
+	if fs.NArg() > 0 {
+		return options{}, fmt.Errorf("unexpected argument %q", fs.Arg(0))
+	}
+
+	opts := options{scan: defaults, timeout: *timeout, verifyOnly: *verifyOnly, format: "text"}
+	switch {
+	case *asJSON && *asCSV:
+		return options{}, fmt.Errorf("-json and -csv are exclusive")
+	case *asJSON:
+		opts.format = "json"
+	case *asCSV:
+		opts.format = "csv"
+	}
+	if *timeout <= 0 || *probeTimeout <= 0 {
+		return options{}, fmt.Errorf("timeouts must be positive")
+	}
+	if *concurrency < 1 {
+		return options{}, fmt.Errorf("-concurrency must be at least 1")
+	}
 
-	fmt.Printf("scan duration: %s\n", dur.Round(time.Millisecond))
+	parsed, err := parsePorts(*ports)
 	if err != nil {
-		fmt.Printf("scan error: %v\n", err)
+		return options{}, err
+	}
+	opts.scan.Ports = parsed
+	opts.scan.Timeout = *probeTimeout
+	opts.scan.Concurrency = *concurrency
+	opts.scan.Targets = splitList(*targets)
+	opts.scan.Exclude = splitList(*exclude)
+	opts.scan.Interfaces = splitList(*ifaces)
+	return opts, nil
+}
+
+func parsePorts(raw string) ([]int, error) {
+	var ports []int
+	for _, part := range splitList(raw) {
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 65535 {
+			return nil, fmt.Errorf("invalid port %q", part)
+		}
+		ports = append(ports, port)
+	}
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("-ports is empty")
 	}
-	fmt.Printf("candidates: %d\n", len(candidates))
+	return ports, nil
+}
 
-	for i, candidate := range candidates {
-		fmt.Printf("%2d) %s:%d verified=%v addr=0x%02X proto=%s score=%d reason=%s banner=%q\n",
+func splitList(raw string) []string {
+	var items []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+func joinInts(values []int) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = strconv.Itoa(v)
+	}
+	return strings.Join(parts, ",")
+}
+
+func optionSummary(opts options) map[string]any {
+	return map[string]any{
+		"ports":            opts.scan.Ports,
+		"targets":          opts.scan.Targets,
+		"exclude":          opts.scan.Exclude,
+		"interfaces":       opts.scan.Interfaces,
+		"timeout_ms":       opts.timeout.Milliseconds(),
+		"probe_timeout_ms": opts.scan.Timeout.Milliseconds(),
+		"concurrency":      opts.scan.Concurrency,
+		"verify_only":      opts.verifyOnly,
+	}
+}
+
+func toRow(c discovery.Candidate, ifaces []ifaceInfo) candidateRow {
+	row := candidateRow{
+		Host:     c.Host,
+		Port:     c.Port,
+		Verified: c.Verified,
+		Protocol: c.Protocol,
+		Score:    c.Score,
+		Reason:   c.Reason,
+		Banner:   c.Banner,
+		MAC:      c.MAC,
+		ProbeMS:  float64(c.ProbeTime.Microseconds()) / 1000,
+	}
+	if c.Verified {
+		row.ReaderAddress = fmt.Sprintf("0x%02X", c.ReaderAddress)
+	}
+	if addr, err := netip.ParseAddr(c.Host); err == nil {
+		for _, iface := range ifaces {
+			if iface.prefix.Contains(addr) {
+				row.Interface = iface.Name
+				break
+			}
+		}
+	}
+	return row
+}
+
+func writeCSV(w io.Writer, rows []candidateRow) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"host", "port", "verified", "protocol", "reader_address", "score", "probe_ms", "interface", "mac", "reason", "banner"})
+	for _, r := range rows {
+		_ = cw.Write([]string{
+			r.Host,
+			strconv.Itoa(r.Port),
+			strconv.FormatBool(r.Verified),
+			r.Protocol,
+			r.ReaderAddress,
+			strconv.Itoa(r.Score),
+			strconv.FormatFloat(r.ProbeMS, 'f', 1, 64),
+			r.Interface,
+			r.MAC,
+			r.Reason,
+			r.Banner,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeText(w io.Writer, rep report) {
+	fmt.Fprintln(w, "local interfaces:")
+	for _, iface := range rep.Interfaces {
+		fmt.Fprintf(w, "  - %s : %s\n", iface.Name, iface.CIDR)
+	}
+	fmt.Fprintln(w, "")
+
+	fmt.Fprintf(w, "scan duration: %s\n", time.Duration(rep.DurationMS)*time.Millisecond)
+	if rep.Error != "" {
+		fmt.Fprintf(w, "scan error: %s\n", rep.Error)
+	}
+	fmt.Fprintf(w, "candidates: %d (verified: %d)\n", len(rep.Candidates), rep.Verified)
+
+	for i, c := range rep.Candidates {
+		iface := c.Interface
+		if iface == "" {
+			iface = "routed"
+		}
+		fmt.Fprintf(w, "%2d) %s:%d verified=%v addr=%s proto=%s score=%d probe=%.1fms iface=%s reason=%s banner=%q\n",
 			i+1,
-			candidate.Host,
-			candidate.Port,
-			candidate.Verified,
-			candidate.ReaderAddress,
-			candidate.Protocol,
-			candidate.Score,
-			candidate.Reason,
-			candidate.Banner,
+			c.Host,
+			c.Port,
+			c.Verified,
+			c.ReaderAddress,
+			c.Protocol,
+			c.Score,
+			c.ProbeMS,
+			iface,
+			c.Reason,
+			c.Banner,
 		)
 	}
 }
 
-func printLocalInterfaces() {
-	fmt.Println("local interfaces:")
+// printProgress redraws one progress line on stderr.
+func printProgress(w io.Writer, p discovery.ScanProgress) {
+	const width = 30
+	filled := 0
+	if p.Total > 0 {
+		filled = min(p.Probed*width/p.Total, width)
+	}
+	fmt.Fprintf(w, "\rscanning [%s%s] %d/%d hosts, %d open, ETA %-6s",
+		strings.Repeat("#", filled), strings.Repeat(".", width-filled),
+		p.Probed, p.Total, p.Found, p.ETA.Round(time.Second))
+}
+
+func localInterfaces() []ifaceInfo {
+	out := []ifaceInfo{}
 	ifaces, err := net.Interfaces()
 	if err != nil {
-		fmt.Printf("  error: %v\n", err)
-		return
+		return out
 	}
 
 	for _, iface := range ifaces {
@@ -59,9 +345,16 @@ func printLocalInterfaces() {
 			continue
 		}
 		for _, addr := range addrs {
-			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() != nil {
-				fmt.Printf("  - %s : %s\n", iface.Name, ipNet.String())
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || ipNet.IP.To4() == nil {
+				continue
+			}
+			prefix, err := netip.ParsePrefix(ipNet.String())
+			if err != nil {
+				continue
 			}
+			out = append(out, ifaceInfo{Name: iface.Name, CIDR: prefix.String(), prefix: prefix.Masked()})
 		}
 	}
+	return out
 }
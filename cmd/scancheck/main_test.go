@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"new_era_go/internal/protocol/reader18"
+)
+
+// listen starts a loopback TCP server; reader makes it answer every request
+// with a GetReaderInfo response, otherwise it hangs up right after accepting.
+func listen(t *testing.T, reader bool) string {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			if !reader {
+				conn.Close()
+				continue
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 256)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						return
+					}
+					reply := reader18.BuildCommand(reader18.DefaultReaderAddress, reader18.CmdGetReaderInfo, []byte{reader18.StatusSuccess, 0x01, 0x02})
+					if _, err := conn.Write(reply); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	return strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+}
+
+func runScancheck(args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := run(args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+func TestParseFlags(t *testing.T) {
+	opts, err := parseFlags([]string{"-ports", "6000, 2022", "-targets", "10.20.0.5-40,10.30.0.0/24", "-exclude", "10.20.0.9", "-iface", "eth0", "-probe-timeout", "300ms", "-concurrency", "8", "-verify-only", "-csv"}, &bytes.Buffer{})
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if opts.format != "csv" || !opts.verifyOnly || opts.scan.Concurrency != 8 || opts.scan.Timeout != 300*time.Millisecond {
+		t.Fatalf("unexpected options: %+v", opts)
+	}
+	if got := joinInts(opts.scan.Ports); got != "6000,2022" {
+		t.Fatalf("ports = %s", got)
+	}
+	if strings.Join(opts.scan.Targets, " ") != "10.20.0.5-40 10.30.0.0/24" || strings.Join(opts.scan.Exclude, " ") != "10.20.0.9" || strings.Join(opts.scan.Interfaces, " ") != "eth0" {
+		t.Fatalf("unexpected lists: %+v", opts.scan)
+	}
+
+	if opts, err := parseFlags(nil, &bytes.Buffer{}); err != nil || opts.format != "text" || opts.timeout != 25*time.Second {
+		t.Fatalf("defaults: %+v %v", opts, err)
+	}
+
+	for _, args := range [][]string{
+		{"-json", "-csv"},
+		{"-ports", "0"},
+		{"-ports", ""},
+		{"-timeout", "0s"},
+		{"-concurrency", "0"},
+		{"extra"},
+	} {
+		if _, err := parseFlags(args, &bytes.Buffer{}); err == nil {
+			t.Fatalf("expected an error for %q", args)
+		}
+	}
+}
+
+func TestRunJSONReportsVerifiedReader(t *testing.T) {
+	port := listen(t, true)
+	code, stdout, _ := runScancheck("-targets", "127.0.0.1", "-ports", port, "-timeout", "5s", "-json")
+	if code != exitFound {
+		t.Fatalf("exit %d, want %d", code, exitFound)
+	}
+
+	var rep report
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil {
+		t.Fatalf("decode: %v\n%s", err, stdout)
+	}
+	if rep.Verified != 1 || len(rep.Candidates) != 1 || rep.Error != "" {
+		t.Fatalf("unexpected report: %+v", rep)
+	}
+	c := rep.Candidates[0]
+	if c.Host != "127.0.0.1" || strconv.Itoa(c.Port) != port || !c.Verified || c.ReaderAddress != "0x00" {
+		t.Fatalf("unexpected candidate: %+v", c)
+	}
+	if rep.Options["verify_only"] != false || rep.Options["timeout_ms"] != float64(5000) {
+		t.Fatalf("unexpected options: %v", rep.Options)
+	}
+}
+
+func TestRunCSVWithoutVerifiedReader(t *testing.T) {
+	port := listen(t, false)
+	code, stdout, _ := runScancheck("-targets", "127.0.0.1", "-ports", port, "-probe-timeout", "200ms", "-timeout", "5s", "-csv")
+	if code != exitNotFound {
+		t.Fatalf("exit %d, want %d", code, exitNotFound)
+	}
+
+	rows, err := csv.NewReader(strings.NewReader(stdout)).ReadAll()
+	if err != nil {
+		t.Fatalf("csv: %v\n%s", err, stdout)
+	}
+	if len(rows) != 2 || rows[0][0] != "host" || len(rows[1]) != len(rows[0]) {
+		t.Fatalf("unexpected csv: %q", rows)
+	}
+	if rows[1][0] != "127.0.0.1" || rows[1][1] != port || rows[1][2] != "false" {
+		t.Fatalf("unexpected row: %q", rows[1])
+	}
+
+	// -verify-only drops the unverified endpoint but keeps the exit status.
+	code, stdout, _ = runScancheck("-targets", "127.0.0.1", "-ports", port, "-probe-timeout", "200ms", "-timeout", "5s", "-csv", "-verify-only")
+	if code != exitNotFound || strings.Count(stdout, "\n") != 1 {
+		t.Fatalf("verify-only: exit %d output %q", code, stdout)
+	}
+}
+
+func TestRunFailsOnBadFlagsAndScanErrors(t *testing.T) {
+	if code, _, stderr := runScancheck("-json", "-csv"); code != exitFailed || !strings.Contains(stderr, "exclusive") {
+		t.Fatalf("bad flags: exit %d stderr %q", code, stderr)
+	}
+	if code, _, stderr := runScancheck("-nope"); code != exitFailed || !strings.Contains(stderr, "usage: scancheck") {
+		t.Fatalf("unknown flag: exit %d stderr %q", code, stderr)
+	}
+	if code, _, _ := runScancheck("-h"); code != exitFound {
+		t.Fatalf("-h: exit %d, want %d", code, exitFound)
+	}
+
+	code, stdout, _ := runScancheck("-targets", "not-a-host", "-json")
+	if code != exitFailed {
+		t.Fatalf("scan error: exit %d, want %d", code, exitFailed)
+	}
+	var rep report
+	if err := json.Unmarshal([]byte(stdout), &rep); err != nil || rep.Error == "" {
+		t.Fatalf("expected the scan error in the report, got %q (%v)", stdout, err)
+	}
+}
@@ -0,0 +1,236 @@
+package discovery
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KnownReader is a reader remembered from an earlier scan.
+type KnownReader struct {
+	Host          string    `json:"host"`
+	Port          int       `json:"port"`
+	ReaderAddress byte      `json:"reader_address"`
+	Protocol      string    `json:"protocol,omitempty"`
+	MAC           string    `json:"mac,omitempty"`
+	LastSeen      time.Time `json:"last_seen"`
+	Label         string    `json:"label,omitempty"`
+	Pinned        bool      `json:"pinned,omitempty"`
+}
+
+// Key identifies the reader: its MAC when known, otherwise host:port.
+func (k KnownReader) Key() string {
+	if k.MAC != "" {
+		return k.MAC
+	}
+	return net.JoinHostPort(k.Host, strconv.Itoa(k.Port))
+}
+
+// CandidateKey returns the registry key a candidate is stored under.
+func CandidateKey(c Candidate) string {
+	return KnownReader{Host: c.Host, Port: c.Port, MAC: c.MAC}.Key()
+}
+
+// Registry is the persistent list of known readers, stored as JSON at path.
+// It is safe for concurrent use.
+type Registry struct {
+	path string
+
+	mu      sync.Mutex
+	readers []KnownReader
+}
+
+// OpenRegistry loads the registry at path. A missing file is an empty registry.
+func OpenRegistry(path string) (*Registry, error) {
+	r := &Registry{path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &r.readers); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// List returns the known readers, pinned first, then most recently seen.
+func (r *Registry) List() []KnownReader {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := append([]KnownReader(nil), r.readers...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Pinned != out[j].Pinned {
+			return out[i].Pinned
+		}
+		return out[i].LastSeen.After(out[j].LastSeen)
+	})
+	return out
+}
+
+// Lookup returns the entry stored under key.
+func (r *Registry) Lookup(key string) (KnownReader, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if i := r.indexLocked(key); i >= 0 {
+		return r.readers[i], true
+	}
+	return KnownReader{}, false
+}
+
+// Remember records every verified candidate as seen at now. A reader that
+// moved keeps its label and pin as long as its MAC is known.
+func (r *Registry) Remember(candidates []Candidate, now time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changed := false
+	for _, c := range candidates {
+		if !c.Verified {
+			continue
+		}
+		r.upsertLocked(c).LastSeen = now
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+	return r.saveLocked()
+}
+
+// Pin marks the candidate's reader as pinned, adding it when unknown.
+func (r *Registry) Pin(c Candidate, pinned bool) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upsertLocked(c).Pinned = pinned
+	return r.saveLocked()
+}
+
+// Rename sets the user label of the candidate's reader, adding it when unknown.
+func (r *Registry) Rename(c Candidate, label string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.upsertLocked(c).Label = label
+	return r.saveLocked()
+}
+
+// Forget removes the entry stored under key.
+func (r *Registry) Forget(key string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	i := r.indexLocked(key)
+	if i < 0 {
+		return fmt.Errorf("reader %s is not known", key)
+	}
+	r.readers = append(r.readers[:i], r.readers[i+1:]...)
+	return r.saveLocked()
+}
+
+// annotate copies label and pin state onto candidates that are known.
+func (r *Registry) annotate(candidates []Candidate) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range candidates {
+		idx := r.indexLocked(CandidateKey(candidates[i]))
+		if idx < 0 && candidates[i].MAC != "" {
+			idx = r.indexLocked(net.JoinHostPort(candidates[i].Host, strconv.Itoa(candidates[i].Port)))
+		}
+		if idx < 0 {
+			continue
+		}
+		candidates[i].Known = true
+		candidates[i].Label = r.readers[idx].Label
+		candidates[i].Pinned = r.readers[idx].Pinned
+	}
+}
+
+func (r *Registry) indexLocked(key string) int {
+	for i, k := range r.readers {
+		if k.Key() == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// upsertLocked returns the entry for c, creating it or refreshing its address.
+// An entry stored by host:port before the MAC was known is upgraded.
+func (r *Registry) upsertLocked(c Candidate) *KnownReader {
+	idx := r.indexLocked(CandidateKey(c))
+	if idx < 0 && c.MAC != "" {
+		idx = r.indexLocked(net.JoinHostPort(c.Host, strconv.Itoa(c.Port)))
+	}
+	if idx < 0 {
+		r.readers = append(r.readers, KnownReader{})
+		idx = len(r.readers) - 1
+	}
+	k := &r.readers[idx]
+	k.Host, k.Port = c.Host, c.Port
+	if c.MAC != "" {
+		k.MAC = c.MAC
+	}
+	if c.Verified {
+		k.ReaderAddress, k.Protocol = c.ReaderAddress, c.Protocol
+	}
+	return k
+}
+
+func (r *Registry) saveLocked() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.readers, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, r.path)
+}
+
+// verifyKnown probes every known reader with the reader protocol handshake
+// and returns those that answered.
+func verifyKnown(ctx context.Context, known []KnownReader, timeout time.Duration) []Candidate {
+	results := make([]*Candidate, len(known))
+	var wg sync.WaitGroup
+	for i, k := range known {
+		addr, err := netip.ParseAddr(k.Host)
+		if err != nil || !addr.Is4() {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, ok := probeTarget(ctx, addr, k.Port, timeout)
+			if ok && c.Verified {
+				c.Reason = "known reader, " + c.Reason
+				c.MAC = k.MAC
+				results[i] = &c
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := make([]Candidate, 0, len(known))
+	for _, c := range results {
+		if c != nil {
+			out = append(out, *c)
+		}
+	}
+	return out
+}
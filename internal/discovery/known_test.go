@@ -0,0 +1,134 @@
+package discovery
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"new_era_go/internal/protocol/reader18"
+)
+
+// fakeReader answers every request with a GetReaderInfo response.
+func fakeReader(t *testing.T) (string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp4", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				buf := make([]byte, 256)
+				for {
+					if _, err := conn.Read(buf); err != nil {
+						return
+					}
+					reply := reader18.BuildCommand(reader18.DefaultReaderAddress, reader18.CmdGetReaderInfo, []byte{reader18.StatusSuccess, 0x01, 0x02})
+					if _, err := conn.Write(reply); err != nil {
+						return
+					}
+				}
+			}()
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port
+}
+
+func TestRegistryPersistsPinLabelAndForget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known.json")
+	reg, err := OpenRegistry(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	seen := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	dock := Candidate{Host: "192.168.1.50", Port: 6000, Verified: true, Protocol: "reader18", MAC: "aa:bb:cc:00:00:01"}
+	gate := Candidate{Host: "192.168.1.60", Port: 2022, Verified: true, Protocol: "reader18"}
+	open := Candidate{Host: "192.168.1.70", Port: 80}
+	if err := reg.Remember([]Candidate{dock, gate, open}, seen); err != nil {
+		t.Fatalf("remember: %v", err)
+	}
+	if err := reg.Pin(gate, true); err != nil {
+		t.Fatalf("pin: %v", err)
+	}
+	if err := reg.Rename(dock, "Dock 1"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	reg, err = OpenRegistry(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	list := reg.List()
+	if len(list) != 2 {
+		t.Fatalf("expected 2 known readers (unverified skipped), got %+v", list)
+	}
+	if !list[0].Pinned || list[0].Host != gate.Host {
+		t.Fatalf("expected pinned gate first, got %+v", list[0])
+	}
+	if list[1].Label != "Dock 1" || !list[1].LastSeen.Equal(seen) {
+		t.Fatalf("unexpected dock entry: %+v", list[1])
+	}
+
+	// The dock moved; its MAC keeps the label on the same entry.
+	moved := dock
+	moved.Host = "192.168.1.51"
+	if err := reg.Remember([]Candidate{moved}, seen.Add(time.Hour)); err != nil {
+		t.Fatalf("remember moved: %v", err)
+	}
+	if k, ok := reg.Lookup(dock.MAC); !ok || k.Host != moved.Host || k.Label != "Dock 1" {
+		t.Fatalf("expected moved dock to keep its label, got %+v ok=%v", k, ok)
+	}
+
+	if err := reg.Forget(CandidateKey(gate)); err != nil {
+		t.Fatalf("forget: %v", err)
+	}
+	if err := reg.Forget(CandidateKey(gate)); err == nil {
+		t.Fatal("expected error forgetting an unknown reader")
+	}
+	reg, _ = OpenRegistry(path)
+	if len(reg.List()) != 1 {
+		t.Fatalf("expected 1 reader after forget, got %+v", reg.List())
+	}
+}
+
+func TestScanVerifiesKnownReadersBeforeSweeping(t *testing.T) {
+	host, port := fakeReader(t)
+	reg, err := OpenRegistry(filepath.Join(t.TempDir(), "known.json"))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	known := Candidate{Host: host, Port: port, Verified: true, Protocol: "reader18"}
+	if err := reg.Rename(known, "Gate"); err != nil {
+		t.Fatalf("rename: %v", err)
+	}
+
+	opts := DefaultOptions()
+	opts.Known = reg
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	candidates, err := Scan(ctx, opts)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(candidates) != 1 {
+		t.Fatalf("expected only the known reader, got %+v", candidates)
+	}
+	got := candidates[0]
+	if got.Host != host || got.Port != port || !got.Verified || !got.Known || got.Label != "Gate" {
+		t.Fatalf("unexpected candidate: %+v", got)
+	}
+	k, ok := reg.Lookup(net.JoinHostPort(host, strconv.Itoa(port)))
+	if !ok || k.LastSeen.IsZero() {
+		t.Fatalf("expected last seen to be recorded, got %+v ok=%v", k, ok)
+	}
+}
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/netip"
 	"os"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
@@ -28,6 +29,14 @@ type Candidate struct {
 	Verified      bool
 	ReaderAddress byte
 	Protocol      string
+	// ProbeTime covers the TCP connect and the protocol handshake.
+	ProbeTime time.Duration
+	// MAC is carried over from a known-reader entry that has one.
+	MAC string
+	// Known, Label and Pinned come from the known-reader registry.
+	Known  bool
+	Label  string
+	Pinned bool
 }
 
 // ScanOptions controls LAN discovery behavior.
@@ -36,8 +45,27 @@ type ScanOptions struct {
 	Timeout               time.Duration
 	Concurrency           int
 	HostLimitPerInterface int
+	// Known readers are verified first; the LAN is swept only when none of
+	// them answers or ForceSweep is set. Verified results are remembered.
+	Known      *Registry
+	ForceSweep bool
+	// Targets replaces the local interface sweep with CIDRs ("10.20.0.0/22"),
+	// ranges ("10.20.0.10-10.20.0.50" or "10.20.0.10-50") or addresses, which
+	// may be on routed subnets.
+	Targets []string
+	// Exclude lists CIDRs, ranges or addresses that are never probed.
+	Exclude []string
+	// Interfaces limits the local sweep to the named interfaces.
+	Interfaces []string
+	// Progress is called from one goroutine while hosts are probed and once
+	// more when the sweep ends. It must not block.
+	Progress func(ScanProgress)
 }
 
+// knownDialTimeout is the minimum dial timeout for known readers, which may
+// sit on a routed subnet.
+const knownDialTimeout = time.Second
+
 func DefaultOptions() ScanOptions {
 	return ScanOptions{
 		Ports: []int{
@@ -64,72 +92,40 @@ func Scan(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 		opts.HostLimitPerInterface = DefaultOptions().HostLimitPerInterface
 	}
 
-	prefixes, localIPs, err := localPrefixes()
-	if err != nil {
-		return nil, err
-	}
-	if len(prefixes) == 0 {
-		return nil, fmt.Errorf("no active IPv4 network interfaces found")
-	}
-
-	hosts := make([]netip.Addr, 0, 512)
-	seenHosts := make(map[string]struct{}, 512)
-	skipLocal := make(map[string]struct{}, len(localIPs))
-	for _, ip := range localIPs {
-		skipLocal[ip.String()] = struct{}{}
-	}
-
-	for _, prefix := range prefixes {
-		for _, host := range hostsFromPrefix(prefix, opts.HostLimitPerInterface) {
-			if _, skip := skipLocal[host.String()]; skip {
-				continue
+	if opts.Known != nil && !opts.ForceSweep {
+		if known := opts.Known.List(); len(known) > 0 {
+			candidates := verifyKnown(ctx, known, max(opts.Timeout, knownDialTimeout))
+			if len(candidates) > 0 {
+				// A registry that cannot be saved must not fail the scan.
+				_ = opts.Known.Remember(candidates, time.Now())
+				opts.Known.annotate(candidates)
+				rankCandidates(candidates)
+				return candidates, nil
 			}
-			key := host.String()
-			if _, exists := seenHosts[key]; exists {
-				continue
-			}
-			seenHosts[key] = struct{}{}
-			hosts = append(hosts, host)
-		}
-	}
-
-	for _, host := range arpNeighbors() {
-		if _, skip := skipLocal[host.String()]; skip {
-			continue
 		}
-		key := host.String()
-		if _, exists := seenHosts[key]; exists {
-			continue
-		}
-		seenHosts[key] = struct{}{}
-		hosts = append(hosts, host)
 	}
 
-	for _, host := range defaultCandidateIPs(localIPs) {
-		if _, skip := skipLocal[host.String()]; skip {
-			continue
-		}
-		key := host.String()
-		if _, exists := seenHosts[key]; exists {
-			continue
-		}
-		seenHosts[key] = struct{}{}
-		hosts = append(hosts, host)
+	hosts, err := sweepHosts(opts)
+	if err != nil {
+		return nil, err
 	}
 
 	type target struct {
-		host netip.Addr
-		port int
+		index int
+		host  netip.Addr
+		port  int
 	}
 
 	jobs := make(chan target)
 	results := make(chan Candidate, opts.Concurrency)
 	var wg sync.WaitGroup
+	progress := newProgressTracker(len(hosts), len(opts.Ports))
 
 	worker := func() {
 		defer wg.Done()
 		for t := range jobs {
 			candidate, ok := probeTarget(ctx, t.host, t.port, opts.Timeout)
+			progress.probeDone(t.index, ok)
 			if !ok {
 				continue
 			}
@@ -148,10 +144,10 @@ func Scan(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 
 	go func() {
 		defer close(jobs)
-		for _, host := range hosts {
+		for i, host := range hosts {
 			for _, port := range opts.Ports {
 				select {
-				case jobs <- target{host: host, port: port}:
+				case jobs <- target{index: i, host: host, port: port}:
 				case <-ctx.Done():
 					return
 				}
@@ -159,11 +155,32 @@ func Scan(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 		}
 	}()
 
+	sweepDone := make(chan struct{})
 	go func() {
 		wg.Wait()
+		close(sweepDone)
 		close(results)
 	}()
 
+	reported := make(chan struct{})
+	go func() {
+		defer close(reported)
+		if opts.Progress == nil {
+			return
+		}
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				opts.Progress(progress.snapshot())
+			case <-sweepDone:
+				opts.Progress(progress.snapshot())
+				return
+			}
+		}
+	}()
+
 	candidates := make([]Candidate, 0, 16)
 	seenCandidates := make(map[string]struct{}, 16)
 	for candidate := range results {
@@ -174,8 +191,87 @@ func Scan(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 		seenCandidates[key] = struct{}{}
 		candidates = append(candidates, candidate)
 	}
+	<-reported
+
+	if opts.Known != nil {
+		_ = opts.Known.Remember(candidates, time.Now())
+		opts.Known.annotate(candidates)
+	}
+	rankCandidates(candidates)
+
+	if ctx.Err() != nil {
+		return candidates, ctx.Err()
+	}
 
+	return candidates, nil
+}
+
+// sweepHosts lists the hosts Scan probes: opts.Targets when set, otherwise
+// the subnets of the selected interfaces plus, for an unrestricted sweep, ARP
+// neighbors and common reader addresses. Local addresses and opts.Exclude
+// are skipped.
+func sweepHosts(opts ScanOptions) ([]netip.Addr, error) {
+	exclude, err := parseTargets(opts.Exclude)
+	if err != nil {
+		return nil, err
+	}
+	prefixes, localIPs, err := localPrefixes(opts.Interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups [][]netip.Addr
+	if len(opts.Targets) > 0 {
+		ranges, err := parseTargets(opts.Targets)
+		if err != nil {
+			return nil, err
+		}
+		targets, err := hostsFromTargets(ranges)
+		if err != nil {
+			return nil, err
+		}
+		groups = append(groups, targets)
+	} else {
+		if len(prefixes) == 0 {
+			if len(opts.Interfaces) > 0 {
+				return nil, fmt.Errorf("no active IPv4 address on interface(s) %s", strings.Join(opts.Interfaces, ", "))
+			}
+			return nil, fmt.Errorf("no active IPv4 network interfaces found")
+		}
+		for _, prefix := range prefixes {
+			groups = append(groups, hostsFromPrefix(prefix, opts.HostLimitPerInterface))
+		}
+		if len(opts.Interfaces) == 0 {
+			groups = append(groups, arpNeighbors(), defaultCandidateIPs(localIPs))
+		}
+	}
+
+	skip := make(map[netip.Addr]struct{}, 512)
+	for _, ip := range localIPs {
+		skip[ip] = struct{}{}
+	}
+	hosts := make([]netip.Addr, 0, 512)
+	for _, group := range groups {
+		for _, host := range group {
+			if _, exists := skip[host]; exists || excluded(exclude, host) {
+				continue
+			}
+			skip[host] = struct{}{}
+			hosts = append(hosts, host)
+		}
+	}
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts left to scan after exclusions")
+	}
+	return hosts, nil
+}
+
+// rankCandidates sorts pinned readers first, then by score.
+func rankCandidates(candidates []Candidate) {
 	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].Pinned != candidates[j].Pinned {
+			return candidates[i].Pinned
+		}
 		if candidates[i].Score != candidates[j].Score {
 			return candidates[i].Score > candidates[j].Score
 		}
@@ -184,15 +280,10 @@ func Scan(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 		}
 		return candidates[i].Port < candidates[j].Port
 	})
-
-	if ctx.Err() != nil {
-		return candidates, ctx.Err()
-	}
-
-	return candidates, nil
 }
 
 func probeTarget(ctx context.Context, host netip.Addr, port int, timeout time.Duration) (Candidate, bool) {
+	start := time.Now()
 	dialer := net.Dialer{Timeout: timeout}
 	address := net.JoinHostPort(host.String(), strconv.Itoa(port))
 	conn, err := dialer.DialContext(ctx, "tcp", address)
@@ -225,6 +316,7 @@ func probeTarget(ctx context.Context, host netip.Addr, port int, timeout time.Du
 		Verified:      verified,
 		ReaderAddress: readerAddr,
 		Protocol:      protocol,
+		ProbeTime:     time.Since(start),
 	}, true
 }
 
@@ -362,7 +454,9 @@ func keywordScore(banner string) int {
 	return score
 }
 
-func localPrefixes() ([]netip.Prefix, []netip.Addr, error) {
+// localPrefixes returns the subnets to sweep on the named interfaces (all when
+// names is empty) and the local addresses of every interface.
+func localPrefixes(names []string) ([]netip.Prefix, []netip.Addr, error) {
 	ifaces, err := net.Interfaces()
 	if err != nil {
 		return nil, nil, err
@@ -400,6 +494,9 @@ func localPrefixes() ([]netip.Prefix, []netip.Addr, error) {
 				continue
 			}
 			locals = append(locals, localAddr)
+			if len(names) > 0 && !slices.Contains(names, iface.Name) {
+				continue
+			}
 
 			ones, bits := ipNet.Mask.Size()
 			if bits != 32 || ones <= 0 {
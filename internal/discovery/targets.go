@@ -0,0 +1,186 @@
+package discovery
+
+import (
+	"fmt"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// maxTargetHosts caps explicit targets so a typo like "10.0.0.0/8" does not
+// start a multi-hour sweep.
+const maxTargetHosts = 1 << 16
+
+// progressInterval is how often ScanOptions.Progress is called during a sweep.
+const progressInterval = 200 * time.Millisecond
+
+// ScanProgress reports how far a LAN sweep is.
+type ScanProgress struct {
+	Probed  int // hosts with every port probed
+	Total   int
+	Found   int // open endpoints so far
+	Elapsed time.Duration
+	ETA     time.Duration // 0 until the first host is done
+}
+
+// Done reports whether every host has been probed.
+func (p ScanProgress) Done() bool {
+	return p.Total > 0 && p.Probed >= p.Total
+}
+
+// addrRange is an inclusive IPv4 range.
+type addrRange struct {
+	first, last uint32
+}
+
+func (r addrRange) contains(addr netip.Addr) bool {
+	if !addr.Is4() {
+		return false
+	}
+	v := ipv4ToUint32(addr)
+	return v >= r.first && v <= r.last
+}
+
+func (r addrRange) size() int {
+	return int(r.last-r.first) + 1
+}
+
+// parseTarget parses one target or exclusion: a CIDR ("10.20.0.0/22"), a
+// range ("10.20.0.10-10.20.0.50" or "10.20.0.10-50") or a single address.
+// The network and broadcast addresses of a CIDR shorter than /31 are skipped.
+func parseTarget(spec string) (addrRange, error) {
+	spec = strings.TrimSpace(spec)
+	if strings.Contains(spec, "/") {
+		prefix, err := netip.ParsePrefix(spec)
+		if err != nil || !prefix.Addr().Is4() {
+			return addrRange{}, fmt.Errorf("invalid CIDR %q", spec)
+		}
+		prefix = prefix.Masked()
+		first := ipv4ToUint32(prefix.Addr())
+		last := first | (1<<uint(32-prefix.Bits()) - 1)
+		if prefix.Bits() < 31 {
+			first, last = first+1, last-1
+		}
+		return addrRange{first: first, last: last}, nil
+	}
+
+	if from, to, ok := strings.Cut(spec, "-"); ok {
+		start, err := netip.ParseAddr(strings.TrimSpace(from))
+		if err != nil || !start.Is4() {
+			return addrRange{}, fmt.Errorf("invalid range %q", spec)
+		}
+		to = strings.TrimSpace(to)
+		end, err := netip.ParseAddr(to)
+		if err != nil {
+			// "10.20.0.10-50": the end replaces the last octet.
+			octet, convErr := strconv.Atoi(to)
+			if convErr != nil || octet < 0 || octet > 255 {
+				return addrRange{}, fmt.Errorf("invalid range %q", spec)
+			}
+			b := start.As4()
+			b[3] = byte(octet)
+			end = netip.AddrFrom4(b)
+		}
+		if !end.Is4() || end.Less(start) {
+			return addrRange{}, fmt.Errorf("invalid range %q", spec)
+		}
+		return addrRange{first: ipv4ToUint32(start), last: ipv4ToUint32(end)}, nil
+	}
+
+	addr, err := netip.ParseAddr(spec)
+	if err != nil || !addr.Is4() {
+		return addrRange{}, fmt.Errorf("invalid target %q", spec)
+	}
+	v := ipv4ToUint32(addr)
+	return addrRange{first: v, last: v}, nil
+}
+
+func parseTargets(specs []string) ([]addrRange, error) {
+	ranges := make([]addrRange, 0, len(specs))
+	for _, spec := range specs {
+		if strings.TrimSpace(spec) == "" {
+			continue
+		}
+		r, err := parseTarget(spec)
+		if err != nil {
+			return nil, err
+		}
+		ranges = append(ranges, r)
+	}
+	return ranges, nil
+}
+
+// hostsFromTargets expands ranges in order, dropping duplicates.
+func hostsFromTargets(ranges []addrRange) ([]netip.Addr, error) {
+	total := 0
+	for _, r := range ranges {
+		total += r.size()
+		if total > maxTargetHosts {
+			return nil, fmt.Errorf("targets cover more than %d hosts", maxTargetHosts)
+		}
+	}
+	hosts := make([]netip.Addr, 0, total)
+	seen := make(map[uint32]struct{}, total)
+	for _, r := range ranges {
+		for v := r.first; ; v++ {
+			if _, ok := seen[v]; !ok {
+				seen[v] = struct{}{}
+				hosts = append(hosts, uint32ToIPv4(v))
+			}
+			if v == r.last {
+				break
+			}
+		}
+	}
+	return hosts, nil
+}
+
+func excluded(ranges []addrRange, addr netip.Addr) bool {
+	for _, r := range ranges {
+		if r.contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// progressTracker counts finished probes per host for ScanOptions.Progress.
+type progressTracker struct {
+	start   time.Time
+	total   int
+	pending []atomic.Int32 // probes left per host
+	probed  atomic.Int64
+	found   atomic.Int64
+}
+
+func newProgressTracker(hosts, ports int) *progressTracker {
+	t := &progressTracker{start: time.Now(), total: hosts, pending: make([]atomic.Int32, hosts)}
+	for i := range t.pending {
+		t.pending[i].Store(int32(ports))
+	}
+	return t
+}
+
+func (t *progressTracker) probeDone(host int, open bool) {
+	if open {
+		t.found.Add(1)
+	}
+	if t.pending[host].Add(-1) == 0 {
+		t.probed.Add(1)
+	}
+}
+
+func (t *progressTracker) snapshot() ScanProgress {
+	p := ScanProgress{
+		Probed:  int(t.probed.Load()),
+		Total:   t.total,
+		Found:   int(t.found.Load()),
+		Elapsed: time.Since(t.start),
+	}
+	if p.Probed > 0 && p.Probed < p.Total {
+		p.ETA = p.Elapsed / time.Duration(p.Probed) * time.Duration(p.Total-p.Probed)
+	}
+	return p
+}
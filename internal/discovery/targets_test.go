@@ -0,0 +1,103 @@
+package discovery
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseTarget(t *testing.T) {
+	cases := []struct {
+		spec        string
+		first, last string
+	}{
+		{"10.20.0.0/22", "10.20.0.1", "10.20.3.254"},
+		{"10.20.0.7/32", "10.20.0.7", "10.20.0.7"},
+		{"10.20.0.6/31", "10.20.0.6", "10.20.0.7"},
+		{"10.20.0.10-10.20.1.5", "10.20.0.10", "10.20.1.5"},
+		{" 10.20.0.10 - 50 ", "10.20.0.10", "10.20.0.50"},
+		{"192.168.1.190", "192.168.1.190", "192.168.1.190"},
+	}
+	for _, tc := range cases {
+		r, err := parseTarget(tc.spec)
+		if err != nil {
+			t.Fatalf("%q: %v", tc.spec, err)
+		}
+		if got := uint32ToIPv4(r.first).String(); got != tc.first {
+			t.Fatalf("%q: first %s, want %s", tc.spec, got, tc.first)
+		}
+		if got := uint32ToIPv4(r.last).String(); got != tc.last {
+			t.Fatalf("%q: last %s, want %s", tc.spec, got, tc.last)
+		}
+	}
+
+	for _, bad := range []string{"10.20.0.0/33", "10.20.0.50-10", "10.20.0.1-300", "fe80::1", "printer"} {
+		if _, err := parseTarget(bad); err == nil {
+			t.Fatalf("expected %q to be rejected", bad)
+		}
+	}
+}
+
+func TestSweepHostsUsesTargetsAndExclusions(t *testing.T) {
+	hosts, err := sweepHosts(ScanOptions{
+		Targets: []string{"10.20.0.0/29", "10.20.0.5-9"},
+		Exclude: []string{"10.20.0.2", "10.20.0.4-5"},
+	})
+	if err != nil {
+		t.Fatalf("sweep hosts: %v", err)
+	}
+	want := []string{"10.20.0.1", "10.20.0.3", "10.20.0.6", "10.20.0.7", "10.20.0.8", "10.20.0.9"}
+	if len(hosts) != len(want) {
+		t.Fatalf("expected %v, got %v", want, hosts)
+	}
+	for i := range want {
+		if hosts[i].String() != want[i] {
+			t.Fatalf("host %d: got %s want %s", i, hosts[i], want[i])
+		}
+	}
+
+	if _, err := sweepHosts(ScanOptions{Targets: []string{"10.0.0.0/8"}}); err == nil {
+		t.Fatal("expected an oversized target to be rejected")
+	}
+	if _, err := sweepHosts(ScanOptions{Targets: []string{"10.20.0.1"}, Exclude: []string{"10.20.0.0/24"}}); err == nil {
+		t.Fatal("expected an error when every target is excluded")
+	}
+}
+
+func TestScanReportsProgressForTargets(t *testing.T) {
+	host, port := fakeReader(t)
+	opts := DefaultOptions()
+	opts.Ports = []int{port}
+	// fakeReader listens on 127.0.0.1; .2 refuses and .3 is excluded.
+	opts.Targets = []string{host + "-3"}
+	opts.Exclude = []string{"127.0.0.3"}
+
+	var mu sync.Mutex
+	var reports []ScanProgress
+	opts.Progress = func(p ScanProgress) {
+		mu.Lock()
+		reports = append(reports, p)
+		mu.Unlock()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	candidates, err := Scan(ctx, opts)
+	if err != nil {
+		t.Fatalf("scan: %v", err)
+	}
+	if len(candidates) != 1 || candidates[0].Host != host || !candidates[0].Verified {
+		t.Fatalf("expected the fake reader only, got %+v", candidates)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reports) == 0 {
+		t.Fatal("expected progress reports")
+	}
+	last := reports[len(reports)-1]
+	if !last.Done() || last.Total != 2 || last.Found != 1 || last.ETA != 0 {
+		t.Fatalf("unexpected final progress: %+v", last)
+	}
+}
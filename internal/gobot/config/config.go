@@ -1,14 +1,36 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// APIRole is the access level granted to an HTTP API key.
+type APIRole string
+
+const (
+	RoleReadOnly APIRole = "read-only"
+	RoleOperator APIRole = "operator"
+	RoleIngest   APIRole = "ingest-only"
+	RoleAdmin    APIRole = "admin"
+)
+
+// APIKey is one HTTP API credential. Name identifies the key in audit logs
+// and in HMAC-signed requests; Secret is never logged.
+type APIKey struct {
+	Name   string
+	Secret string
+	Role   APIRole
+}
+
 type Config struct {
+	File                 string
 	HTTPEnabled          bool
 	BotToken             string
 	ERPURL               string
@@ -18,10 +40,31 @@ type Config struct {
 	IPCEnabled           bool
 	IPCSocket            string
 	WebhookSecret        string
+	HTTPAPIKeys          []APIKey
+	HTTPAuthSkew         time.Duration
+	HTTPTLSCert          string
+	HTTPTLSKey           string
+	TelegramAdmins       []int64
+	TelegramOperators    []int64
+	TelegramViewers      []int64
+	NotifyWindow         time.Duration
+	NotifyRatePerSec     int
+	NotifyQueueSize      int
+	ReportDailyAt        time.Duration
+	HistoryEnabled       bool
+	HistoryDir           string
+	HistoryRetention     time.Duration
+	HistoryMaxBytes      int64
+	HistoryReadDedup     time.Duration
 	RequestTimeout       time.Duration
 	RefreshInterval      time.Duration
+	RefreshMaxBackoff    time.Duration
 	SubmitRetry          int
 	SubmitRetryDelay     time.Duration
+	SubmitRetryMaxDelay  time.Duration
+	ERPBreakerFailures   int
+	ERPBreakerOpen       time.Duration
+	ERPBreakerProbes     int
 	WorkerCount          int
 	QueueSize            int
 	RecentSeenTTL        time.Duration
@@ -33,34 +76,106 @@ type Config struct {
 	ReaderRetryDelay     time.Duration
 	ReaderHost           string
 	ReaderPort           int
+	ReaderStateFile      string
+	ReaderKnownFile      string
+	ReaderScanTargets    []string
+	ReaderScanExclude    []string
+	ReaderScanInterfaces []string
+	AntennaQuiet         time.Duration
+	ReaderWatchdogRounds int
+	HealthRefreshMaxAge  time.Duration
+	HealthStall          time.Duration
+	ShutdownDrain        time.Duration
+	PendingFile          string
+	ShutdownNotify       bool
+	ReaderInventory      Inventory
+
+	// Load notes for the log, not settings: clamped legacy values and
+	// file keys overridden by the environment.
+	Warnings []string
+	Shadowed []string
 }
 
+// Load reads the config file named by BOT_CONFIG_FILE (if any) and applies
+// environment overrides. See LoadFile.
 func Load() (Config, error) {
+	return LoadFile(os.Getenv("BOT_CONFIG_FILE"))
+}
+
+// LoadFile builds the config from defaults, the TOML file at path (optional)
+// and environment variables, which take precedence over the file. Every
+// invalid or out-of-range value is reported in one *ValidationError.
+func LoadFile(path string) (Config, error) {
+	src, err := newSource(path)
+	if err != nil {
+		return Config{}, err
+	}
+
 	cfg := Config{
-		HTTPEnabled:          envBool("BOT_HTTP_ENABLED", true),
-		BotToken:             strings.TrimSpace(os.Getenv("BOT_TOKEN")),
-		ERPURL:               strings.TrimSpace(os.Getenv("ERP_URL")),
-		ERPAPIKey:            strings.TrimSpace(os.Getenv("ERP_API_KEY")),
-		ERPAPISecret:         strings.TrimSpace(os.Getenv("ERP_API_SECRET")),
-		HTTPAddr:             envOr("BOT_HTTP_ADDR", ":8098"),
-		IPCEnabled:           envBool("BOT_IPC_ENABLED", true),
-		IPCSocket:            envOr("BOT_IPC_SOCKET", "/tmp/rfid-go-bot.sock"),
-		WebhookSecret:        strings.TrimSpace(os.Getenv("BOT_WEBHOOK_SECRET")),
-		RequestTimeout:       envDurationMS("BOT_HTTP_TIMEOUT_MS", 12_000),
-		RefreshInterval:      envDurationSec("BOT_CACHE_REFRESH_SEC", 5),
-		SubmitRetry:          envInt("BOT_SUBMIT_RETRY", 2),
-		SubmitRetryDelay:     envDurationMS("BOT_SUBMIT_RETRY_MS", 300),
-		WorkerCount:          envInt("BOT_WORKER_COUNT", 4),
-		QueueSize:            envInt("BOT_QUEUE_SIZE", 2048),
-		RecentSeenTTL:        envDurationSec("BOT_RECENT_SEEN_TTL_SEC", 600),
-		PollTimeout:          envDurationSec("BOT_POLL_TIMEOUT_SEC", 25),
-		ScanBackend:          strings.ToLower(envOr("BOT_SCAN_BACKEND", "hybrid")),
-		ScanDefaultActive:    envBool("BOT_SCAN_DEFAULT_ACTIVE", true),
-		AutoScan:             envBool("BOT_AUTO_SCAN", false),
-		ReaderConnectTimeout: envDurationSec("BOT_READER_CONNECT_TIMEOUT_SEC", 25),
-		ReaderRetryDelay:     envDurationSec("BOT_READER_RETRY_SEC", 2),
-		ReaderHost:           strings.TrimSpace(os.Getenv("BOT_READER_HOST")),
-		ReaderPort:           envInt("BOT_READER_PORT", 0),
+		File:                 strings.TrimSpace(path),
+		HTTPEnabled:          src.bool("BOT_HTTP_ENABLED", true),
+		BotToken:             src.str("BOT_TOKEN", ""),
+		ERPURL:               src.str("ERP_URL", ""),
+		ERPAPIKey:            src.str("ERP_API_KEY", ""),
+		ERPAPISecret:         src.str("ERP_API_SECRET", ""),
+		HTTPAddr:             src.str("BOT_HTTP_ADDR", ":8098"),
+		IPCEnabled:           src.bool("BOT_IPC_ENABLED", true),
+		IPCSocket:            src.str("BOT_IPC_SOCKET", "/tmp/rfid-go-bot.sock"),
+		WebhookSecret:        src.str("BOT_WEBHOOK_SECRET", ""),
+		HTTPAuthSkew:         src.seconds("BOT_HTTP_AUTH_SKEW_SEC", 300),
+		HTTPTLSCert:          src.str("BOT_HTTP_TLS_CERT", ""),
+		HTTPTLSKey:           src.str("BOT_HTTP_TLS_KEY", ""),
+		TelegramAdmins:       src.ids("BOT_TELEGRAM_ADMINS"),
+		TelegramOperators:    src.ids("BOT_TELEGRAM_OPERATORS"),
+		TelegramViewers:      src.ids("BOT_TELEGRAM_VIEWERS"),
+		NotifyWindow:         src.seconds("BOT_NOTIFY_WINDOW_SEC", 30),
+		NotifyRatePerSec:     src.int("BOT_NOTIFY_RATE_PER_SEC", 20),
+		NotifyQueueSize:      src.int("BOT_NOTIFY_QUEUE_SIZE", 512),
+		HistoryEnabled:       src.bool("BOT_HISTORY_ENABLED", true),
+		HistoryDir:           src.str("BOT_HISTORY_DIR", "logs/history"),
+		HistoryRetention:     time.Duration(src.int("BOT_HISTORY_RETENTION_DAYS", 30)) * 24 * time.Hour,
+		HistoryMaxBytes:      int64(src.int("BOT_HISTORY_MAX_MB", 1024)) << 20,
+		HistoryReadDedup:     src.seconds("BOT_HISTORY_READ_DEDUP_SEC", 10),
+		RequestTimeout:       src.millis("BOT_HTTP_TIMEOUT_MS", 12_000),
+		RefreshInterval:      src.seconds("BOT_CACHE_REFRESH_SEC", 5),
+		RefreshMaxBackoff:    src.seconds("BOT_CACHE_REFRESH_MAX_SEC", 300),
+		SubmitRetry:          src.int("BOT_SUBMIT_RETRY", 2),
+		SubmitRetryDelay:     src.millis("BOT_SUBMIT_RETRY_MS", 300),
+		SubmitRetryMaxDelay:  src.millis("BOT_SUBMIT_RETRY_MAX_MS", 15_000),
+		ERPBreakerFailures:   src.int("BOT_ERP_BREAKER_FAILURES", 5),
+		ERPBreakerOpen:       src.seconds("BOT_ERP_BREAKER_OPEN_SEC", 30),
+		ERPBreakerProbes:     src.int("BOT_ERP_BREAKER_PROBES", 1),
+		WorkerCount:          src.int("BOT_WORKER_COUNT", 4),
+		QueueSize:            src.int("BOT_QUEUE_SIZE", 2048),
+		RecentSeenTTL:        src.seconds("BOT_RECENT_SEEN_TTL_SEC", 600),
+		PollTimeout:          src.seconds("BOT_POLL_TIMEOUT_SEC", 25),
+		ScanBackend:          strings.ToLower(src.str("BOT_SCAN_BACKEND", "hybrid")),
+		ScanDefaultActive:    src.bool("BOT_SCAN_DEFAULT_ACTIVE", true),
+		AutoScan:             src.bool("BOT_AUTO_SCAN", false),
+		ReaderConnectTimeout: src.seconds("BOT_READER_CONNECT_TIMEOUT_SEC", 25),
+		ReaderRetryDelay:     src.seconds("BOT_READER_RETRY_SEC", 2),
+		ReaderHost:           src.str("BOT_READER_HOST", ""),
+		ReaderPort:           src.int("BOT_READER_PORT", 0),
+		ReaderStateFile:      src.str("BOT_READER_STATE_FILE", "logs/reader_config.json"),
+		ReaderKnownFile:      src.str("BOT_READER_KNOWN_FILE", "logs/known_readers.json"),
+		ReaderScanTargets:    src.list("BOT_READER_SCAN_TARGETS"),
+		ReaderScanExclude:    src.list("BOT_READER_SCAN_EXCLUDE"),
+		ReaderScanInterfaces: src.list("BOT_READER_SCAN_INTERFACES"),
+		AntennaQuiet:         src.seconds("BOT_ANTENNA_QUIET_SEC", 1800),
+		ReaderWatchdogRounds: src.int("BOT_READER_WATCHDOG_ROUNDS", 50),
+		HealthRefreshMaxAge:  src.seconds("BOT_HEALTH_REFRESH_MAX_AGE_SEC", 600),
+		HealthStall:          src.seconds("BOT_HEALTH_STALL_SEC", 120),
+		ShutdownDrain:        src.seconds("BOT_SHUTDOWN_DRAIN_SEC", 20),
+		PendingFile:          src.str("BOT_SHUTDOWN_PENDING_FILE", "logs/pending_submits.json"),
+		ShutdownNotify:       src.bool("BOT_SHUTDOWN_NOTIFY", true),
+		ReaderInventory: Inventory{
+			Q:        src.int("BOT_READER_Q", 4),
+			Session:  src.int("BOT_READER_SESSION", 1),
+			Power:    src.int("BOT_READER_POWER", 30),
+			ScanTime: src.int("BOT_READER_SCAN_TIME", 1),
+			Antennas: src.antennas("BOT_READER_ANTENNAS", []int{1}),
+			Poll:     src.millis("BOT_READER_POLL_MS", 40),
+		},
 	}
 
 	cfg.ERPURL = strings.TrimRight(cfg.ERPURL, "/")
@@ -71,87 +186,258 @@ func Load() (Config, error) {
 		cfg.IPCSocket = ""
 	}
 
-	if cfg.BotToken == "" {
-		return Config{}, fmt.Errorf("BOT_TOKEN is required")
-	}
-	if cfg.ERPURL == "" || cfg.ERPAPIKey == "" || cfg.ERPAPISecret == "" {
-		return Config{}, fmt.Errorf("ERP_URL, ERP_API_KEY, ERP_API_SECRET are required")
+	rawKeys, keysName := src.lookup("BOT_HTTP_API_KEYS")
+	keys, err := ParseAPIKeys(rawKeys)
+	if err != nil {
+		src.problem(keysName, strings.TrimPrefix(err.Error(), "BOT_HTTP_API_KEYS: "))
 	}
-	if cfg.SubmitRetry < 0 {
-		cfg.SubmitRetry = 0
+	cfg.HTTPAPIKeys = keys
+
+	rawClock, clockName := src.lookup("BOT_REPORT_DAILY_AT")
+	dailyAt, err := ParseClock(rawClock)
+	if err != nil {
+		src.problem(clockName, err.Error())
 	}
-	if cfg.WorkerCount < 1 {
-		cfg.WorkerCount = 1
+	cfg.ReportDailyAt = dailyAt
+
+	if (cfg.HTTPTLSCert == "") != (cfg.HTTPTLSKey == "") {
+		src.problem("BOT_HTTP_TLS_CERT", "BOT_HTTP_TLS_CERT and BOT_HTTP_TLS_KEY must be set together")
 	}
-	if cfg.QueueSize < 64 {
-		cfg.QueueSize = 64
+	switch cfg.ScanBackend {
+	case "ingest", "sdk", "hybrid":
+	default:
+		// Unknown backends used to fall back to ingest silently.
+		_, name := src.lookup("BOT_SCAN_BACKEND")
+		src.warn(name, fmt.Sprintf("must be ingest, sdk or hybrid, got %q; using ingest, this will be an error in the next release", cfg.ScanBackend))
+		cfg.ScanBackend = "ingest"
 	}
-	if cfg.RequestTimeout < time.Second {
-		cfg.RequestTimeout = time.Second
+	if cfg.BotToken == "" {
+		src.problem("BOT_TOKEN", "is required")
 	}
-	if cfg.RefreshInterval < 5*time.Second {
-		cfg.RefreshInterval = 5 * time.Second
+	if cfg.ERPURL == "" || cfg.ERPAPIKey == "" || cfg.ERPAPISecret == "" {
+		src.problem("ERP_URL", "ERP_URL, ERP_API_KEY, ERP_API_SECRET are required")
 	}
-	if cfg.PollTimeout < 5*time.Second {
-		cfg.PollTimeout = 5 * time.Second
+	if len(src.problems) > 0 {
+		return Config{}, &ValidationError{Problems: src.problems}
 	}
-	if cfg.PollTimeout > 55*time.Second {
-		cfg.PollTimeout = 55 * time.Second
+
+	if cfg.RefreshMaxBackoff < cfg.RefreshInterval {
+		cfg.RefreshMaxBackoff = cfg.RefreshInterval
 	}
-	if cfg.RecentSeenTTL < 30*time.Second {
-		cfg.RecentSeenTTL = 30 * time.Second
+	if cfg.SubmitRetryMaxDelay < cfg.SubmitRetryDelay {
+		cfg.SubmitRetryMaxDelay = cfg.SubmitRetryDelay
 	}
-	switch cfg.ScanBackend {
-	case "ingest", "sdk", "hybrid":
-	default:
-		cfg.ScanBackend = "ingest"
+	cfg.Warnings = src.warnings
+	cfg.Shadowed = src.shadowed
+	return cfg, nil
+}
+
+// Inventory holds the reader's inventory tuning.
+type Inventory struct {
+	Q        int
+	Session  int
+	Power    int
+	ScanTime int
+	Antennas []int
+	Poll     time.Duration
+}
+
+// AntennaMask returns the antenna bitmask (port 1 is bit 0).
+func (inv Inventory) AntennaMask() byte {
+	var mask byte
+	for _, port := range inv.Antennas {
+		if port >= 1 && port <= 8 {
+			mask |= 1 << (port - 1)
+		}
 	}
-	if cfg.ReaderConnectTimeout < 5*time.Second {
-		cfg.ReaderConnectTimeout = 5 * time.Second
+	return mask
+}
+
+// ParseAntennas parses a comma-separated list of antenna ports 1-8.
+func ParseAntennas(raw string) ([]int, error) {
+	seen := make(map[int]bool)
+	var ports []int
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		port, err := strconv.Atoi(part)
+		if err != nil || port < 1 || port > 8 {
+			return nil, fmt.Errorf("antenna %q must be a port between 1 and 8", part)
+		}
+		if !seen[port] {
+			seen[port] = true
+			ports = append(ports, port)
+		}
 	}
-	if cfg.ReaderRetryDelay < 500*time.Millisecond {
-		cfg.ReaderRetryDelay = 2 * time.Second
+	if len(ports) == 0 {
+		return nil, fmt.Errorf("at least one antenna is required")
 	}
+	sort.Ints(ports)
+	return ports, nil
+}
 
-	return cfg, nil
+// InventoryUpdate is a partial change to Inventory; nil fields keep their
+// current value. It is also the JSON form of a full Inventory.
+type InventoryUpdate struct {
+	Q        *int  `json:"q,omitempty"`
+	Session  *int  `json:"session,omitempty"`
+	Power    *int  `json:"power,omitempty"`
+	ScanTime *int  `json:"scan_time,omitempty"`
+	Antennas []int `json:"antennas,omitempty"`
+	PollMS   *int  `json:"poll_ms,omitempty"`
 }
 
-func envOr(key, fallback string) string {
-	val := strings.TrimSpace(os.Getenv(key))
-	if val == "" {
-		return fallback
+// AsUpdate returns an update that sets every field to inv's values.
+func (inv Inventory) AsUpdate() InventoryUpdate {
+	q, session, power, scanTime := inv.Q, inv.Session, inv.Power, inv.ScanTime
+	pollMS := int(inv.Poll / time.Millisecond)
+	return InventoryUpdate{
+		Q:        &q,
+		Session:  &session,
+		Power:    &power,
+		ScanTime: &scanTime,
+		Antennas: append([]int(nil), inv.Antennas...),
+		PollMS:   &pollMS,
 	}
-	return val
 }
 
-func envInt(key string, fallback int) int {
-	raw := strings.TrimSpace(os.Getenv(key))
-	if raw == "" {
-		return fallback
+func (inv Inventory) MarshalJSON() ([]byte, error) {
+	return json.Marshal(inv.AsUpdate())
+}
+
+// Apply returns inv with u applied. Values are checked against the same
+// ranges as the BOT_READER_* settings and every problem is reported in one
+// *ValidationError.
+func (inv Inventory) Apply(u InventoryUpdate) (Inventory, error) {
+	var problems []string
+	set := func(env string, v *int, dst *int) {
+		if v == nil {
+			return
+		}
+		spec := settingsByEnv[env]
+		if *v < spec.min || *v > spec.max {
+			problems = append(problems, fmt.Sprintf("%s: must be between %d and %d, got %d", strings.TrimPrefix(spec.key, "reader."), spec.min, spec.max, *v))
+			return
+		}
+		*dst = *v
 	}
-	n, err := strconv.Atoi(raw)
-	if err != nil {
-		return fallback
+
+	out := inv
+	set("BOT_READER_Q", u.Q, &out.Q)
+	set("BOT_READER_SESSION", u.Session, &out.Session)
+	set("BOT_READER_POWER", u.Power, &out.Power)
+	set("BOT_READER_SCAN_TIME", u.ScanTime, &out.ScanTime)
+	pollMS := int(inv.Poll / time.Millisecond)
+	set("BOT_READER_POLL_MS", u.PollMS, &pollMS)
+	out.Poll = time.Duration(pollMS) * time.Millisecond
+
+	if u.Antennas != nil {
+		parts := make([]string, len(u.Antennas))
+		for i, port := range u.Antennas {
+			parts[i] = strconv.Itoa(port)
+		}
+		ports, err := ParseAntennas(strings.Join(parts, ","))
+		if err != nil {
+			problems = append(problems, "antennas: "+err.Error())
+		} else {
+			out.Antennas = ports
+		}
+	}
+
+	if len(problems) > 0 {
+		return inv, &ValidationError{Problems: problems}
 	}
-	return n
+	return out, nil
 }
 
-func envBool(key string, fallback bool) bool {
-	raw := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
-	switch raw {
-	case "1", "true", "yes", "on":
-		return true
-	case "0", "false", "no", "off":
-		return false
-	default:
-		return fallback
+// reloadable names the fields a running bot applies without a restart.
+var reloadable = map[string]bool{
+	"RefreshInterval":     true,
+	"RefreshMaxBackoff":   true,
+	"SubmitRetry":         true,
+	"SubmitRetryDelay":    true,
+	"SubmitRetryMaxDelay": true,
+	"ReaderInventory":     true,
+	"TelegramAdmins":      true,
+	"TelegramOperators":   true,
+	"TelegramViewers":     true,
+}
+
+// Diff compares c with next and splits the changed field names into those a
+// reload applies and those that need a restart.
+func (c Config) Diff(next Config) (reload, restart []string) {
+	cur, nxt := reflect.ValueOf(c), reflect.ValueOf(next)
+	t := cur.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := t.Field(i).Name
+		if name == "File" || name == "Warnings" || name == "Shadowed" || reflect.DeepEqual(cur.Field(i).Interface(), nxt.Field(i).Interface()) {
+			continue
+		}
+		if reloadable[name] {
+			reload = append(reload, name)
+		} else {
+			restart = append(restart, name)
+		}
 	}
+	return reload, restart
 }
 
-func envDurationSec(key string, fallbackSec int) time.Duration {
-	return time.Duration(envInt(key, fallbackSec)) * time.Second
+// ParseAPIKeys parses comma-separated name:secret:role entries,
+// e.g. "dock:s3cret:operator,erp:other:ingest-only".
+func ParseAPIKeys(raw string) ([]APIKey, error) {
+	var keys []APIKey
+	seen := make(map[string]struct{})
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.Split(entry, ":")
+		if len(parts) != 3 {
+			return nil, fmt.Errorf("BOT_HTTP_API_KEYS: entry %q must be name:secret:role", redactKeyEntry(entry))
+		}
+		key := APIKey{
+			Name:   strings.TrimSpace(parts[0]),
+			Secret: strings.TrimSpace(parts[1]),
+			Role:   APIRole(strings.ToLower(strings.TrimSpace(parts[2]))),
+		}
+		if key.Name == "" || key.Secret == "" {
+			return nil, fmt.Errorf("BOT_HTTP_API_KEYS: entry %q has empty name or secret", redactKeyEntry(entry))
+		}
+		if len(key.Secret) < 16 {
+			return nil, fmt.Errorf("BOT_HTTP_API_KEYS: secret for %q must be at least 16 characters", key.Name)
+		}
+		switch key.Role {
+		case RoleReadOnly, RoleOperator, RoleIngest, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("BOT_HTTP_API_KEYS: unknown role %q for %q", parts[2], key.Name)
+		}
+		if _, dup := seen[key.Name]; dup {
+			return nil, fmt.Errorf("BOT_HTTP_API_KEYS: duplicate key name %q", key.Name)
+		}
+		seen[key.Name] = struct{}{}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// ParseClock parses a local HH:MM time into an offset from midnight.
+// An empty value returns -1, meaning disabled.
+func ParseClock(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return -1, nil
+	}
+	t, err := time.Parse("15:04", raw)
+	if err != nil {
+		return 0, fmt.Errorf("expected HH:MM, got %q", raw)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
 }
 
-func envDurationMS(key string, fallbackMS int) time.Duration {
-	return time.Duration(envInt(key, fallbackMS)) * time.Millisecond
+func redactKeyEntry(entry string) string {
+	name, _, _ := strings.Cut(entry, ":")
+	return name + ":***"
 }
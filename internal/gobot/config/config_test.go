@@ -0,0 +1,213 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bot.toml")
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const validFile = `
+[bot]
+token = "file-token"
+
+[erp]
+url = "https://erp.example.com/"
+api_key = "key"
+api_secret = "secret" # trailing comment
+refresh_sec = 20
+
+[telegram]
+admins = [100, 200]
+
+[reader]
+power = 25
+antennas = [2, 1]
+scan_targets = ["10.20.0.0/22", "10.30.0.5-20"]
+`
+
+func TestLoadFileWithEnvOverride(t *testing.T) {
+	path := writeConfig(t, validFile)
+	t.Setenv("BOT_CACHE_REFRESH_SEC", "30")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BotToken != "file-token" || cfg.ERPURL != "https://erp.example.com" {
+		t.Fatalf("file values not applied: %+v", cfg)
+	}
+	if cfg.RefreshInterval != 30*time.Second {
+		t.Fatalf("env should override file, got %s", cfg.RefreshInterval)
+	}
+	if len(cfg.TelegramAdmins) != 2 || cfg.TelegramAdmins[1] != 200 {
+		t.Fatalf("admins not parsed: %v", cfg.TelegramAdmins)
+	}
+	if !slices.Equal(cfg.ReaderScanTargets, []string{"10.20.0.0/22", "10.30.0.5-20"}) {
+		t.Fatalf("scan targets not parsed: %v", cfg.ReaderScanTargets)
+	}
+	inv := cfg.ReaderInventory
+	if inv.Power != 25 || inv.Q != 4 || inv.AntennaMask() != 0x03 {
+		t.Fatalf("unexpected inventory: %+v", inv)
+	}
+}
+
+func TestLoadFileListsEveryProblem(t *testing.T) {
+	path := writeConfig(t, validFile+`
+[notify]
+rate_per_sec = 99
+window = 5
+
+[scan]
+backend = "laser"
+auto = "yes"
+`)
+	t.Setenv("BOT_WORKER_COUNT", "many")
+
+	_, err := LoadFile(path)
+	var verr *ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected ValidationError, got %v", err)
+	}
+	joined := strings.Join(verr.Problems, "\n")
+	for _, want := range []string{
+		"notify.window: unknown key",
+		"notify.rate_per_sec: must be between 1 and 30",
+		"scan.auto: expected true or false",
+		"BOT_WORKER_COUNT: expected an integer",
+	} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing problem %q in:\n%s", want, joined)
+		}
+	}
+}
+
+func TestLoadFileClampsLegacyValuesWithWarning(t *testing.T) {
+	path := writeConfig(t, validFile+`
+[scan]
+backend = "laser"
+`)
+	t.Setenv("BOT_CACHE_REFRESH_SEC", "1")
+	t.Setenv("BOT_READER_POWER", "20")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("legacy values should still load: %v", err)
+	}
+	if cfg.RefreshInterval != 5*time.Second || cfg.ScanBackend != "ingest" {
+		t.Fatalf("expected clamped refresh and ingest backend, got %s %q", cfg.RefreshInterval, cfg.ScanBackend)
+	}
+	joined := strings.Join(cfg.Warnings, "\n")
+	for _, want := range []string{"BOT_CACHE_REFRESH_SEC: 1 is outside 5-3600, using 5", "scan.backend: must be ingest, sdk or hybrid"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("missing warning %q in:\n%s", want, joined)
+		}
+	}
+	if !slices.Equal(cfg.Shadowed, []string{"erp.refresh_sec (BOT_CACHE_REFRESH_SEC)", "reader.power (BOT_READER_POWER)"}) {
+		t.Fatalf("unexpected shadowed keys: %v", cfg.Shadowed)
+	}
+
+	t.Setenv("BOT_READER_POWER", "31")
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "BOT_READER_POWER: must be between 0 and 30") {
+		t.Fatalf("new settings must stay strict, got %v", err)
+	}
+}
+
+func TestParseValueKeepsCommasInQuotedArrayItems(t *testing.T) {
+	got, err := parseValue(`["a,b", 'c, d', "e\",f", 3]`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []any{"a,b", "c, d", `e",f`, int64(3)}
+	if !slices.Equal(got.([]any), want) {
+		t.Fatalf("got %#v want %#v", got, want)
+	}
+	if _, err := parseValue(`["a, b]`); err == nil {
+		t.Fatal("expected an error for an unterminated string")
+	}
+
+	path := writeConfig(t, validFile+`
+[http]
+api_keys = ["ops:secret,with-comma:operator"]
+`)
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), "http.api_keys: item") {
+		t.Fatalf("expected a comma error for http.api_keys, got %v", err)
+	}
+}
+
+func TestStripCommentSkipsEscapedQuotes(t *testing.T) {
+	for line, want := range map[string]string{
+		`token = "a\"#b" # note`: `token = "a\"#b" `,
+		`token = "a\\" # note`:   `token = "a\\" `,
+		`token = 'a\'#b`:         `token = 'a\'`,
+		`token = "#" # "x"`:      `token = "#" `,
+	} {
+		if got := stripComment(line); got != want {
+			t.Fatalf("stripComment(%q) = %q, want %q", line, got, want)
+		}
+	}
+
+	path := writeConfig(t, strings.Replace(validFile, `token = "file-token"`, `token = "file\"#token" # comment`, 1))
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.BotToken != `file"#token` {
+		t.Fatalf("token cut at the escaped quote: %q", cfg.BotToken)
+	}
+}
+
+func TestDiffSplitsReloadableFields(t *testing.T) {
+	path := writeConfig(t, validFile)
+	cur, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	next := cur
+	next.RefreshInterval = time.Minute
+	next.ReaderInventory.Power = 10
+	next.HTTPAddr = ":9000"
+
+	reload, restart := cur.Diff(next)
+	if strings.Join(reload, ",") != "RefreshInterval,ReaderInventory" {
+		t.Fatalf("unexpected reloadable fields: %v", reload)
+	}
+	if strings.Join(restart, ",") != "HTTPAddr" {
+		t.Fatalf("unexpected restart fields: %v", restart)
+	}
+}
+
+func TestInventoryApplyValidatesPartialUpdate(t *testing.T) {
+	base := Inventory{Q: 4, Session: 1, Power: 30, ScanTime: 1, Antennas: []int{1}, Poll: 40 * time.Millisecond}
+
+	power := 25
+	got, err := base.Apply(InventoryUpdate{Power: &power, Antennas: []int{2, 1, 2}})
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got.Power != 25 || got.Q != 4 || !slices.Equal(got.Antennas, []int{1, 2}) {
+		t.Fatalf("unexpected inventory: %+v", got)
+	}
+
+	bad, session := 31, 9
+	_, err = base.Apply(InventoryUpdate{Power: &bad, Session: &session, Antennas: []int{}})
+	var verr *ValidationError
+	if !errors.As(err, &verr) || len(verr.Problems) != 3 {
+		t.Fatalf("expected three problems, got %v", err)
+	}
+	if !strings.HasPrefix(verr.Problems[0], "session:") {
+		t.Fatalf("problems should name the field: %v", verr.Problems)
+	}
+}
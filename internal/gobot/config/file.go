@@ -0,0 +1,182 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// parseFile reads the TOML subset used by the bot config file: [table]
+// headers, key = value pairs with strings, integers, booleans and
+// single-line arrays, and # comments. Keys are returned as "table.key".
+func parseFile(path string) (map[string]any, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open config file: %w", err)
+	}
+	defer f.Close()
+
+	out := make(map[string]any)
+	var problems []string
+	table := ""
+	sc := bufio.NewScanner(f)
+	lineNo := 0
+	for sc.Scan() {
+		lineNo++
+		line := strings.TrimSpace(stripComment(sc.Text()))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				problems = append(problems, fmt.Sprintf("line %d: invalid table header %q", lineNo, line))
+				continue
+			}
+			table = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+
+		key, raw, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			problems = append(problems, fmt.Sprintf("line %d: expected key = value", lineNo))
+			continue
+		}
+		if table != "" {
+			key = table + "." + key
+		}
+		if _, dup := out[key]; dup {
+			problems = append(problems, fmt.Sprintf("line %d: %s set twice", lineNo, key))
+			continue
+		}
+		val, err := parseValue(strings.TrimSpace(raw))
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("line %d: %s: %v", lineNo, key, err))
+			continue
+		}
+		out[key] = val
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("read config file: %w", err)
+	}
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return out, nil
+}
+
+// stripComment drops a trailing # comment that is not inside a string.
+func stripComment(line string) string {
+	var quote rune
+	escaped := false
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+func parseValue(raw string) (any, error) {
+	switch {
+	case raw == "":
+		return nil, fmt.Errorf("missing value")
+	case raw == "true":
+		return true, nil
+	case raw == "false":
+		return false, nil
+	case strings.HasPrefix(raw, `"`) || strings.HasPrefix(raw, "'"):
+		return parseString(raw)
+	case strings.HasPrefix(raw, "["):
+		if !strings.HasSuffix(raw, "]") {
+			return nil, fmt.Errorf("arrays must be on one line")
+		}
+		inner := strings.TrimSpace(raw[1 : len(raw)-1])
+		items := []any{}
+		if inner == "" {
+			return items, nil
+		}
+		parts, err := splitArray(inner)
+		if err != nil {
+			return nil, err
+		}
+		for _, part := range parts {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			item, err := parseValue(part)
+			if err != nil {
+				return nil, err
+			}
+			if _, nested := item.([]any); nested {
+				return nil, fmt.Errorf("nested arrays are not supported")
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	}
+	n, err := strconv.ParseInt(strings.ReplaceAll(raw, "_", ""), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q (strings must be quoted)", raw)
+	}
+	return n, nil
+}
+
+// splitArray splits the inside of a one-line array on the commas that are
+// not inside a quoted string.
+func splitArray(inner string) ([]string, error) {
+	var parts []string
+	var quote rune
+	escaped := false
+	start := 0
+	for i, r := range inner {
+		switch {
+		case escaped:
+			escaped = false
+		case quote != 0:
+			if r == '\\' && quote == '"' {
+				escaped = true
+			} else if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '\'':
+			quote = r
+		case r == ',':
+			parts = append(parts, inner[start:i])
+			start = i + 1
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated string")
+	}
+	return append(parts, inner[start:]), nil
+}
+
+func parseString(raw string) (string, error) {
+	quote := raw[0]
+	if len(raw) < 2 || raw[len(raw)-1] != quote {
+		return "", fmt.Errorf("unterminated string")
+	}
+	if quote == '\'' {
+		return raw[1 : len(raw)-1], nil
+	}
+	s, err := strconv.Unquote(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid string %s", raw)
+	}
+	return s, nil
+}
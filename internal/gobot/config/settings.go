@@ -0,0 +1,330 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type kind int
+
+const (
+	kindString kind = iota
+	kindInt
+	kindBool
+	kindList
+)
+
+// setting ties an environment variable to its config file key. Integer
+// settings are validated against [min, max]. Settings marked clamp were
+// silently clamped before validation existed; for one release an
+// out-of-range value is still clamped, with a warning, instead of failing.
+type setting struct {
+	env      string
+	key      string
+	kind     kind
+	min, max int
+	clamp    bool
+}
+
+var settings = []setting{
+	{env: "BOT_TOKEN", key: "bot.token"},
+	{env: "BOT_HTTP_TIMEOUT_MS", key: "bot.request_timeout_ms", kind: kindInt, min: 1000, max: 120_000, clamp: true},
+	{env: "BOT_WORKER_COUNT", key: "bot.worker_count", kind: kindInt, min: 1, max: 64, clamp: true},
+	{env: "BOT_QUEUE_SIZE", key: "bot.queue_size", kind: kindInt, min: 64, max: 1_000_000, clamp: true},
+	{env: "BOT_RECENT_SEEN_TTL_SEC", key: "bot.recent_seen_ttl_sec", kind: kindInt, min: 30, max: 86_400, clamp: true},
+
+	{env: "BOT_HTTP_ENABLED", key: "http.enabled", kind: kindBool},
+	{env: "BOT_HTTP_ADDR", key: "http.addr"},
+	{env: "BOT_WEBHOOK_SECRET", key: "http.webhook_secret"},
+	{env: "BOT_HTTP_API_KEYS", key: "http.api_keys", kind: kindList},
+	{env: "BOT_HTTP_AUTH_SKEW_SEC", key: "http.auth_skew_sec", kind: kindInt, min: 30, max: 3600},
+	{env: "BOT_HTTP_TLS_CERT", key: "http.tls_cert"},
+	{env: "BOT_HTTP_TLS_KEY", key: "http.tls_key"},
+
+	{env: "BOT_IPC_ENABLED", key: "ipc.enabled", kind: kindBool},
+	{env: "BOT_IPC_SOCKET", key: "ipc.socket"},
+
+	{env: "ERP_URL", key: "erp.url"},
+	{env: "ERP_API_KEY", key: "erp.api_key"},
+	{env: "ERP_API_SECRET", key: "erp.api_secret"},
+	{env: "BOT_CACHE_REFRESH_SEC", key: "erp.refresh_sec", kind: kindInt, min: 5, max: 3600, clamp: true},
+	{env: "BOT_CACHE_REFRESH_MAX_SEC", key: "erp.refresh_max_sec", kind: kindInt, min: 5, max: 86_400},
+	{env: "BOT_SUBMIT_RETRY", key: "erp.submit_retry", kind: kindInt, min: 0, max: 20, clamp: true},
+	{env: "BOT_SUBMIT_RETRY_MS", key: "erp.submit_retry_ms", kind: kindInt, min: 0, max: 60_000, clamp: true},
+	{env: "BOT_SUBMIT_RETRY_MAX_MS", key: "erp.submit_retry_max_ms", kind: kindInt, min: 0, max: 600_000},
+	{env: "BOT_ERP_BREAKER_FAILURES", key: "erp.breaker_failures", kind: kindInt, min: 1, max: 1000},
+	{env: "BOT_ERP_BREAKER_OPEN_SEC", key: "erp.breaker_open_sec", kind: kindInt, min: 1, max: 3600},
+	{env: "BOT_ERP_BREAKER_PROBES", key: "erp.breaker_probes", kind: kindInt, min: 1, max: 100},
+
+	{env: "BOT_POLL_TIMEOUT_SEC", key: "telegram.poll_timeout_sec", kind: kindInt, min: 5, max: 55, clamp: true},
+	{env: "BOT_TELEGRAM_ADMINS", key: "telegram.admins", kind: kindList},
+	{env: "BOT_TELEGRAM_OPERATORS", key: "telegram.operators", kind: kindList},
+	{env: "BOT_TELEGRAM_VIEWERS", key: "telegram.viewers", kind: kindList},
+
+	{env: "BOT_SCAN_BACKEND", key: "scan.backend"},
+	{env: "BOT_SCAN_DEFAULT_ACTIVE", key: "scan.default_active", kind: kindBool},
+	{env: "BOT_AUTO_SCAN", key: "scan.auto", kind: kindBool},
+
+	{env: "BOT_READER_HOST", key: "reader.host"},
+	{env: "BOT_READER_PORT", key: "reader.port", kind: kindInt, min: 0, max: 65_535},
+	{env: "BOT_READER_CONNECT_TIMEOUT_SEC", key: "reader.connect_timeout_sec", kind: kindInt, min: 5, max: 300, clamp: true},
+	{env: "BOT_READER_RETRY_SEC", key: "reader.retry_sec", kind: kindInt, min: 1, max: 300, clamp: true},
+	{env: "BOT_READER_Q", key: "reader.q", kind: kindInt, min: 0, max: 15},
+	{env: "BOT_READER_SESSION", key: "reader.session", kind: kindInt, min: 0, max: 3},
+	{env: "BOT_READER_POWER", key: "reader.power", kind: kindInt, min: 0, max: 30},
+	{env: "BOT_READER_SCAN_TIME", key: "reader.scan_time", kind: kindInt, min: 1, max: 255},
+	{env: "BOT_READER_ANTENNAS", key: "reader.antennas", kind: kindList},
+	{env: "BOT_READER_POLL_MS", key: "reader.poll_ms", kind: kindInt, min: 10, max: 10_000},
+	{env: "BOT_READER_STATE_FILE", key: "reader.state_file"},
+	{env: "BOT_READER_KNOWN_FILE", key: "reader.known_file"},
+	{env: "BOT_READER_SCAN_TARGETS", key: "reader.scan_targets", kind: kindList},
+	{env: "BOT_READER_SCAN_EXCLUDE", key: "reader.scan_exclude", kind: kindList},
+	{env: "BOT_READER_SCAN_INTERFACES", key: "reader.scan_interfaces", kind: kindList},
+	{env: "BOT_ANTENNA_QUIET_SEC", key: "reader.antenna_quiet_sec", kind: kindInt, min: 0, max: 604_800},
+	{env: "BOT_READER_WATCHDOG_ROUNDS", key: "reader.watchdog_rounds", kind: kindInt, min: 0, max: 100_000},
+
+	{env: "BOT_HEALTH_REFRESH_MAX_AGE_SEC", key: "health.refresh_max_age_sec", kind: kindInt, min: 30, max: 86_400},
+	{env: "BOT_HEALTH_STALL_SEC", key: "health.stall_sec", kind: kindInt, min: 10, max: 3600},
+
+	{env: "BOT_SHUTDOWN_DRAIN_SEC", key: "shutdown.drain_sec", kind: kindInt, min: 0, max: 600},
+	{env: "BOT_SHUTDOWN_PENDING_FILE", key: "shutdown.pending_file"},
+	{env: "BOT_SHUTDOWN_NOTIFY", key: "shutdown.notify", kind: kindBool},
+
+	{env: "BOT_NOTIFY_WINDOW_SEC", key: "notify.window_sec", kind: kindInt, min: 1, max: 3600},
+	{env: "BOT_NOTIFY_RATE_PER_SEC", key: "notify.rate_per_sec", kind: kindInt, min: 1, max: 30},
+	{env: "BOT_NOTIFY_QUEUE_SIZE", key: "notify.queue_size", kind: kindInt, min: 16, max: 100_000},
+
+	{env: "BOT_REPORT_DAILY_AT", key: "report.daily_at"},
+
+	{env: "BOT_HISTORY_ENABLED", key: "history.enabled", kind: kindBool},
+	{env: "BOT_HISTORY_DIR", key: "history.dir"},
+	{env: "BOT_HISTORY_RETENTION_DAYS", key: "history.retention_days", kind: kindInt, min: 1, max: 3650},
+	{env: "BOT_HISTORY_MAX_MB", key: "history.max_mb", kind: kindInt, min: 16, max: 1_000_000},
+	{env: "BOT_HISTORY_READ_DEDUP_SEC", key: "history.read_dedup_sec", kind: kindInt, min: 0, max: 3600},
+}
+
+var settingsByEnv = func() map[string]setting {
+	out := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		out[s.env] = s
+	}
+	return out
+}()
+
+// ValidationError lists every invalid setting found while loading.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid config: " + e.Problems[0]
+	}
+	return fmt.Sprintf("invalid config (%d problems):\n  - %s", len(e.Problems), strings.Join(e.Problems, "\n  - "))
+}
+
+// source resolves settings from the environment first, then the config file.
+type source struct {
+	file     map[string]string
+	problems []string
+	warnings []string
+	shadowed []string
+}
+
+func newSource(path string) (*source, error) {
+	src := &source{file: make(map[string]string)}
+	if strings.TrimSpace(path) == "" {
+		return src, nil
+	}
+	values, err := parseFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	byKey := make(map[string]setting, len(settings))
+	for _, s := range settings {
+		byKey[s.key] = s
+	}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		s, ok := byKey[key]
+		if !ok {
+			src.problem(key, "unknown key")
+			continue
+		}
+		raw, err := fileValue(s, values[key])
+		if err != nil {
+			src.problem(key, err.Error())
+			continue
+		}
+		src.file[s.env] = raw
+		if strings.TrimSpace(os.Getenv(s.env)) != "" {
+			src.shadowed = append(src.shadowed, key+" ("+s.env+")")
+		}
+	}
+	return src, nil
+}
+
+// fileValue converts a parsed file value to the env string form after checking its type.
+func fileValue(s setting, v any) (string, error) {
+	switch s.kind {
+	case kindInt:
+		n, ok := v.(int64)
+		if !ok {
+			return "", fmt.Errorf("expected an integer")
+		}
+		return strconv.FormatInt(n, 10), nil
+	case kindBool:
+		b, ok := v.(bool)
+		if !ok {
+			return "", fmt.Errorf("expected true or false")
+		}
+		return strconv.FormatBool(b), nil
+	case kindList:
+		items, ok := v.([]any)
+		if !ok {
+			if str, isStr := v.(string); isStr {
+				return str, nil
+			}
+			return "", fmt.Errorf("expected an array")
+		}
+		parts := make([]string, 0, len(items))
+		for _, item := range items {
+			part := fmt.Sprint(item)
+			if strings.Contains(part, ",") {
+				return "", fmt.Errorf("item %q must not contain a comma", part)
+			}
+			parts = append(parts, part)
+		}
+		return strings.Join(parts, ","), nil
+	}
+	str, ok := v.(string)
+	if !ok {
+		return "", fmt.Errorf("expected a quoted string")
+	}
+	return str, nil
+}
+
+func (s *source) problem(key, msg string) {
+	s.problems = append(s.problems, key+": "+msg)
+}
+
+func (s *source) warn(key, msg string) {
+	s.warnings = append(s.warnings, key+": "+msg)
+}
+
+// lookup returns the raw value and the name to report problems under.
+func (s *source) lookup(env string) (string, string) {
+	if raw := strings.TrimSpace(os.Getenv(env)); raw != "" {
+		return raw, env
+	}
+	if raw, ok := s.file[env]; ok {
+		return strings.TrimSpace(raw), settingsByEnv[env].key
+	}
+	return "", env
+}
+
+func (s *source) str(env, fallback string) string {
+	raw, _ := s.lookup(env)
+	if raw == "" {
+		return fallback
+	}
+	return raw
+}
+
+func (s *source) int(env string, fallback int) int {
+	raw, name := s.lookup(env)
+	if raw == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil {
+		s.problem(name, fmt.Sprintf("expected an integer, got %q", raw))
+		return fallback
+	}
+	if spec, ok := settingsByEnv[env]; ok && (n < spec.min || n > spec.max) {
+		if spec.clamp {
+			clamped := min(max(n, spec.min), spec.max)
+			s.warn(name, fmt.Sprintf("%d is outside %d-%d, using %d; this will be an error in the next release", n, spec.min, spec.max, clamped))
+			return clamped
+		}
+		s.problem(name, fmt.Sprintf("must be between %d and %d, got %d", spec.min, spec.max, n))
+		return fallback
+	}
+	return n
+}
+
+func (s *source) bool(env string, fallback bool) bool {
+	raw, name := s.lookup(env)
+	switch strings.ToLower(raw) {
+	case "":
+		return fallback
+	case "1", "true", "yes", "on":
+		return true
+	case "0", "false", "no", "off":
+		return false
+	}
+	s.problem(name, fmt.Sprintf("expected a boolean, got %q", raw))
+	return fallback
+}
+
+func (s *source) seconds(env string, fallbackSec int) time.Duration {
+	return time.Duration(s.int(env, fallbackSec)) * time.Second
+}
+
+func (s *source) millis(env string, fallbackMS int) time.Duration {
+	return time.Duration(s.int(env, fallbackMS)) * time.Millisecond
+}
+
+// list splits a comma-separated value, dropping empty items.
+func (s *source) list(env string) []string {
+	raw, _ := s.lookup(env)
+	var items []string
+	for _, part := range strings.Split(raw, ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
+// ids parses a comma-separated list of Telegram user/chat IDs.
+func (s *source) ids(env string) []int64 {
+	raw, name := s.lookup(env)
+	var ids []int64
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		id, err := strconv.ParseInt(part, 10, 64)
+		if err != nil || id == 0 {
+			s.problem(name, fmt.Sprintf("invalid id %q", part))
+			continue
+		}
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// antennas parses a comma-separated list of antenna ports 1-8.
+func (s *source) antennas(env string, fallback []int) []int {
+	raw, name := s.lookup(env)
+	if raw == "" {
+		return fallback
+	}
+	ports, err := ParseAntennas(raw)
+	if err != nil {
+		s.problem(name, err.Error())
+		return fallback
+	}
+	return ports
+}
@@ -0,0 +1,249 @@
+package erp
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+type BreakerState string
+
+const (
+	BreakerClosed   BreakerState = "closed"
+	BreakerOpen     BreakerState = "open"
+	BreakerHalfOpen BreakerState = "half_open"
+)
+
+// ErrCircuitOpen is matched by errors returned while the breaker rejects calls.
+var ErrCircuitOpen = errors.New("ERP circuit breaker open")
+
+// OpenError is returned instead of calling ERP while the breaker is open.
+type OpenError struct {
+	RetryAfter time.Duration
+}
+
+func (e *OpenError) Error() string {
+	return fmt.Sprintf("%s (retry in %s)", ErrCircuitOpen.Error(), e.RetryAfter.Round(time.Millisecond))
+}
+
+func (e *OpenError) Is(target error) bool {
+	return target == ErrCircuitOpen
+}
+
+// BreakerConfig controls when the breaker trips and how it recovers.
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the breaker.
+	FailureThreshold int
+	// OpenTimeout is how long the breaker stays open before allowing probes.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of successful probes needed to close again.
+	HalfOpenProbes int
+}
+
+// BreakerStatus is a point-in-time snapshot of breaker state.
+type BreakerStatus struct {
+	State    BreakerState `json:"state"`
+	Since    time.Time    `json:"since"`
+	Failures int          `json:"failures"`
+	Trips    uint64       `json:"trips"`
+}
+
+// Breaker is a closed/open/half-open circuit breaker shared by all ERP calls.
+// A nil *Breaker allows every call.
+type Breaker struct {
+	cfg BreakerConfig
+	now func() time.Time
+
+	mu        sync.Mutex
+	state     BreakerState
+	since     time.Time
+	failures  int
+	openUntil time.Time
+	probes    int
+	successes int
+	trips     uint64
+	onChange  func(from, to BreakerState, st BreakerStatus)
+}
+
+func NewBreaker(cfg BreakerConfig) *Breaker {
+	if cfg.FailureThreshold < 1 {
+		cfg.FailureThreshold = 1
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes < 1 {
+		cfg.HalfOpenProbes = 1
+	}
+	b := &Breaker{
+		cfg:   cfg,
+		now:   time.Now,
+		state: BreakerClosed,
+	}
+	b.since = b.now()
+	return b
+}
+
+// OnStateChange registers a callback fired (outside the lock) on every transition.
+func (b *Breaker) OnStateChange(fn func(from, to BreakerState, st BreakerStatus)) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.onChange = fn
+	b.mu.Unlock()
+}
+
+func (b *Breaker) Status() BreakerStatus {
+	if b == nil {
+		return BreakerStatus{State: BreakerClosed}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.statusLocked()
+}
+
+// Allow reports whether a call may proceed. It returns an *OpenError otherwise.
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := b.now()
+	var fire func()
+	switch b.state {
+	case BreakerOpen:
+		if now.Before(b.openUntil) {
+			wait := b.openUntil.Sub(now)
+			b.mu.Unlock()
+			return &OpenError{RetryAfter: wait}
+		}
+		fire = b.transitionLocked(BreakerHalfOpen, now)
+		b.probes++
+	case BreakerHalfOpen:
+		if b.probes >= b.cfg.HalfOpenProbes {
+			b.mu.Unlock()
+			return &OpenError{RetryAfter: time.Second}
+		}
+		b.probes++
+	}
+	b.mu.Unlock()
+
+	if fire != nil {
+		fire()
+	}
+	return nil
+}
+
+// Success records a call that reached ERP and got a usable answer.
+func (b *Breaker) Success() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	var fire func()
+	switch b.state {
+	case BreakerClosed:
+		b.failures = 0
+	case BreakerHalfOpen:
+		if b.probes > 0 {
+			b.probes--
+		}
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			fire = b.transitionLocked(BreakerClosed, b.now())
+		}
+	}
+	b.mu.Unlock()
+
+	if fire != nil {
+		fire()
+	}
+}
+
+// Failure records a timeout, transport error or overload response.
+// retryAfter extends the open period when the server asked for a longer pause.
+func (b *Breaker) Failure(retryAfter time.Duration) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	now := b.now()
+	var fire func()
+	switch b.state {
+	case BreakerClosed:
+		b.failures++
+		if b.failures >= b.cfg.FailureThreshold {
+			fire = b.tripLocked(now, retryAfter)
+		}
+	case BreakerHalfOpen:
+		b.failures++
+		fire = b.tripLocked(now, retryAfter)
+	case BreakerOpen:
+		if until := now.Add(retryAfter); until.After(b.openUntil) {
+			b.openUntil = until
+		}
+	}
+	b.mu.Unlock()
+
+	if fire != nil {
+		fire()
+	}
+}
+
+// release returns a half-open probe slot without judging ERP health,
+// e.g. when the caller cancelled the request itself.
+func (b *Breaker) release() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	if b.state == BreakerHalfOpen && b.probes > 0 {
+		b.probes--
+	}
+	b.mu.Unlock()
+}
+
+func (b *Breaker) tripLocked(now time.Time, retryAfter time.Duration) func() {
+	wait := b.cfg.OpenTimeout
+	if retryAfter > wait {
+		wait = retryAfter
+	}
+	b.openUntil = now.Add(wait)
+	b.trips++
+	return b.transitionLocked(BreakerOpen, now)
+}
+
+func (b *Breaker) transitionLocked(to BreakerState, now time.Time) func() {
+	from := b.state
+	if from == to {
+		return nil
+	}
+	b.state = to
+	b.since = now
+	b.probes = 0
+	b.successes = 0
+	if to == BreakerClosed {
+		b.failures = 0
+	}
+
+	fn := b.onChange
+	if fn == nil {
+		return nil
+	}
+	st := b.statusLocked()
+	return func() { fn(from, to, st) }
+}
+
+func (b *Breaker) statusLocked() BreakerStatus {
+	return BreakerStatus{
+		State:    b.state,
+		Since:    b.since,
+		Failures: b.failures,
+		Trips:    b.trips,
+	}
+}
@@ -0,0 +1,144 @@
+package erp
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func newTestBreaker(cfg BreakerConfig) (*Breaker, *time.Time) {
+	b := NewBreaker(cfg)
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return now }
+	return b, &now
+}
+
+func TestBreakerOpensAfterThreshold(t *testing.T) {
+	b, _ := newTestBreaker(BreakerConfig{FailureThreshold: 3, OpenTimeout: 10 * time.Second})
+
+	for i := 0; i < 2; i++ {
+		b.Failure(0)
+	}
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected closed breaker to allow, got %v", err)
+	}
+	b.Failure(0)
+
+	err := b.Allow()
+	if !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if got := RetryAfter(err); got != 10*time.Second {
+		t.Fatalf("retry after mismatch: got %s want 10s", got)
+	}
+	if st := b.Status(); st.State != BreakerOpen || st.Trips != 1 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
+
+func TestBreakerHalfOpenRecovers(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: 5 * time.Second, HalfOpenProbes: 1})
+
+	var transitions []BreakerState
+	b.OnStateChange(func(_, to BreakerState, _ BreakerStatus) {
+		transitions = append(transitions, to)
+	})
+
+	b.Failure(0)
+	*now = now.Add(6 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	if err := b.Allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected second probe to be rejected, got %v", err)
+	}
+	b.Success()
+
+	want := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(transitions) != len(want) {
+		t.Fatalf("transitions mismatch: got %v want %v", transitions, want)
+	}
+	for i := range want {
+		if transitions[i] != want[i] {
+			t.Fatalf("transitions mismatch: got %v want %v", transitions, want)
+		}
+	}
+}
+
+func TestBreakerHalfOpenFailureReopensWithRetryAfter(t *testing.T) {
+	b, now := newTestBreaker(BreakerConfig{FailureThreshold: 1, OpenTimeout: 5 * time.Second})
+
+	b.Failure(0)
+	*now = now.Add(5 * time.Second)
+	if err := b.Allow(); err != nil {
+		t.Fatalf("expected probe to be allowed, got %v", err)
+	}
+	b.Failure(time.Minute)
+
+	if got := RetryAfter(b.Allow()); got != time.Minute {
+		t.Fatalf("expected Retry-After to extend open period, got %s", got)
+	}
+}
+
+func TestClientTripsBreakerOnServerErrors(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "7")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "k", "s", time.Second)
+	c.SetBreaker(NewBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Second}))
+
+	for i := 0; i < 2; i++ {
+		_, err := c.SubmitByEPC(context.Background(), "E200")
+		var statusErr *StatusError
+		if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusServiceUnavailable {
+			t.Fatalf("expected 503 StatusError, got %v", err)
+		}
+		if statusErr.RetryAfter != 7*time.Second {
+			t.Fatalf("retry after mismatch: got %s want 7s", statusErr.RetryAfter)
+		}
+	}
+
+	if _, err := c.FetchDraftEPCs(context.Background()); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected breaker to reject call, got %v", err)
+	}
+	if calls != 2 {
+		t.Fatalf("expected 2 upstream calls, got %d", calls)
+	}
+}
+
+func TestClientClientErrorsDoNotTripBreaker(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := New(srv.URL, "k", "s", time.Second)
+	c.SetBreaker(NewBreaker(BreakerConfig{FailureThreshold: 1}))
+
+	_, _ = c.SubmitByEPC(context.Background(), "E200")
+	if st := c.Breaker().Status(); st.State != BreakerClosed {
+		t.Fatalf("expected closed breaker after 403, got %s", st.State)
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	if got := parseRetryAfter("30", now); got != 30*time.Second {
+		t.Fatalf("seconds mismatch: got %s", got)
+	}
+	date := now.Add(90 * time.Second).Format(http.TimeFormat)
+	if got := parseRetryAfter(date, now); got != 90*time.Second {
+		t.Fatalf("http-date mismatch: got %s", got)
+	}
+	if got := parseRetryAfter("soon", now); got != 0 {
+		t.Fatalf("expected 0 for invalid value, got %s", got)
+	}
+}
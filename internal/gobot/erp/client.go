@@ -4,10 +4,12 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -16,6 +18,25 @@ type Client struct {
 	baseURL string
 	auth    string
 	http    *http.Client
+	breaker *Breaker
+	observe func(op string, elapsed time.Duration, err error)
+}
+
+// StatusError is a non-2xx ERP response.
+type StatusError struct {
+	Op         string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("ERP %s HTTP %d: %s", e.Op, e.StatusCode, e.Body)
+}
+
+// Overloaded reports whether the response means ERP is struggling rather than rejecting input.
+func (e *StatusError) Overloaded() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
 }
 
 type FetchResult struct {
@@ -41,6 +62,23 @@ func New(baseURL, apiKey, apiSecret string, timeout time.Duration) *Client {
 	}
 }
 
+// SetBreaker installs a circuit breaker shared by every ERP call of this client.
+func (c *Client) SetBreaker(b *Breaker) {
+	c.breaker = b
+}
+
+// SetObserver registers a hook called after every ERP round trip (not for breaker rejections).
+func (c *Client) SetObserver(fn func(op string, elapsed time.Duration, err error)) {
+	c.observe = fn
+}
+
+func (c *Client) Breaker() *Breaker {
+	if c == nil {
+		return nil
+	}
+	return c.breaker
+}
+
 func (c *Client) FetchDraftEPCs(ctx context.Context) (FetchResult, error) {
 	q := url.Values{}
 	q.Set("limit", "5000")
@@ -56,16 +94,10 @@ func (c *Client) FetchDraftEPCs(ctx context.Context) (FetchResult, error) {
 	}
 	req.Header.Set("Authorization", c.auth)
 
-	resp, err := c.http.Do(req)
+	body, err := c.do(req, "fast drafts")
 	if err != nil {
 		return FetchResult{}, err
 	}
-	defer resp.Body.Close()
-
-	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
-	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return FetchResult{}, fmt.Errorf("ERP fast drafts HTTP %d: %s", resp.StatusCode, compactBody(body))
-	}
 
 	var payload fastDraftEnvelope
 	if err := json.Unmarshal(body, &payload); err != nil {
@@ -105,47 +137,135 @@ func (c *Client) FetchDraftEPCs(ctx context.Context) (FetchResult, error) {
 	}, nil
 }
 
+// SubmitResult is the outcome of a submit. Draft is the Stock Entry name when ERP reports it.
+type SubmitResult struct {
+	Status SubmitStatus
+	Draft  string
+}
+
 func (c *Client) SubmitByEPC(ctx context.Context, epc string) (SubmitStatus, error) {
+	res, err := c.Submit(ctx, epc)
+	return res.Status, err
+}
+
+// Submit submits the open draft holding epc.
+func (c *Client) Submit(ctx context.Context, epc string) (SubmitResult, error) {
 	epc = NormalizeEPC(epc)
 	if epc == "" {
-		return "", fmt.Errorf("epc is empty")
+		return SubmitResult{}, fmt.Errorf("epc is empty")
 	}
 
 	body, _ := json.Marshal(map[string]string{"epc": epc})
 	endpoint := c.baseURL + "/api/method/titan_telegram.api.submit_open_stock_entry_by_epc"
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
 	if err != nil {
-		return "", err
+		return SubmitResult{}, err
 	}
 	req.Header.Set("Authorization", c.auth)
 	req.Header.Set("Content-Type", "application/json")
 
+	respBody, err := c.do(req, "submit")
+	if err != nil {
+		return SubmitResult{}, err
+	}
+
+	var payload submitEnvelope
+	if err := json.Unmarshal(respBody, &payload); err != nil {
+		return SubmitResult{}, fmt.Errorf("ERP submit decode: %w", err)
+	}
+
+	msg := payload.Message
+	draft := strings.TrimSpace(msg.StockEntry)
+	if draft == "" {
+		draft = strings.TrimSpace(msg.Name)
+	}
+	if msg.OK && msg.Status == string(SubmitStatusSubmitted) {
+		return SubmitResult{Status: SubmitStatusSubmitted, Draft: draft}, nil
+	}
+	if msg.OK && msg.Status == string(SubmitStatusNotFound) {
+		return SubmitResult{Status: SubmitStatusNotFound}, nil
+	}
+	if msg.Error != "" {
+		return SubmitResult{}, fmt.Errorf("ERP submit error: %s", msg.Error)
+	}
+	return SubmitResult{}, fmt.Errorf("ERP submit unexpected payload")
+}
+
+// do runs one ERP request through the breaker and returns the 2xx body.
+func (c *Client) do(req *http.Request, op string) ([]byte, error) {
+	if err := c.breaker.Allow(); err != nil {
+		return nil, err
+	}
+
+	started := time.Now()
+	body, err := c.roundTrip(req, op)
+	if c.observe != nil {
+		c.observe(op, time.Since(started), err)
+	}
+	return body, err
+}
+
+func (c *Client) roundTrip(req *http.Request, op string) ([]byte, error) {
 	resp, err := c.http.Do(req)
 	if err != nil {
-		return "", err
+		if errors.Is(err, context.Canceled) {
+			c.breaker.release()
+		} else {
+			c.breaker.Failure(0)
+		}
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 4<<20))
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		return "", fmt.Errorf("ERP submit HTTP %d: %s", resp.StatusCode, compactBody(respBody))
+		statusErr := &StatusError{
+			Op:         op,
+			StatusCode: resp.StatusCode,
+			Body:       compactBody(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+		}
+		if statusErr.Overloaded() {
+			c.breaker.Failure(statusErr.RetryAfter)
+		} else {
+			c.breaker.Success()
+		}
+		return nil, statusErr
 	}
+	c.breaker.Success()
+	return body, nil
+}
 
-	var payload submitEnvelope
-	if err := json.Unmarshal(respBody, &payload); err != nil {
-		return "", fmt.Errorf("ERP submit decode: %w", err)
+// RetryAfter returns the server- or breaker-requested pause carried by err, if any.
+func RetryAfter(err error) time.Duration {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	var openErr *OpenError
+	if errors.As(err, &openErr) {
+		return openErr.RetryAfter
 	}
+	return 0
+}
 
-	if payload.Message.OK && payload.Message.Status == string(SubmitStatusSubmitted) {
-		return SubmitStatusSubmitted, nil
+func parseRetryAfter(raw string, now time.Time) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0
 	}
-	if payload.Message.OK && payload.Message.Status == string(SubmitStatusNotFound) {
-		return SubmitStatusNotFound, nil
+	if sec, err := strconv.Atoi(raw); err == nil {
+		if sec < 0 {
+			return 0
+		}
+		return time.Duration(sec) * time.Second
 	}
-	if payload.Message.Error != "" {
-		return "", fmt.Errorf("ERP submit error: %s", payload.Message.Error)
+	if at, err := http.ParseTime(raw); err == nil {
+		if d := at.Sub(now); d > 0 {
+			return d
+		}
 	}
-	return "", fmt.Errorf("ERP submit unexpected payload")
+	return 0
 }
 
 func NormalizeEPC(raw string) string {
@@ -185,8 +305,10 @@ type fastDraftEnvelope struct {
 
 type submitEnvelope struct {
 	Message struct {
-		OK     bool   `json:"ok"`
-		Status string `json:"status"`
-		Error  string `json:"error"`
+		OK         bool   `json:"ok"`
+		Status     string `json:"status"`
+		Error      string `json:"error"`
+		StockEntry string `json:"stock_entry"`
+		Name       string `json:"name"`
 	} `json:"message"`
 }
@@ -0,0 +1,168 @@
+// Package events is an in-process fan-out bus for live bot events
+// (tag reads, ingest results, submits, cache refreshes, reader state).
+package events
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	TypeTag     = "tag"
+	TypeIngest  = "ingest"
+	TypeSubmit  = "submit"
+	TypeRefresh = "refresh"
+	TypeReader  = "reader"
+
+	TypeNotification = "notification"
+	TypeStats        = "stats"
+)
+
+// Event is one published occurrence. Data is a JSON-encodable payload owned by the publisher.
+type Event struct {
+	Seq    uint64    `json:"seq"`
+	Type   string    `json:"type"`
+	Time   time.Time `json:"time"`
+	Reader string    `json:"reader,omitempty"`
+	Data   any       `json:"data,omitempty"`
+}
+
+// Filter selects events by type and reader. Empty sets match everything.
+type Filter struct {
+	Types   map[string]struct{}
+	Readers map[string]struct{}
+}
+
+// ParseFilter builds a filter from comma-separated type and reader lists.
+func ParseFilter(types, readers []string) Filter {
+	return Filter{
+		Types:   splitSet(types, true),
+		Readers: splitSet(readers, false),
+	}
+}
+
+func (f Filter) Match(ev Event) bool {
+	if len(f.Types) > 0 {
+		if _, ok := f.Types[ev.Type]; !ok {
+			return false
+		}
+	}
+	if len(f.Readers) > 0 {
+		if _, ok := f.Readers[ev.Reader]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// Bus delivers events to subscribers without ever blocking publishers;
+// a subscriber whose buffer is full misses events and has Dropped incremented.
+type Bus struct {
+	seq  atomic.Uint64
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{subs: make(map[*Subscription]struct{})}
+}
+
+// Publish is safe to call on a nil *Bus.
+func (b *Bus) Publish(typ, reader string, data any) {
+	if b == nil {
+		return
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.subs) == 0 {
+		return
+	}
+
+	ev := Event{
+		Seq:    b.seq.Add(1),
+		Type:   typ,
+		Time:   time.Now(),
+		Reader: reader,
+		Data:   data,
+	}
+	for sub := range b.subs {
+		if !sub.filter.Match(ev) {
+			continue
+		}
+		select {
+		case sub.ch <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
+
+func (b *Bus) Subscribe(filter Filter, buffer int) *Subscription {
+	if buffer <= 0 {
+		buffer = 256
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{C: ch, ch: ch, bus: b, filter: filter}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Subscribers returns the number of active subscriptions.
+func (b *Bus) Subscribers() int {
+	if b == nil {
+		return 0
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subs)
+}
+
+type Subscription struct {
+	C <-chan Event
+
+	ch      chan Event
+	bus     *Bus
+	filter  Filter
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// Dropped reports how many events were skipped because the buffer was full.
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Close unsubscribes and closes C. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		close(s.ch)
+		s.bus.mu.Unlock()
+	})
+}
+
+func splitSet(values []string, lower bool) map[string]struct{} {
+	out := make(map[string]struct{})
+	for _, value := range values {
+		for _, part := range strings.Split(value, ",") {
+			part = strings.TrimSpace(part)
+			if lower {
+				part = strings.ToLower(part)
+			}
+			if part != "" {
+				out[part] = struct{}{}
+			}
+		}
+	}
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,48 @@
+package events
+
+import "testing"
+
+func TestBusFiltersByTypeAndReader(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(ParseFilter([]string{"tag,submit"}, []string{"10.0.0.5:2022"}), 8)
+	defer sub.Close()
+
+	bus.Publish(TypeTag, "10.0.0.5:2022", "a")
+	bus.Publish(TypeTag, "10.0.0.6:2022", "b")
+	bus.Publish(TypeRefresh, "", "c")
+	bus.Publish(TypeSubmit, "10.0.0.5:2022", "d")
+
+	var got []any
+	for len(sub.C) > 0 {
+		got = append(got, (<-sub.C).Data)
+	}
+	if len(got) != 2 || got[0] != "a" || got[1] != "d" {
+		t.Fatalf("unexpected events: %v", got)
+	}
+}
+
+func TestBusDropsWhenSubscriberIsSlow(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(Filter{}, 1)
+	defer sub.Close()
+
+	bus.Publish(TypeTag, "", 1)
+	bus.Publish(TypeTag, "", 2)
+	if sub.Dropped() != 1 {
+		t.Fatalf("expected 1 dropped event, got %d", sub.Dropped())
+	}
+}
+
+func TestSubscriptionCloseIsIdempotent(t *testing.T) {
+	bus := NewBus()
+	sub := bus.Subscribe(Filter{}, 1)
+	sub.Close()
+	sub.Close()
+	if bus.Subscribers() != 0 {
+		t.Fatalf("expected no subscribers, got %d", bus.Subscribers())
+	}
+	bus.Publish(TypeTag, "", 1)
+	if _, ok := <-sub.C; ok {
+		t.Fatal("expected closed channel")
+	}
+}
@@ -0,0 +1,91 @@
+// Package health aggregates component checks into liveness and readiness reports.
+package health
+
+import (
+	"sync"
+	"time"
+)
+
+// State is the outcome of one check. Degraded is reported but does not fail a probe.
+type State string
+
+const (
+	StateOK       State = "ok"
+	StateDegraded State = "degraded"
+	StateFail     State = "fail"
+)
+
+// Check is the result of one component check.
+type Check struct {
+	Name   string `json:"name"`
+	State  State  `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Probe evaluates one component at now.
+type Probe func(now time.Time) Check
+
+// Report is the aggregated result served on /health/live and /health/ready.
+type Report struct {
+	OK     bool      `json:"ok"`
+	State  State     `json:"state"`
+	Time   time.Time `json:"time"`
+	Checks []Check   `json:"checks"`
+}
+
+// Monitor holds the registered probes. Liveness probes are also part of readiness.
+type Monitor struct {
+	mu    sync.Mutex
+	live  []Probe
+	ready []Probe
+}
+
+func NewMonitor() *Monitor {
+	return &Monitor{}
+}
+
+// AddLive registers a probe whose failure means the process should be restarted.
+func (m *Monitor) AddLive(p Probe) {
+	m.mu.Lock()
+	m.live = append(m.live, p)
+	m.mu.Unlock()
+}
+
+// AddReady registers a probe whose failure means the bot cannot do useful work yet.
+func (m *Monitor) AddReady(p Probe) {
+	m.mu.Lock()
+	m.ready = append(m.ready, p)
+	m.mu.Unlock()
+}
+
+func (m *Monitor) Live(now time.Time) Report {
+	m.mu.Lock()
+	probes := append([]Probe(nil), m.live...)
+	m.mu.Unlock()
+	return run(now, probes)
+}
+
+func (m *Monitor) Ready(now time.Time) Report {
+	m.mu.Lock()
+	probes := append(append([]Probe(nil), m.live...), m.ready...)
+	m.mu.Unlock()
+	return run(now, probes)
+}
+
+func run(now time.Time, probes []Probe) Report {
+	rep := Report{OK: true, State: StateOK, Time: now, Checks: make([]Check, 0, len(probes))}
+	for _, p := range probes {
+		c := p(now)
+		rep.Checks = append(rep.Checks, c)
+		switch c.State {
+		case StateFail:
+			rep.OK = false
+			rep.State = StateFail
+		case StateDegraded:
+			if rep.State == StateOK {
+				rep.State = StateDegraded
+			}
+		}
+	}
+	return rep
+}
@@ -0,0 +1,28 @@
+package health
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadyIncludesLiveAndFailsOnAnyFailure(t *testing.T) {
+	m := NewMonitor()
+	m.AddLive(func(time.Time) Check { return Check{Name: "workers", State: StateOK} })
+	m.AddReady(func(time.Time) Check { return Check{Name: "erp_refresh", State: StateDegraded} })
+
+	now := time.Now()
+	if rep := m.Live(now); !rep.OK || len(rep.Checks) != 1 {
+		t.Fatalf("unexpected live report: %+v", rep)
+	}
+	if rep := m.Ready(now); !rep.OK || rep.State != StateDegraded || len(rep.Checks) != 2 {
+		t.Fatalf("degraded must not fail readiness: %+v", rep)
+	}
+
+	m.AddReady(func(time.Time) Check { return Check{Name: "reader", State: StateFail} })
+	if rep := m.Ready(now); rep.OK || rep.State != StateFail {
+		t.Fatalf("a failed check must fail readiness: %+v", rep)
+	}
+	if rep := m.Live(now); !rep.OK {
+		t.Fatalf("readiness failures must not affect liveness: %+v", rep)
+	}
+}
@@ -0,0 +1,512 @@
+// Package history is an append-only log of reads, ingest results, submit
+// outcomes and cache refreshes, kept in JSON-lines segment files with age and size retention.
+package history
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/reader"
+	"new_era_go/internal/gobot/service"
+)
+
+const (
+	KindRead    = "read"
+	KindIngest  = "ingest"
+	KindSubmit  = "submit"
+	KindRefresh = "refresh"
+)
+
+// Record is one history entry. Outcome is the ingest action for ingest
+// records, the submit status for submit records and ok or error for refresh
+// records, which carry no EPC.
+type Record struct {
+	Time    time.Time `json:"time"`
+	Kind    string    `json:"kind"`
+	EPC     string    `json:"epc"`
+	Reader  string    `json:"reader,omitempty"`
+	Antenna int       `json:"antenna,omitempty"`
+	RSSI    int       `json:"rssi,omitempty"`
+	Outcome string    `json:"outcome,omitempty"`
+	Draft   string    `json:"draft,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+type Config struct {
+	Dir string
+	// Retention drops segments whose newest record is older than this.
+	Retention time.Duration
+	// MaxBytes caps the total size of all segments; the oldest go first.
+	MaxBytes int64
+	// SegmentBytes rolls the active segment once it grows past this size.
+	SegmentBytes int64
+	// ReadDedup records a repeated read of the same EPC, reader and antenna at most once per window.
+	ReadDedup time.Duration
+}
+
+const (
+	segmentPrefix = "history-"
+	segmentSuffix = ".jsonl"
+	segmentLayout = "20060102-150405.000"
+	flushInterval = time.Second
+	// maxRecordBytes bounds one JSON line when reading segments back.
+	maxRecordBytes = 1 << 20
+)
+
+type Store struct {
+	cfg Config
+	now func() time.Time
+
+	mu       sync.Mutex
+	file     *os.File
+	buf      *bufio.Writer
+	size     int64
+	day      string
+	lastRead map[string]time.Time
+}
+
+// Open prepares the history directory. Segments are created lazily on the first write.
+func Open(cfg Config) (*Store, error) {
+	cfg.Dir = strings.TrimSpace(cfg.Dir)
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("history dir is empty")
+	}
+	if cfg.SegmentBytes <= 0 {
+		cfg.SegmentBytes = 16 << 20
+	}
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return nil, err
+	}
+	s := &Store{cfg: cfg, now: time.Now, lastRead: make(map[string]time.Time)}
+	s.mu.Lock()
+	s.enforceRetentionLocked()
+	s.mu.Unlock()
+	return s, nil
+}
+
+// Run records bus events until ctx ends, flushing every second. When ctx
+// ends it records the events already queued, then flushes and closes the
+// segment, so a shutdown that cancels ctx and waits for Run loses nothing
+// published before the cancel.
+func (s *Store) Run(ctx context.Context, bus *events.Bus) {
+	sub := bus.Subscribe(events.ParseFilter([]string{events.TypeTag, events.TypeIngest, events.TypeSubmit, events.TypeRefresh}, nil), 8192)
+	defer sub.Close()
+
+	t := time.NewTicker(flushInterval)
+	defer t.Stop()
+	var reported uint64
+	for {
+		select {
+		case <-ctx.Done():
+			for {
+				select {
+				case ev, ok := <-sub.C:
+					if !ok {
+						s.Close()
+						return
+					}
+					if rec, ok := recordFor(ev); ok {
+						s.Append(rec)
+					}
+				default:
+					s.Close()
+					return
+				}
+			}
+		case ev, ok := <-sub.C:
+			if !ok {
+				s.Close()
+				return
+			}
+			if rec, ok := recordFor(ev); ok {
+				s.Append(rec)
+			}
+		case <-t.C:
+			if d := sub.Dropped(); d > reported {
+				log.Printf("[bot] history missed %d event(s) under load", d-reported)
+				reported = d
+			}
+			s.Flush()
+		}
+	}
+}
+
+func recordFor(ev events.Event) (Record, bool) {
+	rec := Record{Time: ev.Time, Reader: ev.Reader}
+	switch data := ev.Data.(type) {
+	case reader.TagEvent:
+		rec.Kind = KindRead
+		rec.EPC = strings.ToUpper(strings.TrimSpace(data.EPC))
+		rec.Antenna = data.Antenna
+		rec.RSSI = data.RSSI
+	case service.IngestEvent:
+		rec.Kind = KindIngest
+		rec.EPC = data.EPC
+		rec.Outcome = data.Action
+		rec.Error = data.Error
+	case service.SubmitEvent:
+		rec.Kind = KindSubmit
+		rec.EPC = data.EPC
+		rec.Outcome = data.Status
+		rec.Draft = data.Draft
+		rec.Error = data.Error
+	case service.RefreshEvent:
+		rec.Kind = KindRefresh
+		rec.Outcome = "ok"
+		if !data.OK {
+			rec.Outcome = "error"
+		}
+		rec.Error = data.Error
+		return rec, true
+	default:
+		return Record{}, false
+	}
+	return rec, rec.EPC != ""
+}
+
+// Append writes rec to the active segment. Repeated reads inside the dedup window are skipped.
+func (s *Store) Append(rec Record) {
+	if rec.Time.IsZero() {
+		rec.Time = s.now()
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if rec.Kind == KindRead && s.cfg.ReadDedup > 0 {
+		key := fmt.Sprintf("%s|%s|%d", rec.EPC, rec.Reader, rec.Antenna)
+		if last, ok := s.lastRead[key]; ok && rec.Time.Sub(last) < s.cfg.ReadDedup {
+			return
+		}
+		s.lastRead[key] = rec.Time
+		if len(s.lastRead) > 50_000 {
+			s.gcReadsLocked(rec.Time)
+		}
+	}
+
+	if err := s.rotateLocked(rec.Time); err != nil {
+		log.Printf("[bot] history segment open failed: %v", err)
+		return
+	}
+	n, err := s.buf.Write(append(line, '\n'))
+	s.size += int64(n)
+	if err != nil {
+		log.Printf("[bot] history write failed: %v", err)
+	}
+}
+
+func (s *Store) gcReadsLocked(now time.Time) {
+	for key, at := range s.lastRead {
+		if now.Sub(at) >= s.cfg.ReadDedup {
+			delete(s.lastRead, key)
+		}
+	}
+}
+
+// rotateLocked opens a new segment on the first write, on a new day or when the active one is full.
+func (s *Store) rotateLocked(at time.Time) error {
+	day := at.In(time.Local).Format("20060102")
+	if s.file != nil && s.day == day && s.size < s.cfg.SegmentBytes {
+		return nil
+	}
+	s.closeLocked()
+
+	name := filepath.Join(s.cfg.Dir, segmentPrefix+at.In(time.Local).Format(segmentLayout)+segmentSuffix)
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, _ := f.Stat()
+	s.file = f
+	s.buf = bufio.NewWriterSize(f, 64<<10)
+	s.day = day
+	s.size = 0
+	if info != nil {
+		s.size = info.Size()
+	}
+	s.enforceRetentionLocked()
+	return nil
+}
+
+// Flush pushes buffered records to disk.
+func (s *Store) Flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.buf != nil {
+		if err := s.buf.Flush(); err != nil {
+			log.Printf("[bot] history flush failed: %v", err)
+		}
+	}
+}
+
+func (s *Store) Close() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.closeLocked()
+}
+
+func (s *Store) closeLocked() {
+	if s.file == nil {
+		return
+	}
+	_ = s.buf.Flush()
+	_ = s.file.Close()
+	s.file, s.buf = nil, nil
+}
+
+type segment struct {
+	path  string
+	start time.Time
+	size  int64
+	mod   time.Time
+}
+
+// segments lists segment files oldest first.
+func (s *Store) segments() []segment {
+	entries, err := os.ReadDir(s.cfg.Dir)
+	if err != nil {
+		return nil
+	}
+	var out []segment
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		stamp := strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix)
+		start, err := time.ParseInLocation(segmentLayout, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		out = append(out, segment{path: filepath.Join(s.cfg.Dir, name), start: start, size: info.Size(), mod: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].start.Before(out[j].start) })
+	return out
+}
+
+func (s *Store) enforceRetentionLocked() {
+	segs := s.segments()
+	var active string
+	if s.file != nil {
+		active = s.file.Name()
+	}
+	var total int64
+	for _, seg := range segs {
+		total += seg.size
+	}
+	now := s.now()
+	for _, seg := range segs {
+		if seg.path == active {
+			break
+		}
+		expired := s.cfg.Retention > 0 && now.Sub(seg.mod) > s.cfg.Retention
+		oversize := s.cfg.MaxBytes > 0 && total > s.cfg.MaxBytes
+		if !expired && !oversize {
+			break
+		}
+		if err := os.Remove(seg.path); err != nil {
+			log.Printf("[bot] history retention remove failed: %v", err)
+			break
+		}
+		total -= seg.size
+	}
+}
+
+// Query selects records. Zero fields match everything; Draft matches the
+// submit records for that draft plus every other record of their EPCs.
+type Query struct {
+	EPC     string    `json:"epc,omitempty"`
+	Draft   string    `json:"draft,omitempty"`
+	Kind    string    `json:"kind,omitempty"`
+	Outcome string    `json:"outcome,omitempty"`
+	From    time.Time `json:"from,omitempty"`
+	To      time.Time `json:"to,omitempty"`
+	Limit   int       `json:"limit,omitempty"`
+}
+
+const (
+	DefaultLimit = 500
+	MaxLimit     = 5000
+)
+
+// Result holds matching records oldest first. Truncated means older matches were cut by Limit.
+type Result struct {
+	Records   []Record `json:"records"`
+	Truncated bool     `json:"truncated"`
+}
+
+// Find returns the newest Limit records matching q.
+func (s *Store) Find(q Query) (Result, error) {
+	q.EPC = strings.ToUpper(strings.TrimSpace(q.EPC))
+	q.Draft = strings.TrimSpace(q.Draft)
+	q.Kind = strings.ToLower(strings.TrimSpace(q.Kind))
+	q.Outcome = strings.ToLower(strings.TrimSpace(q.Outcome))
+	if q.Limit <= 0 {
+		q.Limit = DefaultLimit
+	}
+	if q.Limit > MaxLimit {
+		q.Limit = MaxLimit
+	}
+	s.Flush()
+
+	var epcs map[string]struct{}
+	if q.Draft != "" {
+		epcs = make(map[string]struct{})
+		err := s.scan(q.From, q.To, func(rec Record) bool {
+			if rec.Kind == KindSubmit && rec.Draft == q.Draft {
+				epcs[rec.EPC] = struct{}{}
+			}
+			return true
+		}, nil)
+		if err != nil {
+			return Result{}, err
+		}
+		if len(epcs) == 0 {
+			return Result{Records: []Record{}}, nil
+		}
+	}
+
+	// Segments come newest first and records oldest first within each, so
+	// keep the newest room matches of a segment and put them in front of
+	// those from newer segments.
+	var out Result
+	room := q.Limit
+	var seg []Record
+	err := s.scan(q.From, q.To, func(rec Record) bool {
+		if !q.match(rec, epcs) {
+			return true
+		}
+		if room == 0 {
+			out.Truncated = true
+			return false
+		}
+		seg = append(seg, rec)
+		if len(seg) > room {
+			seg = seg[1:]
+			out.Truncated = true
+		}
+		return true
+	}, func() bool {
+		out.Records = append(seg, out.Records...)
+		room = q.Limit - len(out.Records)
+		seg = nil
+		return !out.Truncated
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	if out.Records == nil {
+		out.Records = []Record{}
+	}
+	return out, nil
+}
+
+func (q Query) match(rec Record, epcs map[string]struct{}) bool {
+	if q.EPC != "" && rec.EPC != q.EPC {
+		return false
+	}
+	if epcs != nil {
+		if _, ok := epcs[rec.EPC]; !ok {
+			return false
+		}
+	}
+	if q.Kind != "" && rec.Kind != q.Kind {
+		return false
+	}
+	if q.Outcome != "" && rec.Outcome != q.Outcome {
+		return false
+	}
+	if !q.From.IsZero() && rec.Time.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && !rec.Time.Before(q.To) {
+		return false
+	}
+	return true
+}
+
+// Scan calls fn for every record in [from, to) until it returns false. Unlike
+// Find it has no limit; records come oldest first within a segment, but
+// segments are visited newest first.
+func (s *Store) Scan(from, to time.Time, fn func(Record) bool) error {
+	s.Flush()
+	q := Query{From: from, To: to}
+	return s.scan(from, to, func(rec Record) bool {
+		if !q.match(rec, nil) {
+			return true
+		}
+		return fn(rec)
+	}, nil)
+}
+
+// scan streams the segments overlapping [from, to), newest segment first and
+// each segment oldest record first, calling fn until it returns false.
+// segmentDone, when set, runs after each segment and stops the scan when it
+// returns false.
+func (s *Store) scan(from, to time.Time, fn func(Record) bool, segmentDone func() bool) error {
+	segs := s.segments()
+	for i := len(segs) - 1; i >= 0; i-- {
+		seg := segs[i]
+		if !to.IsZero() && !seg.start.Before(to) {
+			continue
+		}
+		if !from.IsZero() && seg.mod.Before(from) {
+			break
+		}
+		more, err := scanSegment(seg.path, fn)
+		if err != nil {
+			return err
+		}
+		if !more || (segmentDone != nil && !segmentDone()) {
+			return nil
+		}
+	}
+	return nil
+}
+
+// scanSegment streams one segment file line by line. It reports false when
+// fn stopped the scan. A segment removed by retention meanwhile is skipped.
+func scanSegment(path string, fn func(Record) bool) (bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return true, nil
+		}
+		return false, err
+	}
+	defer f.Close()
+
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64<<10), maxRecordBytes)
+	for sc.Scan() {
+		line := sc.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var rec Record
+		if json.Unmarshal(line, &rec) != nil {
+			continue
+		}
+		if !fn(rec) {
+			return false, nil
+		}
+	}
+	return true, sc.Err()
+}
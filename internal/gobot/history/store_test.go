@@ -0,0 +1,148 @@
+package history
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/service"
+)
+
+func TestFindByEPCDraftAndRange(t *testing.T) {
+	s, err := Open(Config{Dir: t.TempDir(), ReadDedup: 10 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Hour)
+	at := func(sec int) time.Time { return base.Add(time.Duration(sec) * time.Second) }
+
+	s.Append(Record{Time: at(0), Kind: KindRead, EPC: "E1", Reader: "r1", Antenna: 1, RSSI: -40})
+	s.Append(Record{Time: at(2), Kind: KindRead, EPC: "E1", Reader: "r1", Antenna: 1, RSSI: -41})
+	s.Append(Record{Time: at(3), Kind: KindRead, EPC: "E1", Reader: "r1", Antenna: 2})
+	s.Append(Record{Time: at(4), Kind: KindIngest, EPC: "E1", Outcome: "queued"})
+	s.Append(Record{Time: at(5), Kind: KindSubmit, EPC: "E1", Outcome: "submitted", Draft: "MAT-STE-0001"})
+	s.Append(Record{Time: at(6), Kind: KindIngest, EPC: "E2", Outcome: "miss"})
+	s.Append(Record{Time: at(20), Kind: KindRead, EPC: "E1", Reader: "r1", Antenna: 1})
+
+	res, err := s.Find(Query{EPC: "e1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Records) != 5 {
+		t.Fatalf("expected 5 E1 records after read dedup, got %+v", res.Records)
+	}
+	if !res.Records[0].Time.Equal(at(0)) || res.Records[0].RSSI != -40 {
+		t.Fatalf("records should be oldest first: %+v", res.Records[0])
+	}
+
+	res, _ = s.Find(Query{Draft: "MAT-STE-0001"})
+	if len(res.Records) != 5 || res.Records[3].Draft != "MAT-STE-0001" {
+		t.Fatalf("draft query should return every record of its EPCs: %+v", res.Records)
+	}
+
+	res, _ = s.Find(Query{Outcome: "miss"})
+	if len(res.Records) != 1 || res.Records[0].EPC != "E2" {
+		t.Fatalf("unexpected outcome query: %+v", res.Records)
+	}
+
+	res, _ = s.Find(Query{From: at(4), To: at(6), Limit: 1})
+	if len(res.Records) != 1 || !res.Truncated || res.Records[0].Kind != KindSubmit {
+		t.Fatalf("limit should keep the newest match in range: %+v", res)
+	}
+}
+
+func TestRetentionDropsOldestSegments(t *testing.T) {
+	dir := t.TempDir()
+	s, err := Open(Config{Dir: dir, SegmentBytes: 200, MaxBytes: 600})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now()
+	for i := 0; i < 40; i++ {
+		s.Append(Record{Time: base.Add(time.Duration(i) * time.Millisecond * 5), Kind: KindIngest, EPC: "E200001122334455", Outcome: "queued"})
+		s.Flush()
+	}
+	s.Close()
+
+	var total int64
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		info, _ := e.Info()
+		total += info.Size()
+	}
+	if len(entries) < 2 || total > 600+400 {
+		t.Fatalf("retention did not bound segments: %d files, %d bytes", len(entries), total)
+	}
+	res, _ := s.Find(Query{})
+	if len(res.Records) == 0 || len(res.Records) == 40 {
+		t.Fatalf("expected only the newest records to survive, got %d", len(res.Records))
+	}
+}
+
+func TestFindKeepsNewestAcrossSegments(t *testing.T) {
+	s, err := Open(Config{Dir: t.TempDir(), SegmentBytes: 300})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := time.Now().Add(-time.Minute)
+	for i := 0; i < 30; i++ {
+		s.Append(Record{Time: base.Add(time.Duration(i) * time.Second), Kind: KindIngest, EPC: fmt.Sprintf("E%02d", i), Outcome: "queued"})
+	}
+	s.Close()
+	if segs := s.segments(); len(segs) < 3 {
+		t.Fatalf("expected several segments, got %d", len(segs))
+	}
+
+	res, err := s.Find(Query{Limit: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !res.Truncated || len(res.Records) != 7 {
+		t.Fatalf("expected 7 truncated records, got %d truncated=%v", len(res.Records), res.Truncated)
+	}
+	for i, rec := range res.Records {
+		if want := fmt.Sprintf("E%02d", 23+i); rec.EPC != want {
+			t.Fatalf("record %d = %s, want %s (newest 7, oldest first)", i, rec.EPC, want)
+		}
+	}
+	if res, _ := s.Find(Query{Limit: 30}); res.Truncated || len(res.Records) != 30 {
+		t.Fatalf("limit equal to the total must not truncate: %d truncated=%v", len(res.Records), res.Truncated)
+	}
+}
+
+func TestRunRecordsQueuedEventsWhenStopped(t *testing.T) {
+	s, err := Open(Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	bus := events.NewBus()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		s.Run(ctx, bus)
+	}()
+	for bus.Subscribers() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	for i := 0; i < 50; i++ {
+		bus.Publish(events.TypeIngest, "", service.IngestEvent{IngestResult: service.IngestResult{EPC: fmt.Sprintf("E%02d", i), Action: "queued"}})
+	}
+	bus.Publish(events.TypeRefresh, "", service.RefreshEvent{Reason: "periodic", Error: "erp down"})
+	cancel()
+	<-done
+
+	res, err := s.Find(Query{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Records) != 51 {
+		t.Fatalf("expected all 51 events on disk after Run returned, got %d", len(res.Records))
+	}
+	if last := res.Records[50]; last.Kind != KindRefresh || last.Outcome != "error" || last.Error != "erp down" {
+		t.Fatalf("refresh failure not recorded: %+v", last)
+	}
+}
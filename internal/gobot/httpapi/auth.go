@@ -0,0 +1,324 @@
+package httpapi
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+)
+
+// Permission is what a route requires from the calling key.
+type Permission int
+
+const (
+	PermRead Permission = iota
+	PermIngest
+	PermOperate
+	PermAdmin
+)
+
+func (p Permission) String() string {
+	switch p {
+	case PermRead:
+		return "read"
+	case PermIngest:
+		return "ingest"
+	case PermOperate:
+		return "operate"
+	case PermAdmin:
+		return "admin"
+	}
+	return "unknown"
+}
+
+func roleAllows(role config.APIRole, perm Permission) bool {
+	switch role {
+	case config.RoleAdmin:
+		return true
+	case config.RoleOperator:
+		return perm == PermRead || perm == PermIngest || perm == PermOperate
+	case config.RoleReadOnly:
+		return perm == PermRead
+	case config.RoleIngest:
+		return perm == PermIngest
+	}
+	return false
+}
+
+// Request signing headers. The signature is hex HMAC-SHA256 over
+// METHOD "\n" PATH?QUERY "\n" TIMESTAMP "\n" NONCE "\n" hex(SHA256(body)).
+// A nonce is accepted once per key within the timestamp skew window.
+const (
+	HeaderAuthKey       = "X-Auth-Key"
+	HeaderAuthTimestamp = "X-Auth-Timestamp"
+	HeaderAuthNonce     = "X-Auth-Nonce"
+	HeaderAuthSignature = "X-Auth-Signature"
+	HeaderAPIKey        = "X-API-Key"
+)
+
+// Nonce length bounds; 16 random bytes hex-encoded fit comfortably.
+const (
+	minNonceLen = 8
+	maxNonceLen = 128
+)
+
+var (
+	errNoCredentials  = errors.New("missing credentials")
+	errBadCredentials = errors.New("invalid credentials")
+	errBadSignature   = errors.New("invalid signature")
+	errStaleSignature = errors.New("signature timestamp out of range")
+	errReplayedNonce  = errors.New("signature nonce already used")
+)
+
+// Auth authenticates HTTP API requests by plain API key (Authorization: Bearer
+// or X-API-Key) or by HMAC-signed request, and enforces role permissions.
+// Keys are only read from headers so they never land in access logs.
+// A nil *Auth allows everything, matching the pre-auth behaviour.
+type Auth struct {
+	keys   []config.APIKey
+	byName map[string]config.APIKey
+	skew   time.Duration
+	now    func() time.Time
+
+	mu        sync.Mutex
+	nonces    map[string]time.Time // key name + nonce -> when it may be forgotten
+	pruneSize int
+}
+
+func NewAuth(keys []config.APIKey, skew time.Duration) *Auth {
+	if len(keys) == 0 {
+		return nil
+	}
+	if skew <= 0 {
+		skew = 5 * time.Minute
+	}
+	a := &Auth{
+		keys:   append([]config.APIKey(nil), keys...),
+		byName: make(map[string]config.APIKey, len(keys)),
+		skew:   skew,
+		now:    time.Now,
+		nonces: make(map[string]time.Time),
+	}
+	for _, key := range keys {
+		a.byName[key.Name] = key
+	}
+	return a
+}
+
+// authenticate identifies the calling key. It may replace r.Body when a
+// signature covers the payload.
+func (a *Auth) authenticate(r *http.Request) (config.APIKey, error) {
+	if name := strings.TrimSpace(r.Header.Get(HeaderAuthKey)); name != "" {
+		return a.verifySignature(r, name)
+	}
+
+	secret := ""
+	if v := strings.TrimSpace(r.Header.Get("Authorization")); v != "" {
+		scheme, token, ok := strings.Cut(v, " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			return config.APIKey{}, errBadCredentials
+		}
+		secret = strings.TrimSpace(token)
+	} else if v := strings.TrimSpace(r.Header.Get(HeaderAPIKey)); v != "" {
+		secret = v
+	}
+	if secret == "" {
+		return config.APIKey{}, errNoCredentials
+	}
+
+	// Compare fixed-size digests against every key so timing reveals neither
+	// the secret length nor which key matched.
+	var found config.APIKey
+	matched := 0
+	for _, key := range a.keys {
+		if secretEqual(secret, key.Secret) {
+			found = key
+			matched = 1
+		}
+	}
+	if matched == 0 {
+		return config.APIKey{}, errBadCredentials
+	}
+	return found, nil
+}
+
+func secretEqual(got, want string) bool {
+	g := sha256.Sum256([]byte(got))
+	w := sha256.Sum256([]byte(want))
+	return subtle.ConstantTimeCompare(g[:], w[:]) == 1
+}
+
+func (a *Auth) verifySignature(r *http.Request, name string) (config.APIKey, error) {
+	key, ok := a.byName[name]
+	if !ok {
+		return config.APIKey{}, errBadCredentials
+	}
+
+	ts, err := strconv.ParseInt(strings.TrimSpace(r.Header.Get(HeaderAuthTimestamp)), 10, 64)
+	if err != nil {
+		return key, errBadSignature
+	}
+	if d := a.now().Sub(time.Unix(ts, 0)); d > a.skew || d < -a.skew {
+		return key, errStaleSignature
+	}
+	nonce := strings.TrimSpace(r.Header.Get(HeaderAuthNonce))
+	if len(nonce) < minNonceLen || len(nonce) > maxNonceLen {
+		return key, errBadSignature
+	}
+	sig, err := hex.DecodeString(strings.TrimSpace(r.Header.Get(HeaderAuthSignature)))
+	if err != nil || len(sig) == 0 {
+		return key, errBadSignature
+	}
+
+	var body []byte
+	if r.Body != nil {
+		body, err = io.ReadAll(io.LimitReader(r.Body, 1<<20+1))
+		_ = r.Body.Close()
+		if err != nil {
+			return key, errBadSignature
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	expected := SignRequest(key.Secret, r.Method, r.URL.RequestURI(), ts, nonce, body)
+	if !hmac.Equal(sig, expected) {
+		return key, errBadSignature
+	}
+	if !a.useNonce(key.Name, nonce, time.Unix(ts, 0).Add(a.skew)) {
+		return key, errReplayedNonce
+	}
+	return key, nil
+}
+
+// useNonce records nonce for key until expires and reports whether it was
+// unused. Past expires the timestamp check rejects the request anyway.
+func (a *Auth) useNonce(name, nonce string, expires time.Time) bool {
+	id := name + "\n" + nonce
+	now := a.now()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if until, ok := a.nonces[id]; ok && now.Before(until) {
+		return false
+	}
+	if len(a.nonces) >= a.pruneSize {
+		for k, until := range a.nonces {
+			if !now.Before(until) {
+				delete(a.nonces, k)
+			}
+		}
+		a.pruneSize = max(1024, 2*len(a.nonces))
+	}
+	a.nonces[id] = expires
+	return true
+}
+
+// SignRequest returns the raw HMAC a client must send hex-encoded in X-Auth-Signature.
+func SignRequest(secret, method, requestURI string, timestamp int64, nonce string, body []byte) []byte {
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strings.ToUpper(method)))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(requestURI))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(nonce))
+	mac.Write([]byte("\n"))
+	mac.Write([]byte(hex.EncodeToString(bodySum[:])))
+	return mac.Sum(nil)
+}
+
+// guard wraps a handler with authentication and a permission check.
+// Rejections are always audited; accepted requests are audited unless read-only.
+func (s *Server) guard(perm Permission, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		a := s.auth
+		if a == nil {
+			next(w, r)
+			return
+		}
+
+		key, err := a.authenticate(r)
+		if err != nil {
+			auditLog(key.Name, key.Role, r, http.StatusUnauthorized, err.Error())
+			w.Header().Set("WWW-Authenticate", `Bearer realm="rfid-go-bot"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]any{"ok": false, "error": "unauthorized"})
+			return
+		}
+		if !roleAllows(key.Role, perm) {
+			auditLog(key.Name, key.Role, r, http.StatusForbidden, "role lacks "+perm.String())
+			writeJSON(w, http.StatusForbidden, map[string]any{"ok": false, "error": "forbidden"})
+			return
+		}
+		if perm == PermRead {
+			next(w, r)
+			return
+		}
+
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next(rec, r)
+		auditLog(key.Name, key.Role, r, rec.status, "")
+	}
+}
+
+func auditLog(name string, role config.APIRole, r *http.Request, status int, reason string) {
+	if name == "" {
+		name = "-"
+	}
+	if role == "" {
+		role = "-"
+	}
+	if reason != "" {
+		log.Printf("[bot] http audit key=%s role=%s %s %s remote=%s status=%d reason=%q", name, role, r.Method, r.URL.Path, r.RemoteAddr, status, reason)
+		return
+	}
+	log.Printf("[bot] http audit key=%s role=%s %s %s remote=%s status=%d", name, role, r.Method, r.URL.Path, r.RemoteAddr, status)
+}
+
+// statusRecorder captures the response code while keeping streaming and
+// hijacking available to the wrapped handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status = code
+		r.wroteHeader = true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Flush() {
+	if f, ok := r.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (r *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hj, ok := r.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("hijack not supported")
+	}
+	return hj.Hijack()
+}
+
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
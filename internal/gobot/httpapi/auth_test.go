@@ -0,0 +1,168 @@
+package httpapi
+
+import (
+	"bytes"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+)
+
+var testKeys = []config.APIKey{
+	{Name: "viewer", Secret: "viewer-secret-0001", Role: config.RoleReadOnly},
+	{Name: "dock", Secret: "dock-secret-000002", Role: config.RoleIngest},
+	{Name: "ops", Secret: "ops-secret-0000003", Role: config.RoleOperator},
+}
+
+func doRequest(t *testing.T, req *http.Request) int {
+	t.Helper()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s failed: %v", req.Method, req.URL.Path, err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+func TestAuthEnforcesRoles(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	s.SetAuth(NewAuth(testKeys, time.Minute))
+
+	cases := []struct {
+		method, path, key string
+		want              int
+	}{
+		{http.MethodGet, "/health", "", http.StatusOK},
+		{http.MethodGet, "/stats", "", http.StatusUnauthorized},
+		{http.MethodGet, "/stats", "wrong-secret-00000", http.StatusUnauthorized},
+		{http.MethodGet, "/stats", "viewer-secret-0001", http.StatusOK},
+		{http.MethodPost, "/scan/stop", "viewer-secret-0001", http.StatusForbidden},
+		{http.MethodPost, "/scan/stop", "dock-secret-000002", http.StatusForbidden},
+		{http.MethodGet, "/stats", "dock-secret-000002", http.StatusForbidden},
+		{http.MethodPost, "/scan/stop", "ops-secret-0000003", http.StatusOK},
+	}
+	for _, tc := range cases {
+		req, _ := http.NewRequest(tc.method, ts.URL+tc.path, nil)
+		if tc.key != "" {
+			req.Header.Set("Authorization", "Bearer "+tc.key)
+		}
+		if got := doRequest(t, req); got != tc.want {
+			t.Fatalf("%s %s key=%q: expected %d, got %d", tc.method, tc.path, tc.key, tc.want, got)
+		}
+	}
+
+	body := []byte(`{"epc":"E2000011","source":"dock-1"}`)
+	req, _ := http.NewRequest(http.MethodPost, ts.URL+"/ingest", bytes.NewReader(body))
+	req.Header.Set(HeaderAPIKey, "dock-secret-000002")
+	if got := doRequest(t, req); got != http.StatusOK {
+		t.Fatalf("ingest key should reach /ingest, got %d", got)
+	}
+
+	req, _ = http.NewRequest(http.MethodGet, ts.URL+"/stats?api_key=viewer-secret-0001", nil)
+	if got := doRequest(t, req); got != http.StatusUnauthorized {
+		t.Fatalf("key in the query string must be ignored, got %d", got)
+	}
+}
+
+func TestLegacyERPWebhookRequiresCredentials(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	s.SetAuth(NewAuth(testKeys, time.Minute))
+	s.webhookSecret = "hook-secret"
+
+	body := []byte(`{"doctype":"Stock Entry","name":"MAT-STE-0001","event":"on_update"}`)
+	for _, header := range []struct{ name, value string }{
+		{},
+		{"X-Webhook-Secret", "wrong-secret"},
+		{HeaderAPIKey, "viewer-secret-0001"},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/api/webhook/erp", bytes.NewReader(body))
+		if header.name != "" {
+			req.Header.Set(header.name, header.value)
+		}
+		if got := doRequest(t, req); got != http.StatusUnauthorized {
+			t.Fatalf("%s=%q: expected 401, got %d", header.name, header.value, got)
+		}
+	}
+}
+
+func TestAuthVerifiesSignedRequests(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	auth := NewAuth(testKeys, time.Minute)
+	s.SetAuth(auth)
+
+	body := []byte(`{"epc":"E2000011"}`)
+	signed := func(ts0 time.Time, nonce string, payload []byte) *http.Request {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+"/ingest?x=1", bytes.NewReader(payload))
+		unix := ts0.Unix()
+		req.Header.Set(HeaderAuthKey, "dock")
+		req.Header.Set(HeaderAuthTimestamp, strconv.FormatInt(unix, 10))
+		req.Header.Set(HeaderAuthNonce, nonce)
+		req.Header.Set(HeaderAuthSignature, hex.EncodeToString(SignRequest("dock-secret-000002", http.MethodPost, "/ingest?x=1", unix, nonce, body)))
+		return req
+	}
+
+	now := time.Now()
+	if got := doRequest(t, signed(now, "nonce-0001", body)); got != http.StatusOK {
+		t.Fatalf("valid signature rejected: %d", got)
+	}
+	if got := doRequest(t, signed(now, "nonce-0001", body)); got != http.StatusUnauthorized {
+		t.Fatalf("replayed nonce accepted: %d", got)
+	}
+	if got := doRequest(t, signed(now, "nonce-0002", body)); got != http.StatusOK {
+		t.Fatalf("fresh nonce rejected: %d", got)
+	}
+	if got := doRequest(t, signed(now, "", body)); got != http.StatusUnauthorized {
+		t.Fatalf("missing nonce accepted: %d", got)
+	}
+	if got := doRequest(t, signed(time.Now(), "nonce-0003", []byte(`{"epc":"E2999999"}`))); got != http.StatusUnauthorized {
+		t.Fatalf("tampered body accepted: %d", got)
+	}
+	if got := doRequest(t, signed(time.Now().Add(-10*time.Minute), "nonce-0004", body)); got != http.StatusUnauthorized {
+		t.Fatalf("stale signature accepted: %d", got)
+	}
+}
+
+func TestNonceForgottenAfterSkewWindow(t *testing.T) {
+	auth := NewAuth(testKeys, time.Minute)
+	now := time.Unix(1_700_000_000, 0)
+	auth.now = func() time.Time { return now }
+
+	expires := now.Add(time.Minute)
+	if !auth.useNonce("dock", "nonce-0001", expires) {
+		t.Fatal("first use rejected")
+	}
+	if auth.useNonce("dock", "nonce-0001", expires) {
+		t.Fatal("reuse inside the window accepted")
+	}
+	if !auth.useNonce("ops", "nonce-0001", expires) {
+		t.Fatal("nonces must be tracked per key")
+	}
+	now = expires
+	if !auth.useNonce("dock", "nonce-0001", now.Add(time.Minute)) {
+		t.Fatal("nonce still blocked after its window")
+	}
+}
+
+func TestParseAPIKeysValidates(t *testing.T) {
+	keys, err := config.ParseAPIKeys("ops:ops-secret-0000003:Operator, dock:dock-secret-000002:ingest-only")
+	if err != nil {
+		t.Fatalf("unexpected parse error: %v", err)
+	}
+	if len(keys) != 2 || keys[0].Role != config.RoleOperator {
+		t.Fatalf("unexpected keys: %+v", keys)
+	}
+
+	for _, raw := range []string{
+		"ops:short:operator",
+		"ops:ops-secret-0000003:root",
+		"ops:ops-secret-0000003",
+		"ops:ops-secret-0000003:admin,ops:ops-secret-0000004:admin",
+	} {
+		if _, err := config.ParseAPIKeys(raw); err == nil {
+			t.Fatalf("expected error for %q", raw)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package httpapi
+
+import (
+	"net/http"
+	"time"
+
+	"new_era_go/internal/gobot/health"
+)
+
+// SetHealth enables per-component /health/live and /health/ready.
+func (s *Server) SetHealth(m *health.Monitor) {
+	s.health = m
+}
+
+func (s *Server) handleLive(w http.ResponseWriter, r *http.Request) {
+	if s.health == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+		return
+	}
+	writeReport(w, s.health.Live(time.Now()), s.healthDetail(r))
+}
+
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if s.health == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+		return
+	}
+	writeReport(w, s.health.Ready(time.Now()), s.healthDetail(r))
+}
+
+// healthDetail reports whether the caller may see the individual checks:
+// always while the API is open, otherwise only with a key that can read.
+// Probes without a key still get the status code.
+func (s *Server) healthDetail(r *http.Request) bool {
+	if s.auth == nil {
+		return true
+	}
+	key, err := s.auth.authenticate(r)
+	return err == nil && roleAllows(key.Role, PermRead)
+}
+
+// writeReport answers 503 when any check failed so orchestrator probes can
+// rely on the status code alone.
+func writeReport(w http.ResponseWriter, rep health.Report, detail bool) {
+	status := http.StatusOK
+	if !rep.OK {
+		status = http.StatusServiceUnavailable
+	}
+	if !detail {
+		writeJSON(w, status, map[string]any{"ok": rep.OK})
+		return
+	}
+	writeJSON(w, status, rep)
+}
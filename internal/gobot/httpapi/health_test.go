@@ -0,0 +1,91 @@
+package httpapi
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/health"
+)
+
+func TestHealthReadyReturns503OnFailedCheck(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	m := health.NewMonitor()
+	m.AddLive(func(time.Time) health.Check { return health.Check{Name: "workers", State: health.StateOK} })
+	m.AddReady(func(time.Time) health.Check { return health.Check{Name: "reader", State: health.StateFail} })
+	s.SetHealth(m)
+
+	for path, want := range map[string]int{
+		"/health/live":  http.StatusOK,
+		"/health/ready": http.StatusServiceUnavailable,
+	} {
+		resp, err := http.Get(ts.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Fatalf("%s: got %d want %d", path, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestHealthHidesChecksWithoutKey(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	m := health.NewMonitor()
+	m.AddReady(func(time.Time) health.Check {
+		return health.Check{Name: "erp_refresh", State: health.StateFail, Detail: "last refresh 15m ago"}
+	})
+	s.SetHealth(m)
+	s.SetAuth(NewAuth(testKeys, time.Minute))
+
+	get := func(key string) (int, string) {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+"/health/ready", nil)
+		if key != "" {
+			req.Header.Set(HeaderAPIKey, key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	code, body := get("")
+	if code != http.StatusServiceUnavailable || strings.Contains(body, "erp_refresh") {
+		t.Fatalf("anonymous /health/ready: %d %s", code, body)
+	}
+	code, body = get("viewer-secret-0001")
+	if code != http.StatusServiceUnavailable || !strings.Contains(body, "last refresh 15m ago") {
+		t.Fatalf("authorized /health/ready: %d %s", code, body)
+	}
+}
+
+func TestHealthKeepsLegacyResponse(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	get := func() (int, string) {
+		resp, err := http.Get(ts.URL + "/health")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, body := get(); code != http.StatusOK || !strings.Contains(body, `"ok":true`) || !strings.Contains(body, `"service":"rfid-go-bot"`) {
+		t.Fatalf("/health without monitor: %d %s", code, body)
+	}
+
+	m := health.NewMonitor()
+	m.AddReady(func(time.Time) health.Check { return health.Check{Name: "reader", State: health.StateFail} })
+	s.SetHealth(m)
+	code, body := get()
+	if code != http.StatusOK || !strings.Contains(body, `"ok":false`) || !strings.Contains(body, `"service":"rfid-go-bot"`) || strings.Contains(body, "checks") {
+		t.Fatalf("/health with failing readiness: %d %s", code, body)
+	}
+}
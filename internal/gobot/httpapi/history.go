@@ -0,0 +1,79 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"new_era_go/internal/gobot/history"
+)
+
+// SetHistory enables GET /history.
+func (s *Server) SetHistory(h *history.Store) {
+	s.history = h
+}
+
+// handleHistory answers GET /history?epc=&draft=&kind=&outcome=&from=&to=&limit=.
+// from and to accept RFC 3339 or unix seconds.
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
+		return
+	}
+	if s.history == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "history disabled"})
+		return
+	}
+
+	params := r.URL.Query()
+	q := history.Query{
+		EPC:     params.Get("epc"),
+		Draft:   params.Get("draft"),
+		Kind:    params.Get("kind"),
+		Outcome: params.Get("outcome"),
+	}
+	var err error
+	if q.From, err = parseTimeParam(params.Get("from")); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "from: " + err.Error()})
+		return
+	}
+	if q.To, err = parseTimeParam(params.Get("to")); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "to: " + err.Error()})
+		return
+	}
+	if raw := params.Get("limit"); raw != "" {
+		if q.Limit, err = strconv.Atoi(raw); err != nil || q.Limit < 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "limit must be a positive integer"})
+			return
+		}
+	}
+
+	res, err := s.history.Find(q)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"ok":        true,
+		"count":     len(res.Records),
+		"truncated": res.Truncated,
+		"records":   res.Records,
+	})
+}
+
+func parseTimeParam(raw string) (time.Time, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, nil
+	}
+	if unix, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		return time.Unix(unix, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 or unix seconds, got %q", raw)
+	}
+	return t, nil
+}
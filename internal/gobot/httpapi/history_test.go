@@ -0,0 +1,59 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/history"
+)
+
+func TestHistoryQueriesStore(t *testing.T) {
+	s, _, ts := newTestServer(t)
+
+	resp, err := http.Get(ts.URL + "/history?epc=E1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("history without a store should be 503, got %d", resp.StatusCode)
+	}
+
+	store, err := history.Open(history.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetHistory(store)
+	now := time.Now()
+	store.Append(history.Record{Time: now.Add(-time.Minute), Kind: history.KindIngest, EPC: "E1", Outcome: "queued"})
+	store.Append(history.Record{Time: now, Kind: history.KindSubmit, EPC: "E1", Outcome: "submitted"})
+	store.Append(history.Record{Time: now, Kind: history.KindIngest, EPC: "E2", Outcome: "miss"})
+
+	resp, err = http.Get(ts.URL + "/history?epc=E1&outcome=submitted&from=" + now.Add(-time.Hour).Format(time.RFC3339))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		OK      bool             `json:"ok"`
+		Count   int              `json:"count"`
+		Records []history.Record `json:"records"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.OK || body.Count != 1 || body.Records[0].Kind != history.KindSubmit {
+		t.Fatalf("unexpected history response: %+v", body)
+	}
+
+	bad, err := http.Get(ts.URL + "/history?from=yesterday")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bad.Body.Close()
+	if bad.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid from should be 400, got %d", bad.StatusCode)
+	}
+}
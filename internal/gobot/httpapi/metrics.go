@@ -0,0 +1,96 @@
+package httpapi
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"new_era_go/internal/gobot/erp"
+	"new_era_go/internal/gobot/metrics"
+	"new_era_go/internal/gobot/reader"
+	"new_era_go/internal/gobot/service"
+)
+
+// readerStatusSource is implemented by scanners that expose SDK reader counters.
+type readerStatusSource interface {
+	Status() reader.Status
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", metrics.ContentType)
+	w.WriteHeader(http.StatusOK)
+
+	mw := metrics.NewWriter(w)
+	writeServiceMetrics(mw, s.svc.Status(), s.svc.ERPLatency(), time.Now())
+	if src, ok := s.scanner.(readerStatusSource); ok {
+		writeReaderMetrics(mw, src.Status())
+	}
+}
+
+func writeServiceMetrics(mw *metrics.Writer, st service.Stats, latency map[string]metrics.HistogramSnapshot, now time.Time) {
+	mw.Counter("rfid_bot_seen_total", "EPC reads received by the bot.", float64(st.SeenTotal))
+	mw.Counter("rfid_bot_cache_hits_total", "Reads that matched a draft EPC in cache.", float64(st.CacheHits))
+	mw.Counter("rfid_bot_cache_misses_total", "Reads that did not match any draft EPC.", float64(st.CacheMisses))
+	mw.Counter("rfid_bot_scan_inactive_total", "Reads ignored because scan was inactive.", float64(st.ScanInactive))
+	mw.Counter("rfid_bot_queue_dropped_total", "Submits dropped because the queue was full.", float64(st.QueueDropped))
+
+	const submits = "rfid_bot_submits_total"
+	const submitsHelp = "ERP submit attempts by final outcome."
+	mw.Counter(submits, submitsHelp, float64(st.SubmittedOK), metrics.L("outcome", "submitted"))
+	mw.Counter(submits, submitsHelp, float64(st.SubmitNotFound), metrics.L("outcome", "not_found"))
+	mw.Counter(submits, submitsHelp, float64(st.SubmitErrors), metrics.L("outcome", "error"))
+
+	mw.Gauge("rfid_bot_queue_depth", "Submits waiting in the queue.", float64(st.QueueDepth))
+	mw.Gauge("rfid_bot_queue_capacity", "Submit queue capacity.", float64(st.QueueCapacity))
+	mw.Gauge("rfid_bot_inflight", "Submits currently being sent to ERP.", float64(st.Inflight))
+	mw.Gauge("rfid_bot_cache_size", "Draft EPCs in cache.", float64(st.CacheSize))
+	mw.Gauge("rfid_bot_drafts", "Open drafts reported by ERP.", float64(st.DraftCount))
+	mw.Gauge("rfid_bot_scan_active", "1 when scan is active.", boolFloat(st.ScanActive))
+	mw.Gauge("rfid_bot_last_refresh_success", "1 when the last cache refresh succeeded.", boolFloat(st.LastRefreshOK))
+	if !st.LastRefreshAt.IsZero() {
+		mw.Gauge("rfid_bot_refresh_age_seconds", "Seconds since the last cache refresh attempt.", now.Sub(st.LastRefreshAt).Seconds())
+	}
+	mw.Gauge("rfid_bot_refresh_failures", "Consecutive periodic refresh failures.", float64(st.RefreshFailures))
+
+	for _, state := range []erp.BreakerState{erp.BreakerClosed, erp.BreakerOpen, erp.BreakerHalfOpen} {
+		mw.Gauge("rfid_bot_erp_breaker_state", "ERP circuit breaker state (1 for the current state).",
+			boolFloat(st.ERPBreaker == state), metrics.L("state", string(state)))
+	}
+	mw.Counter("rfid_bot_erp_breaker_trips_total", "Times the ERP circuit breaker opened.", float64(st.ERPBreakerTrips))
+
+	ops := make([]string, 0, len(latency))
+	for op := range latency {
+		ops = append(ops, op)
+	}
+	sort.Strings(ops)
+	for _, op := range ops {
+		mw.Histogram("rfid_bot_erp_request_duration_seconds", "ERP request latency by operation.", latency[op], metrics.L("op", op))
+	}
+}
+
+func writeReaderMetrics(mw *metrics.Writer, st reader.Status) {
+	mw.Gauge("rfid_reader_running", "1 when the SDK scan loop is running.", boolFloat(st.Running))
+	mw.Gauge("rfid_reader_connected", "1 when the reader TCP session is up.", boolFloat(st.Connected))
+	mw.Counter("rfid_reader_restarts_total", "Reader reconnects since start.", float64(st.RestartCount))
+	mw.Counter("rfid_reader_unique_tags_total", "Unique EPCs read by the SDK scanner.", float64(st.UniqueSeen))
+	mw.Counter("rfid_reader_reads_total", "Tag reads (including repeats) by the SDK scanner.", float64(st.TotalReads))
+	mw.Gauge("rfid_reader_tags_per_second", "Tag reads per second over the last 10 seconds.", st.ReadRate)
+
+	ants := make([]int, 0, len(st.AntennaReads))
+	for ant := range st.AntennaReads {
+		ants = append(ants, ant)
+	}
+	sort.Ints(ants)
+	for _, ant := range ants {
+		mw.Counter("rfid_reader_antenna_reads_total", "Tag reads per antenna.", float64(st.AntennaReads[ant]),
+			metrics.L("antenna", strconv.Itoa(ant)))
+	}
+}
+
+func boolFloat(v bool) float64 {
+	if v {
+		return 1
+	}
+	return 0
+}
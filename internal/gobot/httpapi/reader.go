@@ -0,0 +1,141 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/sdk"
+)
+
+// ReaderConfig reads and changes the reader's inventory tuning.
+type ReaderConfig interface {
+	Inventory() config.Inventory
+	UpdateInventory(ctx context.Context, u config.InventoryUpdate) (config.Inventory, error)
+}
+
+// SetReaderConfig enables GET/PUT /reader/config.
+func (s *Server) SetReaderConfig(rc ReaderConfig) {
+	s.readerConfig = rc
+}
+
+// handleReaderConfig answers GET (read) and PUT (operate) on /reader/config.
+// PUT takes a partial JSON object: {"power":25,"antennas":[1,2]}.
+func (s *Server) handleReaderConfig(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		s.guard(PermRead, s.handleReaderConfigGet)(w, r)
+	case http.MethodPut:
+		s.guard(PermOperate, s.handleReaderConfigPut)(w, r)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
+	}
+}
+
+func (s *Server) handleReaderConfigGet(w http.ResponseWriter, r *http.Request) {
+	if s.readerConfig == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "sdk reader disabled"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "config": s.readerConfig.Inventory()})
+}
+
+func (s *Server) handleReaderConfigPut(w http.ResponseWriter, r *http.Request) {
+	if s.readerConfig == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "sdk reader disabled"})
+		return
+	}
+
+	var u config.InventoryUpdate
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&u); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid json: " + err.Error()})
+		return
+	}
+
+	inv, err := s.readerConfig.UpdateInventory(r.Context(), u)
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": "invalid reader config", "problems": verr.Problems, "config": inv})
+	case err != nil:
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "config": inv, "warning": "saved, reader apply failed: " + err.Error()})
+	default:
+		writeJSON(w, http.StatusOK, map[string]any{"ok": true, "config": inv})
+	}
+}
+
+// AntennaMonitor reports per-antenna health and runs the antenna check.
+type AntennaMonitor interface {
+	AntennaHealth() []sdk.AntennaHealth
+	DiagnoseAntennas(ctx context.Context) ([]sdk.AntennaDiagnosis, error)
+}
+
+// SetAntennaMonitor enables GET /reader/antennas and POST /reader/antennas/check.
+func (s *Server) SetAntennaMonitor(am AntennaMonitor) {
+	s.antennas = am
+}
+
+func (s *Server) handleAntennas(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
+		return
+	}
+	if s.antennas == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "sdk reader disabled"})
+		return
+	}
+
+	health := s.antennas.AntennaHealth()
+	out := make([]map[string]any, 0, len(health))
+	for _, h := range health {
+		item := map[string]any{
+			"port":          h.Port,
+			"rounds":        h.Rounds,
+			"reads":         h.Reads,
+			"errors":        h.Errors,
+			"error_rate":    h.ErrorRate(),
+			"reads_per_min": h.ReadsPerMin,
+		}
+		if !h.LastRead.IsZero() {
+			item["last_read"] = h.LastRead
+		}
+		if !h.LastError.IsZero() {
+			item["last_error"] = h.LastError
+		}
+		out = append(out, item)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "connected": health != nil, "antennas": out})
+}
+
+// handleAntennaCheck runs the antenna check; it takes about two seconds per antenna.
+func (s *Server) handleAntennaCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
+		return
+	}
+	if s.antennas == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "sdk reader disabled"})
+		return
+	}
+
+	results, err := s.antennas.DiagnoseAntennas(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusConflict, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	out := make([]map[string]any, 0, len(results))
+	for _, d := range results {
+		out = append(out, map[string]any{
+			"port":   d.Port,
+			"state":  d.State,
+			"rounds": d.Rounds,
+			"reads":  d.Reads,
+			"errors": d.Errors,
+		})
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true, "antennas": out})
+}
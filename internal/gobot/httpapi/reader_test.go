@@ -0,0 +1,130 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/sdk"
+)
+
+type fakeReaderConfig struct {
+	inv config.Inventory
+}
+
+func (f *fakeReaderConfig) Inventory() config.Inventory { return f.inv }
+
+func (f *fakeReaderConfig) UpdateInventory(_ context.Context, u config.InventoryUpdate) (config.Inventory, error) {
+	inv, err := f.inv.Apply(u)
+	if err != nil {
+		return f.inv, err
+	}
+	f.inv = inv
+	return inv, nil
+}
+
+func TestReaderConfigGetAndPut(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	rc := &fakeReaderConfig{inv: config.Inventory{Q: 4, Session: 1, Power: 30, ScanTime: 1, Antennas: []int{1}}}
+	s.SetReaderConfig(rc)
+
+	put := func(body string) (int, map[string]any) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPut, ts.URL+"/reader/config", strings.NewReader(body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out map[string]any
+		if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+			t.Fatal(err)
+		}
+		return resp.StatusCode, out
+	}
+
+	if code, _ := put(`{"power":25,"antennas":[2,1]}`); code != http.StatusOK {
+		t.Fatalf("valid put returned %d", code)
+	}
+	if rc.inv.Power != 25 || len(rc.inv.Antennas) != 2 {
+		t.Fatalf("update not applied: %+v", rc.inv)
+	}
+	if code, body := put(`{"power":45}`); code != http.StatusBadRequest || body["problems"] == nil {
+		t.Fatalf("out-of-range power should be 400 with problems, got %d %v", code, body)
+	}
+	if code, _ := put(`{"pwr":10}`); code != http.StatusBadRequest {
+		t.Fatalf("unknown field should be 400, got %d", code)
+	}
+
+	resp, err := http.Get(ts.URL + "/reader/config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		OK     bool `json:"ok"`
+		Config struct {
+			Power    int   `json:"power"`
+			Antennas []int `json:"antennas"`
+		} `json:"config"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.OK || body.Config.Power != 25 || len(body.Config.Antennas) != 2 {
+		t.Fatalf("unexpected get response: %+v", body)
+	}
+}
+
+type fakeAntennas struct{}
+
+func (fakeAntennas) AntennaHealth() []sdk.AntennaHealth {
+	return []sdk.AntennaHealth{{Port: 1, Rounds: 10, Errors: 5, Reads: 0}}
+}
+
+func (fakeAntennas) DiagnoseAntennas(context.Context) ([]sdk.AntennaDiagnosis, error) {
+	return []sdk.AntennaDiagnosis{{Port: 1, State: sdk.AntennaDisconnected, Errors: 4}}, nil
+}
+
+func TestReaderAntennasHealthAndCheck(t *testing.T) {
+	s, _, ts := newTestServer(t)
+	s.SetAntennaMonitor(fakeAntennas{})
+
+	resp, err := http.Get(ts.URL + "/reader/antennas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var health struct {
+		Antennas []struct {
+			Port      int     `json:"port"`
+			ErrorRate float64 `json:"error_rate"`
+		} `json:"antennas"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		t.Fatal(err)
+	}
+	if len(health.Antennas) != 1 || health.Antennas[0].ErrorRate != 0.5 {
+		t.Fatalf("unexpected health: %+v", health)
+	}
+
+	check, err := http.Post(ts.URL+"/reader/antennas/check", "application/json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer check.Body.Close()
+	var diag struct {
+		Antennas []struct {
+			State string `json:"state"`
+		} `json:"antennas"`
+	}
+	if err := json.NewDecoder(check.Body).Decode(&diag); err != nil {
+		t.Fatal(err)
+	}
+	if len(diag.Antennas) != 1 || diag.Antennas[0].State != "disconnected" {
+		t.Fatalf("unexpected check result: %+v", diag)
+	}
+}
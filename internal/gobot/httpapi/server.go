@@ -8,6 +8,8 @@ import (
 	"strings"
 	"time"
 
+	"new_era_go/internal/gobot/health"
+	"new_era_go/internal/gobot/history"
 	"new_era_go/internal/gobot/service"
 )
 
@@ -17,6 +19,14 @@ type Server struct {
 	svc           *service.Service
 	scanner       Scanner
 	http          *http.Server
+	closing       chan struct{}
+	auth          *Auth
+	tlsCert       string
+	tlsKey        string
+	history       *history.Store
+	readerConfig  ReaderConfig
+	antennas      AntennaMonitor
+	health        *health.Monitor
 }
 
 type Scanner interface {
@@ -37,24 +47,57 @@ func New(addr, webhookSecret string, svc *service.Service, scanner Scanner) *Ser
 			Handler:           mux,
 			ReadHeaderTimeout: 5 * time.Second,
 		},
+		closing: make(chan struct{}),
 	}
+	s.http.RegisterOnShutdown(func() { close(s.closing) })
 
 	mux.HandleFunc("/health", s.handleHealth)
-	mux.HandleFunc("/stats", s.handleStats)
-	mux.HandleFunc("/ingest", s.handleIngest)
+	mux.HandleFunc("/health/live", s.handleLive)
+	mux.HandleFunc("/health/ready", s.handleReady)
+	mux.HandleFunc("/stats", s.guard(PermRead, s.handleStats))
+	mux.HandleFunc("/metrics", s.guard(PermRead, s.handleMetrics))
+	mux.HandleFunc("/events", s.guard(PermRead, s.handleEvents))
+	mux.HandleFunc("/ws", s.guard(PermRead, s.handleWebSocket))
+	mux.HandleFunc("/history", s.guard(PermRead, s.handleHistory))
+	mux.HandleFunc("/reader/config", s.handleReaderConfig)
+	mux.HandleFunc("/reader/antennas", s.guard(PermRead, s.handleAntennas))
+	mux.HandleFunc("/reader/antennas/check", s.guard(PermOperate, s.handleAntennaCheck))
+	mux.HandleFunc("/ingest", s.guard(PermIngest, s.handleIngest))
 	mux.HandleFunc("/webhook/draft", s.handleWebhookDraft)
 	mux.HandleFunc("/api/webhook/erp", s.handleLegacyERPWebhook)
-	mux.HandleFunc("/turbo", s.handleTurbo)
-	mux.HandleFunc("/scan/start", s.handleScanStart)
-	mux.HandleFunc("/scan/stop", s.handleScanStop)
+	mux.HandleFunc("/turbo", s.guard(PermOperate, s.handleTurbo))
+	mux.HandleFunc("/scan/start", s.guard(PermOperate, s.handleScanStart))
+	mux.HandleFunc("/scan/stop", s.guard(PermOperate, s.handleScanStop))
 	return s
 }
 
+// SetAuth enables API key / HMAC authentication. A nil auth leaves the API open.
+func (s *Server) SetAuth(a *Auth) {
+	s.auth = a
+}
+
+// SetTLS serves HTTPS with the given certificate and key files.
+func (s *Server) SetTLS(certFile, keyFile string) {
+	s.tlsCert = strings.TrimSpace(certFile)
+	s.tlsKey = strings.TrimSpace(keyFile)
+}
+
 func (s *Server) Run(ctx context.Context) error {
+	if s.auth == nil {
+		log.Printf("[bot] http api has no keys configured (BOT_HTTP_API_KEYS); control endpoints are open")
+	}
+
 	errCh := make(chan error, 1)
 	go func() {
-		log.Printf("[bot] http listening on %s", s.addr)
-		if err := s.http.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		var err error
+		if s.tlsCert != "" {
+			log.Printf("[bot] https listening on %s", s.addr)
+			err = s.http.ListenAndServeTLS(s.tlsCert, s.tlsKey)
+		} else {
+			log.Printf("[bot] http listening on %s", s.addr)
+			err = s.http.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
 			errCh <- err
 		}
 		close(errCh)
@@ -70,9 +113,16 @@ func (s *Server) Run(ctx context.Context) error {
 	}
 }
 
+// handleHealth keeps the original /health response: always 200 with the
+// service name. Once SetHealth is called, ok follows readiness; the checks
+// and the 503 live only on /health/live and /health/ready.
 func (s *Server) handleHealth(w http.ResponseWriter, _ *http.Request) {
+	ok := true
+	if s.health != nil {
+		ok = s.health.Ready(time.Now()).OK
+	}
 	writeJSON(w, http.StatusOK, map[string]any{
-		"ok":      true,
+		"ok":      ok,
 		"service": "rfid-go-bot",
 	})
 }
@@ -86,6 +136,10 @@ func (s *Server) handleIngest(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
 		return
 	}
+	if s.svc.Draining() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]any{"ok": false, "error": "shutting down"})
+		return
+	}
 
 	var payload epcPayload
 	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&payload); err != nil {
@@ -116,7 +170,7 @@ func (s *Server) handleWebhookDraft(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if s.webhookSecret != "" && strings.TrimSpace(r.Header.Get("X-Webhook-Secret")) != s.webhookSecret {
+	if !s.webhookAuthorized(r) {
 		writeJSON(w, http.StatusUnauthorized, map[string]any{"ok": false, "error": "invalid webhook secret"})
 		return
 	}
@@ -140,12 +194,43 @@ func (s *Server) handleWebhookDraft(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// webhookAuthorized accepts the shared ERP webhook secret or, when API keys
+// are configured, a key allowed to ingest.
+func (s *Server) webhookAuthorized(r *http.Request) bool {
+	if s.webhookSecret != "" {
+		if got := strings.TrimSpace(r.Header.Get("X-Webhook-Secret")); got != "" {
+			ok := secretEqual(got, s.webhookSecret)
+			if !ok {
+				auditLog("webhook", "", r, http.StatusUnauthorized, "invalid webhook secret")
+			}
+			return ok
+		}
+	}
+	if s.auth != nil {
+		key, err := s.auth.authenticate(r)
+		if err != nil || !roleAllows(key.Role, PermIngest) {
+			auditLog(key.Name, key.Role, r, http.StatusUnauthorized, "webhook requires secret or ingest key")
+			return false
+		}
+		auditLog(key.Name, key.Role, r, http.StatusOK, "")
+		return true
+	}
+	return s.webhookSecret == ""
+}
+
 func (s *Server) handleLegacyERPWebhook(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
 		return
 	}
 
+	// Every call refreshes the whole ERP cache, so it needs the same
+	// credentials as /webhook/draft.
+	if !s.webhookAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, map[string]any{"ok": false, "error": "invalid webhook secret"})
+		return
+	}
+
 	var payload struct {
 		Doctype string `json:"doctype"`
 		Name    string `json:"name"`
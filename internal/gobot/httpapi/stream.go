@@ -0,0 +1,119 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"new_era_go/internal/gobot/events"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// subscribe registers an event subscription filtered by ?type=tag,submit&reader=host:port.
+// Repeated parameters are merged; "types" is accepted as an alias of "type".
+func (s *Server) subscribe(r *http.Request) *events.Subscription {
+	q := r.URL.Query()
+	types := append(q["type"], q["types"]...)
+	filter := events.ParseFilter(types, q["reader"])
+	return s.svc.Events().Subscribe(filter, 512)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]any{"ok": false, "error": "method not allowed"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, map[string]any{"ok": false, "error": "streaming unsupported"})
+		return
+	}
+
+	sub := s.subscribe(r)
+	defer sub.Close()
+
+	h := w.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	_, _ = fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-s.closing:
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", ev.Seq, ev.Type, data); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sub := s.subscribe(r)
+	defer sub.Close()
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"ok": false, "error": err.Error()})
+		return
+	}
+	log.Printf("[bot] ws client connected: %s", r.RemoteAddr)
+	defer log.Printf("[bot] ws client disconnected: %s", r.RemoteAddr)
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ws.Closed():
+			return
+		case <-s.closing:
+			ws.Close(1001, "server shutdown")
+			return
+		case <-heartbeat.C:
+			if err := ws.Ping(); err != nil {
+				ws.shutdown()
+				return
+			}
+		case ev, ok := <-sub.C:
+			if !ok {
+				ws.Close(1011, "subscription closed")
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			if err := ws.WriteText(data); err != nil {
+				ws.shutdown()
+				return
+			}
+		}
+	}
+}
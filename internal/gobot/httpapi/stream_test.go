@@ -0,0 +1,144 @@
+package httpapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/cache"
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/service"
+)
+
+func newTestServer(t *testing.T) (*Server, *service.Service, *httptest.Server) {
+	t.Helper()
+	svc := service.New(config.Config{
+		RequestTimeout: time.Second,
+		QueueSize:      64,
+		RecentSeenTTL:  time.Minute,
+	}, nil, cache.New())
+	s := New(":0", "", svc, nil)
+	ts := httptest.NewServer(s.http.Handler)
+	t.Cleanup(ts.Close)
+	return s, svc, ts
+}
+
+func waitSubscribers(t *testing.T, bus *events.Bus) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for bus.Subscribers() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("subscriber did not register")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventsStreamsFilteredIngest(t *testing.T) {
+	_, svc, ts := newTestServer(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/events?type=ingest&reader=192.168.1.50:6000", nil)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("events request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("unexpected content type %q", ct)
+	}
+
+	waitSubscribers(t, svc.Events())
+	// Only the ingest of a tag read by the filtered reader comes through; the
+	// source moves into the payload.
+	svc.HandleEPC(ctx, "E2000001", "http")
+	svc.HandleReaderEPC(ctx, "E2000002", "sdk", "192.168.1.51:6000")
+	svc.HandleReaderEPC(ctx, "E2000003", "sdk", "192.168.1.50:6000")
+
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		var ev struct {
+			Type   string `json:"type"`
+			Reader string `json:"reader"`
+			Data   struct {
+				EPC    string `json:"epc"`
+				Source string `json:"source"`
+			} `json:"data"`
+		}
+		if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if ev.Type != events.TypeIngest || ev.Reader != "192.168.1.50:6000" || ev.Data.EPC != "E2000003" || ev.Data.Source != "sdk" {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+		return
+	}
+	t.Fatalf("stream ended without event: %v", sc.Err())
+}
+
+func TestWebSocketHandshakeAndPush(t *testing.T) {
+	_, svc, ts := newTestServer(t)
+
+	conn, err := net.Dial("tcp", strings.TrimPrefix(ts.URL, "http://"))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(2 * time.Second))
+
+	_, _ = io.WriteString(conn, "GET /ws?type=ingest HTTP/1.1\r\n"+
+		"Host: test\r\n"+
+		"Connection: Upgrade\r\n"+
+		"Upgrade: websocket\r\n"+
+		"Sec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("unexpected status %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("unexpected accept key %q", got)
+	}
+
+	waitSubscribers(t, svc.Events())
+	svc.HandleEPC(context.Background(), "E2000003", "ws-test")
+
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(br, head); err != nil {
+		t.Fatalf("read frame header: %v", err)
+	}
+	if head[0] != 0x81 {
+		t.Fatalf("expected final text frame, got 0x%02X", head[0])
+	}
+	n := int(head[1] & 0x7F)
+	if n == 126 {
+		ext := make([]byte, 2)
+		_, _ = io.ReadFull(br, ext)
+		n = int(ext[0])<<8 | int(ext[1])
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(br, payload); err != nil {
+		t.Fatalf("read frame payload: %v", err)
+	}
+	if !strings.Contains(string(payload), `"epc":"E2000003"`) {
+		t.Fatalf("unexpected payload %s", payload)
+	}
+}
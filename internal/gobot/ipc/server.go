@@ -2,16 +2,22 @@ package ipc
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/history"
 	"new_era_go/internal/gobot/service"
 )
 
@@ -20,10 +26,18 @@ type Scanner interface {
 	Stop()
 }
 
+// ReaderConfig reads and changes the reader's inventory tuning.
+type ReaderConfig interface {
+	Inventory() config.Inventory
+	UpdateInventory(ctx context.Context, u config.InventoryUpdate) (config.Inventory, error)
+}
+
 type Server struct {
-	socketPath string
-	svc        *service.Service
-	scanner    Scanner
+	socketPath   string
+	svc          *service.Service
+	scanner      Scanner
+	history      *history.Store
+	readerConfig ReaderConfig
 }
 
 func New(socketPath string, svc *service.Service, scanner Scanner) *Server {
@@ -34,6 +48,16 @@ func New(socketPath string, svc *service.Service, scanner Scanner) *Server {
 	}
 }
 
+// SetHistory enables the history request type.
+func (s *Server) SetHistory(h *history.Store) {
+	s.history = h
+}
+
+// SetReaderConfig enables the reader_config request type.
+func (s *Server) SetReaderConfig(rc ReaderConfig) {
+	s.readerConfig = rc
+}
+
 func (s *Server) Run(ctx context.Context) error {
 	if s.socketPath == "" || s.svc == nil {
 		return nil
@@ -81,7 +105,12 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 
 	sc := bufio.NewScanner(conn)
 	sc.Buffer(make([]byte, 0, 4096), 1<<20)
-	enc := json.NewEncoder(conn)
+	c := &session{enc: json.NewEncoder(conn), subs: make(map[string]*events.Subscription)}
+
+	// Closing subscriptions ends the pumps, so it must run before the wait.
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer c.closeSubs()
 
 	for sc.Scan() {
 		line := strings.TrimSpace(sc.Text())
@@ -91,13 +120,166 @@ func (s *Server) handleConn(ctx context.Context, conn net.Conn) {
 
 		var req request
 		if err := json.Unmarshal([]byte(line), &req); err != nil {
-			_ = enc.Encode(response{OK: false, Error: "invalid json"})
+			c.write(response{OK: false, Error: "invalid json"})
+			continue
+		}
+
+		switch strings.ToLower(strings.TrimSpace(req.Type)) {
+		case "subscribe":
+			c.write(s.subscribe(ctx, c, req, &wg))
 			continue
+		case "unsubscribe":
+			c.unsubscribe(req.ID)
+			c.write(response{OK: true, ID: req.ID, Action: "unsubscribe", Stats: s.svc.Status()})
+			continue
+		}
+
+		// Requests carrying an id come from multiplexed clients that match
+		// responses by id, so slow ones (scan_start refresh) must not block the rest.
+		if req.ID == "" {
+			c.write(s.handleRequest(ctx, req))
+			continue
+		}
+		wg.Add(1)
+		go func(req request) {
+			defer wg.Done()
+			resp := s.handleRequest(ctx, req)
+			resp.ID = req.ID
+			c.write(resp)
+		}(req)
+	}
+}
+
+// subscribe registers an event stream on the connection. Events are pushed as
+// {"action":"event","id":<subscribe id>,"event":{...}} lines until the
+// connection closes or an unsubscribe with the same id arrives.
+func (s *Server) subscribe(ctx context.Context, c *session, req request, wg *sync.WaitGroup) response {
+	filter := events.ParseFilter(req.Events, req.Readers)
+	sub := s.svc.Events().Subscribe(filter, 512)
+	c.addSub(req.ID, sub)
+
+	wantStats := len(filter.Types) == 0
+	if _, ok := filter.Types[events.TypeStats]; ok {
+		wantStats = true
+	}
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		s.pump(ctx, c, req.ID, sub, wantStats)
+	}()
+	return response{OK: true, ID: req.ID, Action: "subscribe", Stats: s.svc.Status()}
+}
+
+func (s *Server) pump(ctx context.Context, c *session, id string, sub *events.Subscription, wantStats bool) {
+	var tick <-chan time.Time
+	var last map[string]json.RawMessage
+	if wantStats {
+		t := time.NewTicker(statsInterval)
+		defer t.Stop()
+		tick = t.C
+		last = statsFields(s.svc.Status())
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if !c.write(eventFrame{ID: id, Action: "event", Event: ev}) {
+				return
+			}
+		case <-tick:
+			cur := statsFields(s.svc.Status())
+			delta := statsDelta(last, cur)
+			last = cur
+			if len(delta) == 0 {
+				continue
+			}
+			ev := events.Event{Type: events.TypeStats, Time: time.Now(), Data: delta}
+			if !c.write(eventFrame{ID: id, Action: "event", Event: ev}) {
+				return
+			}
 		}
+	}
+}
 
-		resp := s.handleRequest(ctx, req)
-		_ = enc.Encode(resp)
+const statsInterval = time.Second
+
+// statsFields flattens Stats into its JSON fields so deltas can be computed
+// without listing every field by hand.
+func statsFields(st service.Stats) map[string]json.RawMessage {
+	raw, err := json.Marshal(st)
+	if err != nil {
+		return nil
+	}
+	var out map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &out); err != nil {
+		return nil
 	}
+	return out
+}
+
+func statsDelta(prev, cur map[string]json.RawMessage) map[string]json.RawMessage {
+	delta := make(map[string]json.RawMessage)
+	for k, v := range cur {
+		if old, ok := prev[k]; ok && bytes.Equal(old, v) {
+			continue
+		}
+		delta[k] = v
+	}
+	return delta
+}
+
+// session serializes writes from request handlers and event pumps sharing one connection.
+type session struct {
+	mu     sync.Mutex
+	enc    *json.Encoder
+	broken bool
+
+	subMu sync.Mutex
+	subs  map[string]*events.Subscription
+}
+
+func (c *session) write(v any) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken {
+		return false
+	}
+	if err := c.enc.Encode(v); err != nil {
+		c.broken = true
+		return false
+	}
+	return true
+}
+
+func (c *session) addSub(id string, sub *events.Subscription) {
+	c.subMu.Lock()
+	if old := c.subs[id]; old != nil {
+		old.Close()
+	}
+	c.subs[id] = sub
+	c.subMu.Unlock()
+}
+
+// unsubscribe closes the subscription with the given id, or all of them when id is empty.
+func (c *session) unsubscribe(id string) {
+	c.subMu.Lock()
+	defer c.subMu.Unlock()
+	for key, sub := range c.subs {
+		if id == "" || key == id {
+			sub.Close()
+			delete(c.subs, key)
+		}
+	}
+}
+
+func (c *session) closeSubs() {
+	c.unsubscribe("")
 }
 
 func (s *Server) handleRequest(ctx context.Context, req request) response {
@@ -106,6 +288,9 @@ func (s *Server) handleRequest(ctx context.Context, req request) response {
 	if source == "" {
 		source = "ipc"
 	}
+	if (typ == "epc" || typ == "epcs") && s.svc.Draining() {
+		return response{OK: false, Action: typ, Error: "shutting down", Stats: s.svc.Status()}
+	}
 
 	switch typ {
 	case "status":
@@ -154,6 +339,39 @@ func (s *Server) handleRequest(ctx context.Context, req request) response {
 		}
 		return response{OK: true, Action: "epcs", Results: results, Stats: s.svc.Status()}
 
+	case "history":
+		if s.history == nil {
+			return response{OK: false, Action: "history", Error: "history disabled", Stats: s.svc.Status()}
+		}
+		var q history.Query
+		if req.Query != nil {
+			q = *req.Query
+		}
+		res, err := s.history.Find(q)
+		if err != nil {
+			return response{OK: false, Action: "history", Error: err.Error(), Stats: s.svc.Status()}
+		}
+		return response{OK: true, Action: "history", History: &res, Stats: s.svc.Status()}
+
+	case "reader_config":
+		if s.readerConfig == nil {
+			return response{OK: false, Action: "reader_config", Error: "sdk reader disabled", Stats: s.svc.Status()}
+		}
+		if req.Inventory == nil {
+			inv := s.readerConfig.Inventory()
+			return response{OK: true, Action: "reader_config", Reader: &inv, Stats: s.svc.Status()}
+		}
+		inv, err := s.readerConfig.UpdateInventory(ctx, *req.Inventory)
+		var verr *config.ValidationError
+		if errors.As(err, &verr) {
+			return response{OK: false, Action: "reader_config", Error: strings.Join(verr.Problems, "; "), Reader: &inv, Stats: s.svc.Status()}
+		}
+		warn := ""
+		if err != nil {
+			warn = "saved, reader apply failed: " + err.Error()
+		}
+		return response{OK: true, Action: "reader_config", Warning: warn, Reader: &inv, Stats: s.svc.Status()}
+
 	case "draft_epc":
 		added, replay := s.svc.AddDraftEPCs(ctx, []string{req.EPC})
 		return response{OK: true, Action: "draft_epc", Added: added, Replay: replay, Stats: s.svc.Status()}
@@ -171,13 +389,25 @@ func (s *Server) handleRequest(ctx context.Context, req request) response {
 }
 
 type request struct {
+	ID     string   `json:"id,omitempty"`
 	Type   string   `json:"type"`
 	Source string   `json:"source,omitempty"`
 	EPC    string   `json:"epc,omitempty"`
 	EPCs   []string `json:"epcs,omitempty"`
+
+	// Events and Readers filter a subscribe request; empty means everything.
+	Events  []string `json:"events,omitempty"`
+	Readers []string `json:"readers,omitempty"`
+
+	// Query filters a history request.
+	Query *history.Query `json:"query,omitempty"`
+
+	// Inventory is the change for a reader_config request; nil only reads.
+	Inventory *config.InventoryUpdate `json:"inventory,omitempty"`
 }
 
 type response struct {
+	ID      string                 `json:"id,omitempty"`
 	OK      bool                   `json:"ok"`
 	Action  string                 `json:"action,omitempty"`
 	Error   string                 `json:"error,omitempty"`
@@ -185,5 +415,13 @@ type response struct {
 	Replay  int                    `json:"replayed_seen,omitempty"`
 	Added   int                    `json:"added_to_cache,omitempty"`
 	Results []service.IngestResult `json:"results,omitempty"`
+	History *history.Result        `json:"history,omitempty"`
+	Reader  *config.Inventory      `json:"reader_config,omitempty"`
 	Stats   service.Stats          `json:"stats"`
 }
+
+type eventFrame struct {
+	ID     string       `json:"id,omitempty"`
+	Action string       `json:"action"`
+	Event  events.Event `json:"event"`
+}
@@ -0,0 +1,148 @@
+package ipc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/cache"
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/service"
+)
+
+type frame struct {
+	ID     string          `json:"id"`
+	OK     bool            `json:"ok"`
+	Action string          `json:"action"`
+	Error  string          `json:"error"`
+	Event  json.RawMessage `json:"event"`
+}
+
+func startServer(t *testing.T) (*service.Service, net.Conn, *bufio.Scanner) {
+	t.Helper()
+	svc := service.New(config.Config{
+		RequestTimeout: time.Second,
+		WorkerCount:    1,
+		QueueSize:      16,
+		RecentSeenTTL:  time.Minute,
+	}, nil, cache.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	sock := filepath.Join(t.TempDir(), "bot.sock")
+	srv := New(sock, svc, nil)
+	go func() { _ = srv.Run(ctx) }()
+
+	var conn net.Conn
+	var err error
+	for i := 0; i < 100; i++ {
+		if conn, err = net.Dial("unix", sock); err == nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err != nil {
+		t.Fatalf("dial ipc: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return svc, conn, bufio.NewScanner(conn)
+}
+
+func send(t *testing.T, conn net.Conn, req request) {
+	t.Helper()
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		t.Fatalf("write request: %v", err)
+	}
+}
+
+func next(t *testing.T, conn net.Conn, sc *bufio.Scanner) frame {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	if !sc.Scan() {
+		t.Fatalf("read frame: %v", sc.Err())
+	}
+	var f frame
+	if err := json.Unmarshal(sc.Bytes(), &f); err != nil {
+		t.Fatalf("decode frame %q: %v", sc.Text(), err)
+	}
+	return f
+}
+
+func TestSubscribePushesEventsAndMatchesIDs(t *testing.T) {
+	svc, conn, sc := startServer(t)
+
+	send(t, conn, request{ID: "s1", Type: "subscribe", Events: []string{"ingest,stats"}})
+	if f := next(t, conn, sc); !f.OK || f.ID != "s1" || f.Action != "subscribe" {
+		t.Fatalf("unexpected subscribe reply: %+v", f)
+	}
+	if svc.Events().Subscribers() != 1 {
+		t.Fatalf("expected one bus subscriber, got %d", svc.Events().Subscribers())
+	}
+
+	send(t, conn, request{ID: "r7", Type: "epc", Source: "test", EPC: "E2000011"})
+
+	var gotResp, gotIngest, gotStats bool
+	for i := 0; i < 6 && !(gotResp && gotIngest && gotStats); i++ {
+		f := next(t, conn, sc)
+		switch f.Action {
+		case "epc":
+			if f.ID != "r7" {
+				t.Fatalf("expected response id r7, got %q", f.ID)
+			}
+			gotResp = true
+		case "event":
+			if f.ID != "s1" {
+				t.Fatalf("expected event for subscription s1, got %q", f.ID)
+			}
+			var ev struct {
+				Type string                     `json:"type"`
+				Data map[string]json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal(f.Event, &ev); err != nil {
+				t.Fatalf("decode event: %v", err)
+			}
+			switch ev.Type {
+			case events.TypeIngest:
+				gotIngest = true
+			case events.TypeStats:
+				if string(ev.Data["scan_inactive"]) != "1" {
+					t.Fatalf("expected scan_inactive delta, got %v", ev.Data)
+				}
+				if _, ok := ev.Data["cache_size"]; ok {
+					t.Fatalf("unchanged field leaked into delta: %v", ev.Data)
+				}
+				gotStats = true
+			default:
+				t.Fatalf("event %q should have been filtered", ev.Type)
+			}
+		}
+	}
+	if !gotResp || !gotIngest || !gotStats {
+		t.Fatalf("missing frames: resp=%v ingest=%v stats=%v", gotResp, gotIngest, gotStats)
+	}
+
+	send(t, conn, request{ID: "s1", Type: "unsubscribe"})
+	for {
+		if f := next(t, conn, sc); f.Action == "unsubscribe" {
+			break
+		}
+	}
+	if n := svc.Events().Subscribers(); n != 0 {
+		t.Fatalf("expected subscription closed, got %d subscribers", n)
+	}
+}
+
+func TestRequestWithoutIDStaysSequential(t *testing.T) {
+	_, conn, sc := startServer(t)
+
+	send(t, conn, request{Type: "status"})
+	f := next(t, conn, sc)
+	if !f.OK || f.Action != "status" || f.ID != "" {
+		t.Fatalf("unexpected legacy status reply: %+v", f)
+	}
+}
@@ -0,0 +1,170 @@
+// Package metrics implements the small subset of the Prometheus text
+// exposition format the bot needs, without pulling in client_golang.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ContentType is the media type of the text exposition format.
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// LatencyBuckets are upper bounds (seconds) suited to ERP HTTP calls.
+var LatencyBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+
+// Label is one name="value" pair on a sample.
+type Label struct {
+	Name  string
+	Value string
+}
+
+func L(name, value string) Label {
+	return Label{Name: name, Value: value}
+}
+
+// Histogram is a fixed-bucket, concurrency-safe histogram.
+type Histogram struct {
+	mu     sync.Mutex
+	bounds []float64
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// HistogramSnapshot holds cumulative bucket counts aligned with Bounds.
+type HistogramSnapshot struct {
+	Bounds []float64
+	Counts []uint64
+	Sum    float64
+	Count  uint64
+}
+
+func NewHistogram(bounds []float64) *Histogram {
+	b := append([]float64{}, bounds...)
+	sort.Float64s(b)
+	return &Histogram{
+		bounds: b,
+		counts: make([]uint64, len(b)),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sum += v
+	h.count++
+	idx := sort.SearchFloat64s(h.bounds, v)
+	if idx < len(h.counts) {
+		h.counts[idx]++
+	}
+}
+
+func (h *Histogram) Snapshot() HistogramSnapshot {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	cumulative := make([]uint64, len(h.counts))
+	var running uint64
+	for i, c := range h.counts {
+		running += c
+		cumulative[i] = running
+	}
+	return HistogramSnapshot{
+		Bounds: append([]float64{}, h.bounds...),
+		Counts: cumulative,
+		Sum:    h.sum,
+		Count:  h.count,
+	}
+}
+
+// Writer emits metric families. HELP/TYPE lines are written once per name,
+// so all samples of a family must be written consecutively.
+type Writer struct {
+	w    io.Writer
+	seen map[string]struct{}
+	err  error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w, seen: make(map[string]struct{})}
+}
+
+// Err returns the first write error, if any.
+func (w *Writer) Err() error {
+	return w.err
+}
+
+func (w *Writer) Counter(name, help string, value float64, labels ...Label) {
+	w.header(name, help, "counter")
+	w.sample(name, value, labels)
+}
+
+func (w *Writer) Gauge(name, help string, value float64, labels ...Label) {
+	w.header(name, help, "gauge")
+	w.sample(name, value, labels)
+}
+
+func (w *Writer) Histogram(name, help string, snap HistogramSnapshot, labels ...Label) {
+	w.header(name, help, "histogram")
+	for i, bound := range snap.Bounds {
+		w.sample(name+"_bucket", float64(snap.Counts[i]), append(labels[:len(labels):len(labels)], L("le", formatFloat(bound))))
+	}
+	w.sample(name+"_bucket", float64(snap.Count), append(labels[:len(labels):len(labels)], L("le", "+Inf")))
+	w.sample(name+"_sum", snap.Sum, labels)
+	w.sample(name+"_count", float64(snap.Count), labels)
+}
+
+func (w *Writer) header(name, help, typ string) {
+	if _, ok := w.seen[name]; ok {
+		return
+	}
+	w.seen[name] = struct{}{}
+	w.printf("# HELP %s %s\n# TYPE %s %s\n", name, escapeHelp(help), name, typ)
+}
+
+func (w *Writer) sample(name string, value float64, labels []Label) {
+	if len(labels) == 0 {
+		w.printf("%s %s\n", name, formatFloat(value))
+		return
+	}
+	parts := make([]string, 0, len(labels))
+	for _, l := range labels {
+		parts = append(parts, l.Name+`="`+escapeLabel(l.Value)+`"`)
+	}
+	w.printf("%s{%s} %s\n", name, strings.Join(parts, ","), formatFloat(value))
+}
+
+func (w *Writer) printf(format string, args ...any) {
+	if w.err != nil {
+		return
+	}
+	_, w.err = fmt.Fprintf(w.w, format, args...)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+func escapeHelp(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
+
+func escapeLabel(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `"`, `\"`)
+	return strings.ReplaceAll(s, "\n", `\n`)
+}
@@ -0,0 +1,57 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestWriterCounterAndGauge(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Counter("rfid_submits_total", "Submits by outcome.", 3, L("outcome", "submitted"))
+	w.Counter("rfid_submits_total", "Submits by outcome.", 1, L("outcome", "error"))
+	w.Gauge("rfid_queue_depth", "Queue depth.", 7)
+
+	want := "# HELP rfid_submits_total Submits by outcome.\n" +
+		"# TYPE rfid_submits_total counter\n" +
+		"rfid_submits_total{outcome=\"submitted\"} 3\n" +
+		"rfid_submits_total{outcome=\"error\"} 1\n" +
+		"# HELP rfid_queue_depth Queue depth.\n" +
+		"# TYPE rfid_queue_depth gauge\n" +
+		"rfid_queue_depth 7\n"
+	if b.String() != want {
+		t.Fatalf("output mismatch:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestHistogramCumulativeBuckets(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	h.Observe(0.05)
+	h.Observe(0.5)
+	h.Observe(3)
+
+	var b strings.Builder
+	w := NewWriter(&b)
+	w.Histogram("erp_seconds", "ERP latency.", h.Snapshot(), L("op", "submit"))
+	out := b.String()
+
+	for _, line := range []string{
+		`erp_seconds_bucket{op="submit",le="0.1"} 1`,
+		`erp_seconds_bucket{op="submit",le="1"} 2`,
+		`erp_seconds_bucket{op="submit",le="+Inf"} 3`,
+		`erp_seconds_sum{op="submit"} 3.55`,
+		`erp_seconds_count{op="submit"} 3`,
+	} {
+		if !strings.Contains(out, line+"\n") {
+			t.Fatalf("missing %q in:\n%s", line, out)
+		}
+	}
+}
+
+func TestLabelEscaping(t *testing.T) {
+	var b strings.Builder
+	NewWriter(&b).Gauge("g", "h", 1, L("v", "a\"b\\c\nd"))
+	if !strings.Contains(b.String(), `g{v="a\"b\\c\nd"} 1`) {
+		t.Fatalf("unexpected escaping: %s", b.String())
+	}
+}
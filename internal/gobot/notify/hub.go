@@ -0,0 +1,365 @@
+// Package notify batches bot notifications per category and delivers them
+// through a rate-limited send queue to the chats subscribed to each category.
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	CategorySubmits = "submits"
+	CategoryErrors  = "errors"
+	CategoryDrafts  = "drafts"
+	CategoryReader  = "reader"
+	CategorySystem  = "system"
+)
+
+// Categories lists every category in display order.
+var Categories = []string{CategorySubmits, CategoryErrors, CategoryDrafts, CategoryReader, CategorySystem}
+
+// ValidCategory reports whether name is a known category.
+func ValidCategory(name string) bool {
+	for _, c := range Categories {
+		if c == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Sender delivers messages to chats. Recipients returns the chats subscribed to category.
+type Sender interface {
+	Recipients(category string) []int64
+	SendNotification(ctx context.Context, chatID int64, text string) error
+}
+
+// RetryAfterError is implemented by send errors that carry a server-requested pause (HTTP 429).
+// A zero pause marks an error that retrying will not fix.
+type RetryAfterError interface {
+	RetryAfter() time.Duration
+}
+
+type Config struct {
+	// Window is how long submit and draft notifications are collected before one summary is sent.
+	Window time.Duration
+	// UrgentWindow coalesces bursts of errors, reader and system notifications.
+	UrgentWindow time.Duration
+	// RatePerSec caps messages per second across all chats.
+	RatePerSec int
+	// ChatGap is the minimum gap between two messages to the same chat.
+	ChatGap time.Duration
+	// QueueSize bounds pending sends; new messages are dropped when it is full.
+	QueueSize int
+}
+
+const (
+	maxListed  = 10
+	maxSamples = 3
+	maxRetries = 3
+)
+
+type counter struct {
+	label  string
+	items  []string
+	total  int
+	groups map[string]struct{}
+}
+
+type batch struct {
+	opened   time.Time
+	texts    []string
+	counters []*counter
+}
+
+type outgoing struct {
+	chatID int64
+	text   string
+}
+
+// Hub collects notifications per category and flushes each category once its window has passed.
+type Hub struct {
+	cfg    Config
+	sender Sender
+	now    func() time.Time
+
+	mu      sync.Mutex
+	batches map[string]*batch
+	queue   chan outgoing
+	pending int // queued or being sent
+	dropped uint64
+}
+
+func NewHub(cfg Config, sender Sender) *Hub {
+	if cfg.Window <= 0 {
+		cfg.Window = 30 * time.Second
+	}
+	if cfg.UrgentWindow <= 0 {
+		cfg.UrgentWindow = 2 * time.Second
+	}
+	if cfg.RatePerSec < 1 {
+		cfg.RatePerSec = 20
+	}
+	if cfg.ChatGap <= 0 {
+		cfg.ChatGap = time.Second
+	}
+	if cfg.QueueSize < 16 {
+		cfg.QueueSize = 512
+	}
+	return &Hub{
+		cfg:     cfg,
+		sender:  sender,
+		now:     time.Now,
+		batches: make(map[string]*batch),
+		queue:   make(chan outgoing, cfg.QueueSize),
+	}
+}
+
+func (h *Hub) window(category string) time.Duration {
+	switch category {
+	case CategorySubmits, CategoryDrafts:
+		return h.cfg.Window
+	}
+	return h.cfg.UrgentWindow
+}
+
+// Notify implements service.Notifier; uncategorized text is treated as a system message.
+func (h *Hub) Notify(text string) {
+	h.NotifyCategory(CategorySystem, text)
+}
+
+// NotifyCategory adds a text notification to the category's current batch.
+func (h *Hub) NotifyCategory(category, text string) {
+	text = strings.TrimSpace(text)
+	if h == nil || text == "" {
+		return
+	}
+	h.mu.Lock()
+	b := h.batchLocked(category)
+	b.texts = append(b.texts, text)
+	h.mu.Unlock()
+}
+
+// CountCategory adds item under label; a flush reports the count with a few samples
+// instead of one line per item. A non-empty draft is counted once per distinct name.
+func (h *Hub) CountCategory(category, label, item, draft string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	b := h.batchLocked(category)
+	var c *counter
+	for _, existing := range b.counters {
+		if existing.label == label {
+			c = existing
+			break
+		}
+	}
+	if c == nil {
+		c = &counter{label: label, groups: make(map[string]struct{})}
+		b.counters = append(b.counters, c)
+	}
+	c.total++
+	if len(c.items) < maxSamples {
+		c.items = append(c.items, item)
+	}
+	if draft != "" {
+		c.groups[draft] = struct{}{}
+	}
+}
+
+// For returns a notify func bound to category, e.g. for reader.Manager.SetNotifier.
+func (h *Hub) For(category string) func(text string) {
+	return func(text string) { h.NotifyCategory(category, text) }
+}
+
+func (h *Hub) batchLocked(category string) *batch {
+	b, ok := h.batches[category]
+	if !ok {
+		b = &batch{opened: h.now()}
+		h.batches[category] = b
+	}
+	return b
+}
+
+// Run flushes due batches and drains the send queue until ctx ends.
+func (h *Hub) Run(ctx context.Context) {
+	go h.sendLoop(ctx)
+
+	t := time.NewTicker(500 * time.Millisecond)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			h.flush(false)
+		}
+	}
+}
+
+// flush moves due batches (or all batches when force is set) onto the send queue.
+func (h *Hub) flush(force bool) {
+	now := h.now()
+	h.mu.Lock()
+	due := make(map[string]*batch)
+	for category, b := range h.batches {
+		if force || now.Sub(b.opened) >= h.window(category) {
+			due[category] = b
+			delete(h.batches, category)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, category := range Categories {
+		b, ok := due[category]
+		if !ok {
+			continue
+		}
+		text := h.format(category, b)
+		if text == "" {
+			continue
+		}
+		for _, chatID := range h.sender.Recipients(category) {
+			h.mu.Lock()
+			h.pending++
+			h.mu.Unlock()
+			select {
+			case h.queue <- outgoing{chatID: chatID, text: text}:
+			default:
+				h.mu.Lock()
+				h.pending--
+				h.dropped++
+				h.mu.Unlock()
+				log.Printf("[bot] notify queue full; dropped %s message for chat=%d", category, chatID)
+			}
+		}
+	}
+}
+
+func (h *Hub) format(category string, b *batch) string {
+	var lines []string
+	for _, c := range b.counters {
+		if c.total == 1 {
+			line := c.label + ": " + c.items[0]
+			for draft := range c.groups {
+				line += " (" + draft + ")"
+			}
+			lines = append(lines, line)
+			continue
+		}
+		line := fmt.Sprintf("%s: %d ta EPC", c.label, c.total)
+		if len(c.groups) > 0 {
+			line += fmt.Sprintf(", %d ta draft", len(c.groups))
+		}
+		line += fmt.Sprintf(" (so'nggi %s)", formatWindow(h.window(category)))
+		if len(c.items) > 0 {
+			line += ". Namuna: " + strings.Join(c.items, ", ")
+		}
+		lines = append(lines, line)
+	}
+	for i, text := range b.texts {
+		if i >= maxListed {
+			lines = append(lines, fmt.Sprintf("... va yana %d ta xabar", len(b.texts)-maxListed))
+			break
+		}
+		lines = append(lines, text)
+	}
+	return strings.Join(lines, "\n")
+}
+
+func formatWindow(d time.Duration) string {
+	if d%time.Minute == 0 {
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%ds", int(d.Round(time.Second)/time.Second))
+}
+
+func (h *Hub) sendLoop(ctx context.Context) {
+	interval := time.Second / time.Duration(h.cfg.RatePerSec)
+	lastByChat := make(map[int64]time.Time)
+	var last time.Time
+
+	for {
+		var msg outgoing
+		select {
+		case <-ctx.Done():
+			return
+		case msg = <-h.queue:
+		}
+
+		wait := interval - time.Since(last)
+		if gap := h.cfg.ChatGap - time.Since(lastByChat[msg.chatID]); gap > wait {
+			wait = gap
+		}
+		if wait > 0 && !sleep(ctx, wait) {
+			return
+		}
+
+		for attempt := 0; ; attempt++ {
+			err := h.sender.SendNotification(ctx, msg.chatID, msg.text)
+			last = time.Now()
+			lastByChat[msg.chatID] = last
+			if err == nil {
+				break
+			}
+			var ra RetryAfterError
+			if !errors.As(err, &ra) || ra.RetryAfter() <= 0 || attempt >= maxRetries {
+				log.Printf("[bot] notify chat=%d failed: %v", msg.chatID, err)
+				break
+			}
+			log.Printf("[bot] notify chat=%d rate limited; retry in %s", msg.chatID, ra.RetryAfter())
+			if !sleep(ctx, ra.RetryAfter()) {
+				return
+			}
+		}
+		h.mu.Lock()
+		h.pending--
+		h.mu.Unlock()
+	}
+}
+
+// Flush sends every open batch now and waits until the send queue is empty
+// or ctx is done. Run must still be running.
+func (h *Hub) Flush(ctx context.Context) error {
+	h.flush(true)
+	t := time.NewTicker(50 * time.Millisecond)
+	defer t.Stop()
+	for {
+		h.mu.Lock()
+		idle := h.pending == 0
+		h.mu.Unlock()
+		if idle {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// Dropped reports how many messages were discarded because the send queue was full.
+func (h *Hub) Dropped() uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.dropped
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,187 @@
+package notify
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type rateLimited struct{ wait time.Duration }
+
+func (e rateLimited) Error() string             { return "too many requests" }
+func (e rateLimited) RetryAfter() time.Duration { return e.wait }
+
+type fakeSender struct {
+	mu         sync.Mutex
+	recipients map[string][]int64
+	sent       map[int64][]string
+	fail429    int
+	failHard   int
+	attempts   int
+}
+
+func (f *fakeSender) Recipients(category string) []int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.recipients[category]
+}
+
+func (f *fakeSender) SendNotification(_ context.Context, chatID int64, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.attempts++
+	if f.fail429 > 0 {
+		f.fail429--
+		return rateLimited{wait: 10 * time.Millisecond}
+	}
+	if f.failHard > 0 {
+		f.failHard--
+		return rateLimited{} // e.g. a 403 from a blocked bot: no pause, no retry
+	}
+	f.sent[chatID] = append(f.sent[chatID], text)
+	return nil
+}
+
+func (f *fakeSender) messages(chatID int64) []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.sent[chatID]...)
+}
+
+func newFakeSender() *fakeSender {
+	all := []int64{1, 2}
+	return &fakeSender{
+		recipients: map[string][]int64{
+			CategorySubmits: all,
+			CategoryErrors:  {1},
+			CategorySystem:  all,
+		},
+		sent: make(map[int64][]string),
+	}
+}
+
+func TestHubSummarizesSubmitBurst(t *testing.T) {
+	sender := newFakeSender()
+	h := NewHub(Config{Window: 30 * time.Second}, sender)
+	now := time.Unix(1_700_000_000, 0)
+	h.now = func() time.Time { return now }
+
+	for i, epc := range []string{"E1", "E2", "E3", "E4", "E5"} {
+		h.CountCategory(CategorySubmits, "Submit OK", epc, []string{"MAT-STE-1", "MAT-STE-2", "MAT-STE-1", "", "MAT-STE-3"}[i])
+	}
+	h.flush(false)
+	if len(h.queue) != 0 {
+		t.Fatalf("batch flushed before its window")
+	}
+
+	now = now.Add(31 * time.Second)
+	h.flush(false)
+	if len(h.queue) != 2 {
+		t.Fatalf("expected one summary per recipient, queued %d", len(h.queue))
+	}
+	msg := <-h.queue
+	if !strings.Contains(msg.text, "Submit OK: 5 ta EPC, 3 ta draft (so'nggi 30s)") || !strings.Contains(msg.text, "E1, E2, E3") {
+		t.Fatalf("unexpected summary: %q", msg.text)
+	}
+	if strings.Contains(msg.text, "E4") {
+		t.Fatalf("summary should list only samples: %q", msg.text)
+	}
+}
+
+func TestHubRoutesByCategoryAndDropsWhenFull(t *testing.T) {
+	sender := newFakeSender()
+	h := NewHub(Config{QueueSize: 16}, sender)
+
+	h.NotifyCategory(CategoryErrors, "Submit xato")
+	h.NotifyCategory(CategoryDrafts, "Draft yangilandi")
+	h.flush(true)
+	if len(h.queue) != 1 {
+		t.Fatalf("errors go to chat 1 only and drafts have no recipients, queued %d", len(h.queue))
+	}
+	if msg := <-h.queue; msg.chatID != 1 {
+		t.Fatalf("unexpected recipient %d", msg.chatID)
+	}
+
+	for i := 0; i < 10; i++ {
+		h.NotifyCategory(CategorySystem, "x")
+		h.flush(true)
+	}
+	if h.Dropped() != 4 {
+		t.Fatalf("expected 4 dropped messages, got %d", h.Dropped())
+	}
+}
+
+func TestHubRetriesAfterRateLimit(t *testing.T) {
+	sender := newFakeSender()
+	sender.fail429 = 2
+	h := NewHub(Config{ChatGap: time.Millisecond}, sender)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.sendLoop(ctx)
+
+	h.NotifyCategory(CategoryErrors, "Breaker ochildi")
+	h.flush(true)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sender.messages(1)) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("message not delivered after 429 retries")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	sender.mu.Lock()
+	attempts := sender.attempts
+	sender.mu.Unlock()
+	if attempts != 3 {
+		t.Fatalf("expected 3 attempts, got %d", attempts)
+	}
+}
+
+func TestHubDoesNotRetryPermanentErrors(t *testing.T) {
+	sender := newFakeSender()
+	sender.failHard = 1
+	h := NewHub(Config{ChatGap: time.Millisecond}, sender)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.sendLoop(ctx)
+
+	h.NotifyCategory(CategoryErrors, "Breaker ochildi")
+	h.flush(true)
+	flushCtx, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if err := h.Flush(flushCtx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	sender.mu.Lock()
+	attempts := sender.attempts
+	sender.mu.Unlock()
+	if attempts != 1 || len(sender.messages(1)) != 0 {
+		t.Fatalf("expected one attempt and no delivery, got %d attempts", attempts)
+	}
+}
+
+func TestHubFlushWaitsForOpenBatchesToBeSent(t *testing.T) {
+	sender := newFakeSender()
+	h := NewHub(Config{Window: time.Hour, ChatGap: time.Millisecond}, sender)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go h.sendLoop(ctx)
+
+	h.CountCategory(CategorySubmits, "Submit OK", "E1", "")
+	h.NotifyCategory(CategorySystem, "Bot to'xtatildi.")
+	flushCtx, stop := context.WithTimeout(context.Background(), 2*time.Second)
+	defer stop()
+	if err := h.Flush(flushCtx); err != nil {
+		t.Fatalf("flush: %v", err)
+	}
+	for _, chat := range []int64{1, 2} {
+		if got := sender.messages(chat); len(got) != 2 {
+			t.Fatalf("chat %d: expected submit and system messages after flush, got %q", chat, got)
+		}
+	}
+}
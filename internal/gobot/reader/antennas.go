@@ -0,0 +1,149 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"new_era_go/internal/gobot/events"
+	"new_era_go/sdk"
+)
+
+const (
+	antennaCheckInterval = 15 * time.Second
+	antennaDiagnoseDwell = 2 * time.Second
+)
+
+// AntennaEvent is published on the event bus when an antenna that used to
+// read goes quiet ("antenna_quiet") or reads again ("antenna_recovered").
+type AntennaEvent struct {
+	State    string    `json:"state"`
+	Port     int       `json:"port"`
+	LastRead time.Time `json:"last_read"`
+	Errors   uint64    `json:"errors"`
+}
+
+// antennaWatch remembers the last read per port across reconnects, so an
+// antenna that stopped reading before a reconnect is still reported.
+type antennaWatch struct {
+	quiet    time.Duration
+	lastRead map[int]time.Time
+	alerted  map[int]bool
+}
+
+func newAntennaWatch(quiet time.Duration) *antennaWatch {
+	return &antennaWatch{quiet: quiet, lastRead: make(map[int]time.Time), alerted: make(map[int]bool)}
+}
+
+// check returns the ports that just went quiet and those that recovered.
+// Only ports listed in active are considered.
+func (w *antennaWatch) check(now time.Time, health []sdk.AntennaHealth, active []int) (quiet, recovered []AntennaEvent) {
+	for _, h := range health {
+		if h.LastRead.After(w.lastRead[h.Port]) {
+			w.lastRead[h.Port] = h.LastRead
+		}
+	}
+	for _, port := range active {
+		last := w.lastRead[port]
+		if last.IsZero() {
+			continue
+		}
+		var errs uint64
+		for _, h := range health {
+			if h.Port == port {
+				errs = h.Errors
+			}
+		}
+		silent := now.Sub(last) >= w.quiet
+		switch {
+		case silent && !w.alerted[port]:
+			w.alerted[port] = true
+			quiet = append(quiet, AntennaEvent{State: "antenna_quiet", Port: port, LastRead: last, Errors: errs})
+		case !silent && w.alerted[port]:
+			delete(w.alerted, port)
+			recovered = append(recovered, AntennaEvent{State: "antenna_recovered", Port: port, LastRead: last, Errors: errs})
+		}
+	}
+	for port := range w.alerted {
+		if !slices.Contains(active, port) {
+			delete(w.alerted, port)
+		}
+	}
+	return quiet, recovered
+}
+
+// AntennaHealth returns per-antenna counters of the current connection, or nil
+// while the reader is not connected.
+func (m *Manager) AntennaHealth() []sdk.AntennaHealth {
+	m.mu.Lock()
+	client := m.client
+	m.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+	return client.AntennaHealth()
+}
+
+// DiagnoseAntennas cycles the connected reader through each configured antenna.
+func (m *Manager) DiagnoseAntennas(ctx context.Context) ([]sdk.AntennaDiagnosis, error) {
+	var out []sdk.AntennaDiagnosis
+	err := m.useClient(func(client *sdk.Client) error {
+		var err error
+		out, err = client.DiagnoseAntennas(ctx, antennaDiagnoseDwell)
+		return err
+	})
+	return out, err
+}
+
+// watchAntennas alerts when an antenna that used to read stays quiet for
+// cfg.AntennaQuiet. It runs for the lifetime of one connection.
+func (m *Manager) watchAntennas(ctx context.Context, client *sdk.Client, endpoint string) {
+	interval := antennaCheckInterval
+	if m.cfg.AntennaQuiet < 4*interval {
+		interval = max(m.cfg.AntennaQuiet/4, time.Second)
+	}
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			m.mu.Lock()
+			watch := m.antennas
+			m.mu.Unlock()
+			quiet, recovered := watch.check(now, client.AntennaHealth(), m.Inventory().Antennas)
+			for _, ev := range quiet {
+				text := fmt.Sprintf("Antenna %d jim: oxirgi o'qish %s oldin (%s).", ev.Port, now.Sub(ev.LastRead).Round(time.Minute), ev.LastRead.Format("2006-01-02 15:04"))
+				if ev.Errors > 0 {
+					text += " Antenna xatolari bor, kabel uzilgan bo'lishi mumkin."
+				}
+				log.Printf("[reader] antenna %d quiet since %s errors=%d", ev.Port, ev.LastRead.Format(time.RFC3339), ev.Errors)
+				m.publish(events.TypeReader, endpoint, ev)
+				m.notify(text)
+			}
+			for _, ev := range recovered {
+				log.Printf("[reader] antenna %d reading again", ev.Port)
+				m.publish(events.TypeReader, endpoint, ev)
+				m.notify(fmt.Sprintf("Antenna %d yana o'qiyapti.", ev.Port))
+			}
+		}
+	}
+}
+
+// antennaText renders per-antenna health for StatusText.
+func antennaText(health []sdk.AntennaHealth) string {
+	if len(health) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	for _, h := range health {
+		fmt.Fprintf(&sb, "\nant%d: reads/min=%d last=%s errors=%d (%.0f%%)",
+			h.Port, h.ReadsPerMin, formatTime(h.LastRead), h.Errors, h.ErrorRate()*100)
+	}
+	return sb.String()
+}
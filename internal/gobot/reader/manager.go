@@ -2,49 +2,230 @@ package reader
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"new_era_go/internal/discovery"
 	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/events"
 	"new_era_go/sdk"
 )
 
-type EPCHandler func(epc string)
+// EPCHandler receives each new EPC with the endpoint of the reader that read it.
+type EPCHandler func(epc, endpoint string)
 type Notifier func(text string)
 
+// TagEvent is published on the event bus for every tag read (including repeats).
+type TagEvent struct {
+	EPC     string `json:"epc"`
+	Antenna int    `json:"antenna"`
+	RSSI    int    `json:"rssi"`
+	New     bool   `json:"new"`
+}
+
+// StateEvent is published on the event bus when the reader loop changes state.
+type StateEvent struct {
+	State    string `json:"state"`
+	Endpoint string `json:"endpoint,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
 type Status struct {
 	Running      bool
 	Connected    bool
 	Endpoint     string
 	LastError    string
 	UniqueSeen   uint64
+	TotalReads   uint64
+	ReadRate     float64
+	AntennaReads map[int]uint64
 	LastTagAt    time.Time
 	LastTagEPC   string
 	LastStartAt  time.Time
 	RestartCount uint64
+	LastFrameAt  time.Time
+	Watchdog     uint64 // reconnects forced by the watchdog
 }
 
 type Manager struct {
 	cfg      config.Config
 	onEPC    EPCHandler
 	notifyFn Notifier
-
-	mu      sync.Mutex
-	running bool
-	cancel  context.CancelFunc
-	done    chan struct{}
-	status  Status
+	bus      *events.Bus
+
+	// connMu is held for writing while a connection is torn down and for
+	// reading by useClient, so a command never goes to a closed client.
+	connMu sync.RWMutex
+
+	mu        sync.Mutex
+	target    sdk.Endpoint
+	inventory config.Inventory
+	stateFile string
+	client    *sdk.Client
+	antennas  *antennaWatch
+	running   bool
+	cancel    context.CancelFunc
+	done      chan struct{}
+	status    Status
+	rate      rateWindow
 }
 
 func New(cfg config.Config, onEPC EPCHandler, notify Notifier) *Manager {
 	return &Manager{
-		cfg:      cfg,
-		onEPC:    onEPC,
-		notifyFn: notify,
+		cfg:       cfg,
+		onEPC:     onEPC,
+		notifyFn:  notify,
+		inventory: cfg.ReaderInventory,
+		antennas:  newAntennaWatch(cfg.AntennaQuiet),
+	}
+}
+
+// Inventory returns the inventory tuning used for the next (or current) connection.
+func (m *Manager) Inventory() config.Inventory {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.inventory
+}
+
+// SetInventory changes and persists the inventory tuning. A connected reader
+// gets the new power, antennas and scan time immediately; Q and session apply
+// from the next round.
+func (m *Manager) SetInventory(ctx context.Context, inv config.Inventory) error {
+	m.mu.Lock()
+	m.inventory = inv
+	stateFile := m.stateFile
+	m.mu.Unlock()
+
+	if stateFile != "" {
+		if err := saveInventory(stateFile, inv); err != nil {
+			log.Printf("[reader] inventory state save failed: %v", err)
+		}
 	}
+	err := m.useClient(func(client *sdk.Client) error {
+		client.SetInventoryConfig(applyInventory(client.InventoryConfig(), inv))
+		applyCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		defer cancel()
+		return client.ApplyInventoryConfig(applyCtx)
+	})
+	if errors.Is(err, errNotConnected) {
+		// The next connection picks up m.inventory.
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("apply inventory config: %w", err)
+	}
+	log.Printf("[reader] inventory updated: q=%d session=%d power=%d scan_time=%d antennas=%v",
+		inv.Q, inv.Session, inv.Power, inv.ScanTime, inv.Antennas)
+	return nil
+}
+
+var errNotConnected = errors.New("reader not connected")
+
+// useClient runs fn with the connected client. The connection is not torn
+// down until fn returns; errNotConnected means there was no client.
+func (m *Manager) useClient(fn func(*sdk.Client) error) error {
+	m.connMu.RLock()
+	defer m.connMu.RUnlock()
+	m.mu.Lock()
+	client := m.client
+	m.mu.Unlock()
+	if client == nil {
+		return errNotConnected
+	}
+	return fn(client)
+}
+
+// UpdateInventory validates u against the current tuning and applies the
+// result like SetInventory. A *config.ValidationError means nothing changed;
+// any other error means the new values were kept but the reader rejected them.
+func (m *Manager) UpdateInventory(ctx context.Context, u config.InventoryUpdate) (config.Inventory, error) {
+	inv, err := m.Inventory().Apply(u)
+	if err != nil {
+		return m.Inventory(), err
+	}
+	return inv, m.SetInventory(ctx, inv)
+}
+
+// SetStateFile makes inventory changes persistent. Tuning saved in path by an
+// earlier run replaces the configured values.
+func (m *Manager) SetStateFile(path string) {
+	path = strings.TrimSpace(path)
+	m.mu.Lock()
+	m.stateFile = path
+	base := m.inventory
+	m.mu.Unlock()
+	if path == "" {
+		return
+	}
+
+	inv, err := loadInventory(path, base)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.Printf("[reader] inventory state ignored: %v", err)
+		}
+		return
+	}
+	m.mu.Lock()
+	m.inventory = inv
+	m.mu.Unlock()
+	log.Printf("[reader] inventory restored from %s: q=%d session=%d power=%d scan_time=%d antennas=%v",
+		path, inv.Q, inv.Session, inv.Power, inv.ScanTime, inv.Antennas)
+}
+
+func loadInventory(path string, base config.Inventory) (config.Inventory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return base, err
+	}
+	var u config.InventoryUpdate
+	if err := json.Unmarshal(data, &u); err != nil {
+		return base, fmt.Errorf("decode %s: %w", path, err)
+	}
+	return base.Apply(u)
+}
+
+func saveInventory(path string, inv config.Inventory) error {
+	if dir := filepath.Dir(path); dir != "" && dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	data, err := json.MarshalIndent(inv, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func applyInventory(base sdk.InventoryConfig, inv config.Inventory) sdk.InventoryConfig {
+	if inv.ScanTime == 0 {
+		// A zero Inventory (config not loaded through config.Load) keeps the SDK defaults.
+		return base
+	}
+	base.QValue = byte(inv.Q)
+	base.Session = byte(inv.Session)
+	base.OutputPower = byte(inv.Power)
+	base.ScanTime = byte(inv.ScanTime)
+	if mask := inv.AntennaMask(); mask != 0 {
+		base.AntennaMask = mask
+	}
+	if inv.Poll > 0 {
+		base.PollInterval = inv.Poll
+	}
+	return base
 }
 
 func (m *Manager) SetNotifier(notify Notifier) {
@@ -53,6 +234,13 @@ func (m *Manager) SetNotifier(notify Notifier) {
 	m.mu.Unlock()
 }
 
+// SetEvents sets the bus that receives tag and reader state events.
+func (m *Manager) SetEvents(bus *events.Bus) {
+	m.mu.Lock()
+	m.bus = bus
+	m.mu.Unlock()
+}
+
 func (m *Manager) Start(parent context.Context) error {
 	m.mu.Lock()
 	if m.running {
@@ -69,6 +257,7 @@ func (m *Manager) Start(parent context.Context) error {
 	done := m.done
 	m.mu.Unlock()
 
+	m.publishState("starting", "", "")
 	go m.scanLoop(ctx, done)
 	return nil
 }
@@ -88,16 +277,113 @@ func (m *Manager) Stop() {
 	}
 }
 
+// KnownReaders returns the endpoints in the known-reader registry, pinned
+// first, without probing them.
+func (m *Manager) KnownReaders() []string {
+	if m.cfg.ReaderKnownFile == "" {
+		return nil
+	}
+	reg, err := discovery.OpenRegistry(m.cfg.ReaderKnownFile)
+	if err != nil {
+		log.Printf("[bot] known readers: %v", err)
+		return nil
+	}
+	var endpoints []string
+	for _, k := range reg.List() {
+		endpoints = append(endpoints, net.JoinHostPort(k.Host, strconv.Itoa(k.Port)))
+	}
+	return endpoints
+}
+
+// DiscoverReaders scans the LAN and returns reader endpoints, verified ones first.
+func (m *Manager) DiscoverReaders(ctx context.Context) ([]string, error) {
+	timeout := m.cfg.ReaderConnectTimeout
+	if timeout <= 0 {
+		timeout = 25 * time.Second
+	}
+	scanCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	client := sdk.NewClient()
+	defer client.Close()
+	candidates, err := client.Discover(scanCtx, m.scanOptions(true))
+	if err != nil && len(candidates) == 0 {
+		return nil, err
+	}
+
+	var verified, rest []string
+	for _, candidate := range candidates {
+		addr := net.JoinHostPort(candidate.Host, strconv.Itoa(candidate.Port))
+		if candidate.Verified {
+			verified = append(verified, addr)
+		} else {
+			rest = append(rest, addr)
+		}
+	}
+	return append(verified, rest...), nil
+}
+
+// scanOptions checks the known readers in cfg.ReaderKnownFile before sweeping
+// the configured targets; sweep forces the full scan, e.g. when listing every
+// reader.
+func (m *Manager) scanOptions(sweep bool) sdk.ScanOptions {
+	opts := sdk.DefaultScanOptions()
+	opts.KnownFile = m.cfg.ReaderKnownFile
+	opts.ForceSweep = sweep
+	opts.Targets = m.cfg.ReaderScanTargets
+	opts.Exclude = m.cfg.ReaderScanExclude
+	opts.Interfaces = m.cfg.ReaderScanInterfaces
+	return opts
+}
+
+// SelectReader pins the reader endpoint ("host:port"); an empty endpoint
+// returns to BOT_READER_HOST/discovery. A running scan reconnects to it.
+func (m *Manager) SelectReader(ctx context.Context, endpoint string) error {
+	var target sdk.Endpoint
+	if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+		host, portText, err := net.SplitHostPort(endpoint)
+		if err != nil {
+			return fmt.Errorf("reader endpoint %q: %w", endpoint, err)
+		}
+		port, err := strconv.Atoi(portText)
+		if err != nil || port <= 0 || port > 65535 || host == "" {
+			return fmt.Errorf("reader endpoint %q: invalid host or port", endpoint)
+		}
+		target = sdk.Endpoint{Host: host, Port: port}
+	}
+
+	m.mu.Lock()
+	m.target = target
+	running := m.running
+	m.mu.Unlock()
+
+	log.Printf("[reader] target set to %s", fallback(endpoint, "auto"))
+	if !running {
+		return nil
+	}
+	m.Stop()
+	return m.Start(ctx)
+}
+
 func (m *Manager) Status() Status {
 	m.mu.Lock()
 	defer m.mu.Unlock()
-	return m.status
+	st := m.status
+	if m.client != nil {
+		st.LastFrameAt = m.client.Stats().LastFrameAt
+	}
+	st.ReadRate = m.rate.perSecond(time.Now())
+	st.AntennaReads = make(map[int]uint64, len(m.status.AntennaReads))
+	for ant, n := range m.status.AntennaReads {
+		st.AntennaReads[ant] = n
+	}
+	return st
 }
 
 func (m *Manager) StatusText() string {
 	st := m.Status()
-	return fmt.Sprintf(
-		"running=%v connected=%v endpoint=%s\nseen=%d last_tag=%s at=%s\nrestarts=%d last_error=%s",
+	text := fmt.Sprintf(
+		"running=%v connected=%v endpoint=%s\nseen=%d last_tag=%s at=%s\nrestarts=%d watchdog=%d last_error=%s",
 		st.Running,
 		st.Connected,
 		fallback(st.Endpoint, "-"),
@@ -105,8 +391,10 @@ func (m *Manager) StatusText() string {
 		fallback(trimEPC(st.LastTagEPC), "-"),
 		formatTime(st.LastTagAt),
 		st.RestartCount,
+		st.Watchdog,
 		fallback(st.LastError, "-"),
 	)
+	return text + antennaText(m.AntennaHealth())
 }
 
 func (m *Manager) scanLoop(ctx context.Context, done chan struct{}) {
@@ -136,18 +424,48 @@ func (m *Manager) scanLoop(ctx context.Context, done chan struct{}) {
 			continue
 		}
 
+		endpoint := m.Status().Endpoint
 		if connected {
-			m.notify("RFID scan boshlandi: " + m.Status().Endpoint)
+			m.publishState("connected", endpoint, "")
+			m.notify("RFID scan boshlandi: " + endpoint)
 		}
 
-		shouldReconnect := m.consumeTags(ctx, client)
+		// connCtx ends this connection; the watchdog cancels it to force a reconnect.
+		connCtx, endConn := context.WithCancel(ctx)
+		var watchers sync.WaitGroup
+		if m.cfg.AntennaQuiet > 0 {
+			watchers.Add(1)
+			go func() {
+				defer watchers.Done()
+				m.watchAntennas(connCtx, client, endpoint)
+			}()
+		}
+		if m.cfg.ReaderWatchdogRounds > 0 {
+			watchers.Add(1)
+			go func() {
+				defer watchers.Done()
+				m.watchdog(connCtx, client, endConn)
+			}()
+		}
+
+		shouldReconnect := m.consumeTags(connCtx, client)
+		if connCtx.Err() != nil && ctx.Err() == nil {
+			shouldReconnect = true
+		}
+		endConn()
+		watchers.Wait()
+
+		m.connMu.Lock()
 		_ = client.StopInventory()
 		_ = client.Close()
-
 		m.mu.Lock()
+		m.client = nil
 		m.status.Connected = false
 		m.status.Endpoint = ""
+		lastErr := m.status.LastError
 		m.mu.Unlock()
+		m.connMu.Unlock()
+		m.publishState("disconnected", endpoint, lastErr)
 
 		if !shouldReconnect {
 			return
@@ -168,19 +486,25 @@ func (m *Manager) connectAndStart(ctx context.Context, client *sdk.Client) (bool
 		timeout = 25 * time.Second
 	}
 
+	m.mu.Lock()
+	target := m.target
+	m.mu.Unlock()
+	if target.Host == "" && m.cfg.ReaderHost != "" && m.cfg.ReaderPort > 0 {
+		target = sdk.Endpoint{Host: m.cfg.ReaderHost, Port: m.cfg.ReaderPort}
+	}
+
 	var endpoint string
-	if m.cfg.ReaderHost != "" && m.cfg.ReaderPort > 0 {
+	if target.Host != "" {
 		dialCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
-		target := sdk.Endpoint{Host: m.cfg.ReaderHost, Port: m.cfg.ReaderPort}
 		if err := client.Reconnect(dialCtx, target, timeout); err != nil {
-			return false, fmt.Errorf("direct connect %s:%d: %w", m.cfg.ReaderHost, m.cfg.ReaderPort, err)
+			return false, fmt.Errorf("direct connect %s: %w", target.Address(), err)
 		}
 		endpoint = target.Address()
 	} else {
 		scanCtx, cancel := context.WithTimeout(ctx, timeout)
 		defer cancel()
-		candidates, err := client.Discover(scanCtx, sdk.DefaultScanOptions())
+		candidates, err := client.Discover(scanCtx, m.scanOptions(false))
 		if err != nil && len(candidates) == 0 {
 			return false, fmt.Errorf("discover: %w", err)
 		}
@@ -209,14 +533,14 @@ func (m *Manager) connectAndStart(ctx context.Context, client *sdk.Client) (bool
 		endpoint = target.Address()
 	}
 
-	cfg := client.InventoryConfig()
-	client.SetInventoryConfig(cfg)
+	client.SetInventoryConfig(applyInventory(client.InventoryConfig(), m.Inventory()))
 
 	if err := client.StartInventory(ctx); err != nil {
 		return false, fmt.Errorf("start inventory: %w", err)
 	}
 
 	m.mu.Lock()
+	m.client = client
 	m.status.Connected = true
 	m.status.Endpoint = endpoint
 	m.status.LastError = ""
@@ -227,6 +551,7 @@ func (m *Manager) connectAndStart(ctx context.Context, client *sdk.Client) (bool
 func (m *Manager) consumeTags(ctx context.Context, client *sdk.Client) bool {
 	tags := client.Tags()
 	errs := client.Errors()
+	endpoint := m.Status().Endpoint
 
 	for {
 		select {
@@ -237,6 +562,13 @@ func (m *Manager) consumeTags(ctx context.Context, client *sdk.Client) bool {
 				m.setError(fmt.Errorf("tag channel closed"))
 				return true
 			}
+			m.recordRead(tag.Antenna)
+			m.publish(events.TypeTag, endpoint, TagEvent{
+				EPC:     tag.EPC,
+				Antenna: tag.Antenna,
+				RSSI:    tag.RSSI,
+				New:     tag.IsNew,
+			})
 			if !tag.IsNew {
 				continue
 			}
@@ -252,7 +584,7 @@ func (m *Manager) consumeTags(ctx context.Context, client *sdk.Client) bool {
 			m.mu.Unlock()
 
 			if m.onEPC != nil {
-				m.onEPC(epc)
+				m.onEPC(epc, endpoint)
 			}
 		case err, ok := <-errs:
 			if !ok {
@@ -267,13 +599,37 @@ func (m *Manager) consumeTags(ctx context.Context, client *sdk.Client) bool {
 	}
 }
 
+func (m *Manager) recordRead(antenna int) {
+	m.mu.Lock()
+	m.status.TotalReads++
+	if m.status.AntennaReads == nil {
+		m.status.AntennaReads = make(map[int]uint64)
+	}
+	m.status.AntennaReads[antenna]++
+	m.rate.add(time.Now())
+	m.mu.Unlock()
+}
+
 func (m *Manager) setError(err error) {
 	if err == nil {
 		return
 	}
 	m.mu.Lock()
 	m.status.LastError = err.Error()
+	endpoint := m.status.Endpoint
 	m.mu.Unlock()
+	m.publishState("error", endpoint, err.Error())
+}
+
+func (m *Manager) publishState(state, endpoint, errText string) {
+	m.publish(events.TypeReader, endpoint, StateEvent{State: state, Endpoint: endpoint, Error: errText})
+}
+
+func (m *Manager) publish(typ, reader string, data any) {
+	m.mu.Lock()
+	bus := m.bus
+	m.mu.Unlock()
+	bus.Publish(typ, reader, data)
 }
 
 func (m *Manager) finishStopped() {
@@ -284,6 +640,7 @@ func (m *Manager) finishStopped() {
 	m.status.Running = false
 	m.status.Connected = false
 	m.mu.Unlock()
+	m.publishState("stopped", "", "")
 }
 
 func (m *Manager) notify(text string) {
@@ -294,6 +651,35 @@ func (m *Manager) notify(text string) {
 	m.notifyFn(text)
 }
 
+const rateWindowSec = 10
+
+// rateWindow counts reads in per-second buckets over the last rateWindowSec seconds.
+type rateWindow struct {
+	counts [rateWindowSec]uint64
+	stamps [rateWindowSec]int64
+}
+
+func (r *rateWindow) add(now time.Time) {
+	sec := now.Unix()
+	i := sec % rateWindowSec
+	if r.stamps[i] != sec {
+		r.stamps[i] = sec
+		r.counts[i] = 0
+	}
+	r.counts[i]++
+}
+
+func (r *rateWindow) perSecond(now time.Time) float64 {
+	sec := now.Unix()
+	var total uint64
+	for i := range r.counts {
+		if sec-r.stamps[i] < rateWindowSec {
+			total += r.counts[i]
+		}
+	}
+	return float64(total) / rateWindowSec
+}
+
 func sleepWithContext(ctx context.Context, d time.Duration) bool {
 	if d <= 0 {
 		return true
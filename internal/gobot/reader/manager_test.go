@@ -0,0 +1,66 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/sdk"
+)
+
+func TestInventoryUpdatePersistsAcrossRestart(t *testing.T) {
+	cfg := config.Config{ReaderInventory: config.Inventory{Q: 4, Session: 1, Power: 30, ScanTime: 1, Antennas: []int{1}, Poll: 40 * time.Millisecond}}
+	path := filepath.Join(t.TempDir(), "reader_config.json")
+
+	m := New(cfg, nil, nil)
+	m.SetStateFile(path)
+	power := 22
+	if _, err := m.UpdateInventory(context.Background(), config.InventoryUpdate{Power: &power, Antennas: []int{1, 3}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	bad := 99
+	_, err := m.UpdateInventory(context.Background(), config.InventoryUpdate{Power: &bad})
+	var verr *config.ValidationError
+	if !errors.As(err, &verr) || m.Inventory().Power != 22 {
+		t.Fatalf("invalid power must be rejected without changes, err=%v inv=%+v", err, m.Inventory())
+	}
+
+	restarted := New(cfg, nil, nil)
+	restarted.SetStateFile(path)
+	inv := restarted.Inventory()
+	if inv.Power != 22 || !slices.Equal(inv.Antennas, []int{1, 3}) || inv.Q != 4 || inv.Poll != 40*time.Millisecond {
+		t.Fatalf("inventory not restored: %+v", inv)
+	}
+}
+
+func TestAntennaWatchAlertsOnceAndRecovers(t *testing.T) {
+	w := newAntennaWatch(10 * time.Minute)
+	start := time.Now()
+	health := []sdk.AntennaHealth{
+		{Port: 1, Reads: 5, LastRead: start},
+		{Port: 2, Reads: 0},
+	}
+	if quiet, _ := w.check(start.Add(time.Minute), health, []int{1, 2}); len(quiet) != 0 {
+		t.Fatalf("no alert expected yet: %+v", quiet)
+	}
+
+	// Reconnect: the SDK counters reset, but the watch remembers port 1 read before.
+	quiet, _ := w.check(start.Add(11*time.Minute), []sdk.AntennaHealth{{Port: 1, Errors: 3}}, []int{1, 2})
+	if len(quiet) != 1 || quiet[0].Port != 1 || quiet[0].Errors != 3 {
+		t.Fatalf("expected one quiet alert for port 1, got %+v", quiet)
+	}
+	if quiet, _ := w.check(start.Add(12*time.Minute), nil, []int{1, 2}); len(quiet) != 0 {
+		t.Fatalf("alert must not repeat: %+v", quiet)
+	}
+
+	now := start.Add(13 * time.Minute)
+	_, recovered := w.check(now, []sdk.AntennaHealth{{Port: 1, Reads: 1, LastRead: now}}, []int{1, 2})
+	if len(recovered) != 1 || recovered[0].Port != 1 {
+		t.Fatalf("expected port 1 to recover, got %+v", recovered)
+	}
+}
@@ -0,0 +1,77 @@
+package reader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"new_era_go/internal/gobot/health"
+	"new_era_go/sdk"
+)
+
+const watchdogInterval = time.Second
+
+// watchdog ends the connection when the reader has not answered a single
+// frame for cfg.ReaderWatchdogRounds inventory rounds; scanLoop then reconnects.
+func (m *Manager) watchdog(ctx context.Context, client *sdk.Client, endConn context.CancelFunc) {
+	limit := m.cfg.ReaderWatchdogRounds
+	t := time.NewTicker(watchdogInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			st := client.Stats()
+			if !st.Running || st.RoundsSinceFrame < limit {
+				continue
+			}
+			err := fmt.Errorf("watchdog: no frame in %d inventory rounds (last frame %s)", st.RoundsSinceFrame, formatTime(st.LastFrameAt))
+			log.Printf("[reader] %v, reconnecting", err)
+			m.mu.Lock()
+			m.status.Watchdog++
+			m.mu.Unlock()
+			m.setError(err)
+			m.notify("Reader javob bermayapti, qayta ulanmoqda.")
+			endConn()
+			return
+		}
+	}
+}
+
+// CheckReader reports the reader as failed while scanning is on but the
+// reader is disconnected or has stopped answering. A stopped scan is ok.
+func (m *Manager) CheckReader(now time.Time) health.Check {
+	c := health.Check{Name: "reader", State: health.StateOK}
+	st := m.Status()
+
+	m.mu.Lock()
+	client := m.client
+	m.mu.Unlock()
+	var sdkStats sdk.Stats
+	if client != nil {
+		sdkStats = client.Stats()
+	}
+
+	switch {
+	case !st.Running:
+		c.Detail = "scan stopped"
+	case !st.Connected:
+		c.State = health.StateFail
+		c.Detail = "disconnected: " + fallback(st.LastError, "connecting")
+	case m.cfg.ReaderWatchdogRounds > 0 && sdkStats.RoundsSinceFrame >= m.cfg.ReaderWatchdogRounds:
+		c.State = health.StateFail
+		c.Detail = fmt.Sprintf("no frame in %d rounds", sdkStats.RoundsSinceFrame)
+	case st.LastFrameAt.IsZero():
+		c.State = health.StateDegraded
+		c.Detail = "connected to " + st.Endpoint + ", no frame yet"
+	default:
+		c.Detail = fmt.Sprintf("connected to %s, last frame %s ago", st.Endpoint, now.Sub(st.LastFrameAt).Round(time.Millisecond))
+	}
+	if st.Watchdog > 0 {
+		c.Detail += fmt.Sprintf(", watchdog reconnects=%d", st.Watchdog)
+	}
+	return c
+}
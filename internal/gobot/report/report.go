@@ -0,0 +1,209 @@
+// Package report builds /report summaries, CSV exports and the daily digest
+// from the history log, so reads and submits are persisted in one place.
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"new_era_go/internal/gobot/history"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// maxEPCs caps per-EPC rows in one summary; counters keep counting past it.
+	maxEPCs  = 100_000
+	topLimit = 5
+)
+
+// EPCStat is what happened to one EPC within the reported range. Result is the
+// submit outcome when there was one, otherwise the last ingest action.
+type EPCStat struct {
+	EPC       string    `json:"epc"`
+	Reads     int       `json:"reads"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	Result    string    `json:"result,omitempty"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Summary aggregates the history records in [From, To).
+type Summary struct {
+	Label         string
+	From          time.Time
+	To            time.Time
+	Reads         int
+	Misses        int
+	ScanInactive  int
+	Submitted     int
+	NotFound      int
+	SubmitErrors  int
+	RefreshErrors int
+	EPCs          []EPCStat
+}
+
+// epcAcc tracks the latest ingest and submit of one EPC; history segments
+// are scanned newest first, so records do not arrive in time order.
+type epcAcc struct {
+	EPCStat
+	actionAt time.Time
+	action   string
+	submitAt time.Time
+}
+
+// Summarize reads [from, to) from the history store. Reads counts ingest
+// records, one per EPC the service handled.
+func Summarize(h *history.Store, label string, from, to time.Time) (Summary, error) {
+	out := Summary{Label: label, From: from, To: to}
+	accs := make(map[string]*epcAcc)
+	acc := func(rec history.Record) *epcAcc {
+		a, ok := accs[rec.EPC]
+		if !ok {
+			if len(accs) >= maxEPCs {
+				return nil
+			}
+			a = &epcAcc{EPCStat: EPCStat{EPC: rec.EPC, FirstSeen: rec.Time, LastSeen: rec.Time}}
+			accs[rec.EPC] = a
+		}
+		if rec.Time.Before(a.FirstSeen) {
+			a.FirstSeen = rec.Time
+		}
+		if rec.Time.After(a.LastSeen) {
+			a.LastSeen = rec.Time
+		}
+		return a
+	}
+
+	err := h.Scan(from, to, func(rec history.Record) bool {
+		switch rec.Kind {
+		case history.KindIngest:
+			out.Reads++
+			switch rec.Outcome {
+			case "miss":
+				out.Misses++
+			case "scan_inactive":
+				out.ScanInactive++
+			}
+			if a := acc(rec); a != nil {
+				a.Reads++
+				if !rec.Time.Before(a.actionAt) {
+					a.actionAt, a.action = rec.Time, rec.Outcome
+				}
+			}
+		case history.KindSubmit:
+			switch rec.Outcome {
+			case "submitted":
+				out.Submitted++
+			case "not_found":
+				out.NotFound++
+			default:
+				out.SubmitErrors++
+			}
+			if a := acc(rec); a != nil && !rec.Time.Before(a.submitAt) {
+				a.submitAt, a.Result, a.Error = rec.Time, rec.Outcome, rec.Error
+			}
+		case history.KindRefresh:
+			if rec.Outcome != "ok" {
+				out.RefreshErrors++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return Summary{}, err
+	}
+
+	out.EPCs = make([]EPCStat, 0, len(accs))
+	for _, a := range accs {
+		if a.submitAt.IsZero() {
+			a.Result = a.action
+		}
+		out.EPCs = append(out.EPCs, a.EPCStat)
+	}
+	sort.Slice(out.EPCs, func(i, j int) bool {
+		if out.EPCs[i].Reads != out.EPCs[j].Reads {
+			return out.EPCs[i].Reads > out.EPCs[j].Reads
+		}
+		return out.EPCs[i].EPC < out.EPCs[j].EPC
+	})
+	return out, nil
+}
+
+// Range resolves today, yesterday or week (the last 7 days including today) relative to now.
+func Range(name string, now time.Time) (label string, from, to time.Time, ok bool) {
+	today := startOfDay(now)
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "today", "bugun":
+		return "bugun", today, today.AddDate(0, 0, 1), true
+	case "yesterday", "kecha":
+		return "kecha", today.AddDate(0, 0, -1), today, true
+	case "week", "hafta":
+		return "so'nggi 7 kun", today.AddDate(0, 0, -6), today.AddDate(0, 0, 1), true
+	}
+	return "", time.Time{}, time.Time{}, false
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.In(time.Local)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+}
+
+// Text renders the summary for Telegram.
+func (s Summary) Text() string {
+	var sb strings.Builder
+	last := s.To.AddDate(0, 0, -1)
+	period := s.From.Format(dateLayout)
+	if last.After(s.From) {
+		period += " - " + last.Format(dateLayout)
+	}
+	fmt.Fprintf(&sb, "Hisobot (%s): %s\n", s.Label, period)
+	fmt.Fprintf(&sb, "O'qishlar: %d, unikal EPC: %d\n", s.Reads, len(s.EPCs))
+	fmt.Fprintf(&sb, "Submit qilingan draftlar: %d\n", s.Submitted)
+	fmt.Fprintf(&sb, "Draft topilmadi: %d\n", s.NotFound)
+	fmt.Fprintf(&sb, "Cache miss: %d\n", s.Misses)
+	if s.ScanInactive > 0 {
+		fmt.Fprintf(&sb, "Scan o'chiq paytda: %d\n", s.ScanInactive)
+	}
+	fmt.Fprintf(&sb, "Xatolar: submit %d, refresh %d", s.SubmitErrors, s.RefreshErrors)
+	if len(s.EPCs) > 0 {
+		sb.WriteString("\n\nEng ko'p o'qilgan EPC:")
+		for i, st := range s.EPCs {
+			if i >= topLimit {
+				break
+			}
+			fmt.Fprintf(&sb, "\n%d. %s - %d marta", i+1, st.EPC, st.Reads)
+			if st.Result != "" {
+				sb.WriteString(" (" + st.Result + ")")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// WriteCSV writes one row per EPC, most read first.
+func (s Summary) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"epc", "reads", "first_seen", "last_seen", "result", "error"}); err != nil {
+		return err
+	}
+	for _, st := range s.EPCs {
+		row := []string{st.EPC, strconv.Itoa(st.Reads), formatCSVTime(st.FirstSeen), formatCSVTime(st.LastSeen), st.Result, st.Error}
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.In(time.Local).Format(time.RFC3339)
+}
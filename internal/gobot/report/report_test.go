@@ -0,0 +1,75 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/history"
+)
+
+func ingest(at time.Time, epc, action string) history.Record {
+	return history.Record{Time: at, Kind: history.KindIngest, EPC: epc, Outcome: action}
+}
+
+func submit(at time.Time, epc, status string) history.Record {
+	return history.Record{Time: at, Kind: history.KindSubmit, EPC: epc, Outcome: status}
+}
+
+func TestSummarizeTodayAndWeek(t *testing.T) {
+	h, err := history.Open(history.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	yesterday := now.AddDate(0, 0, -1)
+	h.Append(ingest(yesterday, "E1", "queued"))
+	h.Append(submit(yesterday.Add(time.Second), "E1", "submitted"))
+	h.Append(ingest(now, "E1", "queued"))
+	h.Append(ingest(now, "E2", "miss"))
+	h.Append(ingest(now, "E2", "miss"))
+	h.Append(ingest(now, "E3", "queued"))
+	h.Append(submit(now, "E3", "error"))
+	h.Append(history.Record{Time: now, Kind: history.KindRefresh, Outcome: "error"})
+	h.Append(history.Record{Time: now, Kind: history.KindRefresh, Outcome: "ok"})
+	h.Append(history.Record{Time: now, Kind: history.KindRead, EPC: "E4"})
+
+	label, from, to, _ := Range("today", now)
+	today, err := Summarize(h, label, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if today.Reads != 4 || today.Misses != 2 || today.Submitted != 0 || today.SubmitErrors != 1 || today.RefreshErrors != 1 {
+		t.Fatalf("unexpected today summary: %+v", today)
+	}
+	if len(today.EPCs) != 3 || today.EPCs[0].EPC != "E2" || today.EPCs[0].Reads != 2 {
+		t.Fatalf("expected E2 first by reads: %+v", today.EPCs)
+	}
+
+	label, from, to, _ = Range("week", now)
+	week, err := Summarize(h, label, from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if week.Reads != 5 || week.Submitted != 1 || len(week.EPCs) != 3 {
+		t.Fatalf("unexpected week summary: %+v", week)
+	}
+	for _, st := range week.EPCs {
+		if st.EPC == "E1" && (st.Reads != 2 || st.Result != "submitted" || !st.FirstSeen.Equal(yesterday)) {
+			t.Fatalf("E1 should merge both days and keep the submit result: %+v", st)
+		}
+	}
+	if !strings.Contains(week.Text(), "Submit qilingan draftlar: 1") {
+		t.Fatalf("unexpected text: %s", week.Text())
+	}
+
+	var buf bytes.Buffer
+	if err := today.WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || lines[0] != "epc,reads,first_seen,last_seen,result,error" || !strings.HasPrefix(lines[1], "E2,2,") {
+		t.Fatalf("unexpected csv:\n%s", buf.String())
+	}
+}
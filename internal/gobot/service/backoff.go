@@ -0,0 +1,49 @@
+package service
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// backoffDelay returns base*2^attempt capped at max with equal jitter, and never
+// less than retryAfter (Retry-After header or remaining breaker open time).
+func backoffDelay(base, max time.Duration, attempt int, retryAfter time.Duration) time.Duration {
+	if base <= 0 {
+		base = 100 * time.Millisecond
+	}
+	if max < base {
+		max = base
+	}
+
+	d := base
+	for i := 0; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+
+	half := d / 2
+	if half > 0 {
+		d = half + rand.N(half+1)
+	}
+	if retryAfter > d {
+		d = retryAfter
+	}
+	return d
+}
+
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return ctx.Err() == nil
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const drainPoll = 50 * time.Millisecond
+
+// DrainSummary describes what happened to the submit queue during shutdown.
+type DrainSummary struct {
+	Queued    int           `json:"queued"`
+	Inflight  int           `json:"inflight"`
+	Submitted uint64        `json:"submitted"`
+	NotFound  uint64        `json:"not_found"`
+	Failed    uint64        `json:"failed"`
+	Remaining []string      `json:"remaining,omitempty"`
+	TimedOut  bool          `json:"timed_out"`
+	Elapsed   time.Duration `json:"elapsed"`
+}
+
+func (d DrainSummary) String() string {
+	return fmt.Sprintf("queued=%d inflight=%d submitted=%d not_found=%d failed=%d remaining=%d timed_out=%v elapsed=%s",
+		d.Queued, d.Inflight, d.Submitted, d.NotFound, d.Failed, len(d.Remaining), d.TimedOut, d.Elapsed.Round(time.Millisecond))
+}
+
+// Draining reports whether Drain has been called; new EPCs are refused from then on.
+func (s *Service) Draining() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.draining
+}
+
+// Drain stops accepting new EPCs and lets the workers finish the queue until
+// it is empty or ctx is done. The workers are then stopped; EPCs still queued,
+// interrupted mid-submit or held by the open ERP breaker are returned in
+// Remaining.
+func (s *Service) Drain(ctx context.Context) DrainSummary {
+	start := time.Now()
+	s.mu.Lock()
+	s.draining = true
+	before := s.stats
+	sum := DrainSummary{Queued: len(s.queue), Inflight: len(s.inflight)}
+	stopWorkers := s.stopWorkers
+	s.mu.Unlock()
+
+	t := time.NewTicker(drainPoll)
+	defer t.Stop()
+wait:
+	for stopWorkers != nil && !s.idle() {
+		select {
+		case <-ctx.Done():
+			sum.TimedOut = true
+			break wait
+		case <-t.C:
+		}
+	}
+	if stopWorkers != nil {
+		stopWorkers()
+		s.workers.Wait()
+	}
+
+	s.mu.Lock()
+	remaining := s.unfinished
+	s.unfinished = nil
+	if s.heldTimer != nil {
+		s.heldTimer.Stop()
+		s.heldTimer = nil
+	}
+	for epc := range s.held {
+		remaining = append(remaining, epc)
+	}
+	s.held = nil
+	for len(s.queue) > 0 {
+		epc := <-s.queue
+		delete(s.queued, epc)
+		remaining = append(remaining, epc)
+	}
+	sum.Submitted = s.stats.SubmittedOK - before.SubmittedOK
+	sum.NotFound = s.stats.SubmitNotFound - before.SubmitNotFound
+	sum.Failed = s.stats.SubmitErrors - before.SubmitErrors
+	s.mu.Unlock()
+
+	sum.Remaining = normalizeEPCList(remaining)
+	sum.Elapsed = time.Since(start)
+	return sum
+}
+
+func (s *Service) idle() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.queue) == 0 && len(s.inflight) == 0
+}
+
+// pendingFile is the on-disk form of EPCs left over by Drain.
+type pendingFile struct {
+	SavedAt time.Time `json:"saved_at"`
+	EPCs    []string  `json:"epcs"`
+}
+
+// SavePending writes epcs to path for RestorePending on the next start. An
+// empty list removes the file.
+func SavePending(path string, epcs []string) error {
+	if path == "" {
+		return errors.New("pending file not configured")
+	}
+	if len(epcs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		return nil
+	}
+	data, err := json.MarshalIndent(pendingFile{SavedAt: time.Now(), EPCs: epcs}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RestorePending queues the EPCs saved by SavePending. EPCs no longer in the
+// cache are dropped; those that do not fit in the queue stay in the file.
+// Call it after a successful cache refresh.
+func (s *Service) RestorePending(path string) (queued, dropped int, err error) {
+	if path == "" {
+		return 0, 0, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, 0, nil
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+	var pf pendingFile
+	if err := json.Unmarshal(data, &pf); err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var kept []string
+	for _, epc := range normalizeEPCList(pf.EPCs) {
+		switch {
+		case !s.cache.Has(epc):
+			dropped++
+		case s.enqueue(epc):
+			queued++
+		default:
+			kept = append(kept, epc)
+		}
+	}
+	if len(kept) > 0 {
+		log.Printf("[bot] pending restore: %d EPC(s) did not fit in the queue, kept in %s", len(kept), path)
+	}
+	return queued, dropped, SavePending(path, kept)
+}
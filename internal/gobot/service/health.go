@@ -0,0 +1,94 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"new_era_go/internal/gobot/erp"
+	"new_era_go/internal/gobot/health"
+)
+
+// CheckWorkers fails when the submit workers are not running or one has been
+// busy with a single EPC for longer than cfg.HealthStall.
+func (s *Service) CheckWorkers(now time.Time) health.Check {
+	c := health.Check{Name: "workers", State: health.StateOK}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started.IsZero() {
+		c.State = health.StateFail
+		c.Detail = "workers not started"
+		return c
+	}
+	busy, stuck := 0, 0
+	for _, since := range s.workerBusy {
+		if since.IsZero() {
+			continue
+		}
+		busy++
+		if now.Sub(since) > s.cfg.HealthStall {
+			stuck++
+		}
+	}
+	c.Detail = fmt.Sprintf("%d worker(s), %d busy", len(s.workerBusy), busy)
+	if stuck > 0 {
+		c.State = health.StateFail
+		c.Detail = fmt.Sprintf("%d worker(s) busy for more than %s", stuck, s.cfg.HealthStall)
+	}
+	return c
+}
+
+// CheckRefresh fails when the last successful ERP cache refresh is older than
+// cfg.HealthRefreshMaxAge and is degraded while recent refreshes fail.
+func (s *Service) CheckRefresh(now time.Time) health.Check {
+	c := health.Check{Name: "erp_refresh", State: health.StateOK}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.lastGoodRefresh.IsZero():
+		c.State = health.StateFail
+		c.Detail = "no successful refresh yet"
+		if s.lastErr != "" {
+			c.Detail += ": " + s.lastErr
+		}
+	case now.Sub(s.lastGoodRefresh) > s.cfg.HealthRefreshMaxAge:
+		c.State = health.StateFail
+		c.Detail = fmt.Sprintf("last successful refresh %s ago: %s", now.Sub(s.lastGoodRefresh).Round(time.Second), s.lastErr)
+	case s.lastErr != "":
+		c.State = health.StateDegraded
+		c.Detail = fmt.Sprintf("refresh failing (last success %s ago): %s", now.Sub(s.lastGoodRefresh).Round(time.Second), s.lastErr)
+	default:
+		c.Detail = fmt.Sprintf("last refresh %s ago, %d EPC(s) cached", now.Sub(s.lastGoodRefresh).Round(time.Second), s.cache.Size())
+	}
+	return c
+}
+
+// CheckQueue fails when EPCs are queued but no worker has taken one for
+// cfg.HealthStall, and is degraded when the queue is nearly full or the ERP
+// breaker is open.
+func (s *Service) CheckQueue(now time.Time) health.Check {
+	c := health.Check{Name: "submit_queue", State: health.StateOK}
+	depth, capacity := len(s.queue), cap(s.queue)
+	s.mu.Lock()
+	lastDequeue := s.lastDequeue
+	s.mu.Unlock()
+	breaker := erp.BreakerClosed
+	if s.erp != nil {
+		breaker = s.erp.Breaker().Status().State
+	}
+
+	c.Detail = fmt.Sprintf("%d/%d queued", depth, capacity)
+	switch {
+	case depth > 0 && !lastDequeue.IsZero() && now.Sub(lastDequeue) > s.cfg.HealthStall:
+		c.State = health.StateFail
+		c.Detail = fmt.Sprintf("%d queued, no progress for %s", depth, now.Sub(lastDequeue).Round(time.Second))
+	case capacity > 0 && depth*10 >= capacity*9:
+		c.State = health.StateDegraded
+		c.Detail += ", queue nearly full"
+	case breaker != erp.BreakerClosed:
+		c.State = health.StateDegraded
+		c.Detail += ", erp breaker " + string(breaker)
+	}
+	return c
+}
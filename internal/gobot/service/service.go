@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
@@ -11,18 +12,60 @@ import (
 	"new_era_go/internal/gobot/cache"
 	"new_era_go/internal/gobot/config"
 	"new_era_go/internal/gobot/erp"
+	"new_era_go/internal/gobot/events"
+	"new_era_go/internal/gobot/metrics"
+	"new_era_go/internal/gobot/notify"
 )
 
 type Notifier interface {
 	Notify(text string)
 }
 
+// CategoryNotifier is implemented by notifiers that batch and route by
+// category (see notify.Hub). Plain Notifiers get one message per event.
+type CategoryNotifier interface {
+	NotifyCategory(category, text string)
+	CountCategory(category, label, item, draft string)
+}
+
 type IngestResult struct {
 	EPC    string `json:"epc"`
 	Action string `json:"action"`
 	Error  string `json:"error,omitempty"`
 }
 
+// IngestEvent is published on the event bus for every ingested EPC. The
+// event's Reader is the reader endpoint that read the tag, when known; Source
+// is the ingest path ("sdk", "ipc", "http" or the caller's label).
+type IngestEvent struct {
+	IngestResult
+	Source string `json:"source,omitempty"`
+}
+
+// SubmitEvent is published on the event bus when an EPC submit finishes.
+type SubmitEvent struct {
+	EPC      string `json:"epc"`
+	Status   string `json:"status"`
+	Draft    string `json:"draft,omitempty"`
+	Attempts int    `json:"attempts"`
+	Error    string `json:"error,omitempty"`
+}
+
+// RefreshEvent is published on the event bus after every cache refresh attempt.
+type RefreshEvent struct {
+	Reason string `json:"reason"`
+	OK     bool   `json:"ok"`
+	Drafts int    `json:"drafts"`
+	EPCs   int    `json:"epcs"`
+	Error  string `json:"error,omitempty"`
+}
+
+// NotificationEvent mirrors a user-facing notification on the event bus.
+type NotificationEvent struct {
+	Category string `json:"category"`
+	Text     string `json:"text"`
+}
+
 type Stats struct {
 	CacheSize     int       `json:"cache_size"`
 	DraftCount    int       `json:"draft_count"`
@@ -40,6 +83,15 @@ type Stats struct {
 	SubmitErrors   uint64 `json:"submit_errors"`
 	QueueDropped   uint64 `json:"queue_dropped"`
 	ScanInactive   uint64 `json:"scan_inactive"`
+	QueueDepth     int    `json:"queue_depth"`
+	QueueCapacity  int    `json:"queue_capacity"`
+	Inflight       int    `json:"inflight"`
+
+	ERPBreaker      erp.BreakerState `json:"erp_breaker"`
+	ERPBreakerSince time.Time        `json:"erp_breaker_since"`
+	ERPBreakerTrips uint64           `json:"erp_breaker_trips"`
+	RefreshFailures int              `json:"refresh_failures"`
+	NextRefreshAt   time.Time        `json:"next_refresh_at"`
 }
 
 type Service struct {
@@ -47,6 +99,7 @@ type Service struct {
 	erp   *erp.Client
 	cache *cache.Store
 	queue chan string
+	bus   *events.Bus
 
 	mu          sync.Mutex
 	inflight    map[string]struct{}
@@ -59,6 +112,23 @@ type Service struct {
 	scanSince   time.Time
 	stats       Stats
 	notifier    Notifier
+	erpLatency  map[string]*metrics.Histogram
+
+	// Liveness bookkeeping for the health checks.
+	started         time.Time
+	lastGoodRefresh time.Time
+	lastDequeue     time.Time
+	workerBusy      []time.Time
+
+	// Shutdown state, see Drain.
+	draining    bool
+	stopWorkers context.CancelFunc
+	workers     sync.WaitGroup
+	unfinished  []string
+
+	// EPCs rejected by the open ERP breaker, re-queued by heldTimer.
+	held      map[string]struct{}
+	heldTimer *time.Timer
 }
 
 func New(cfg config.Config, erpClient *erp.Client, c *cache.Store) *Service {
@@ -67,11 +137,12 @@ func New(cfg config.Config, erpClient *erp.Client, c *cache.Store) *Service {
 	if cfg.ScanDefaultActive {
 		scanSince = now
 	}
-	return &Service{
+	s := &Service{
 		cfg:        cfg,
 		erp:        erpClient,
 		cache:      c,
 		queue:      make(chan string, cfg.QueueSize),
+		bus:        events.NewBus(),
 		inflight:   make(map[string]struct{}),
 		queued:     make(map[string]struct{}),
 		recentSeen: make(map[string]time.Time),
@@ -81,7 +152,13 @@ func New(cfg config.Config, erpClient *erp.Client, c *cache.Store) *Service {
 			ScanActive: cfg.ScanDefaultActive,
 			ScanSince:  scanSince,
 		},
+		erpLatency: make(map[string]*metrics.Histogram),
+	}
+	if erpClient != nil {
+		erpClient.Breaker().OnStateChange(s.onBreakerChange)
+		erpClient.SetObserver(s.observeERP)
 	}
+	return s
 }
 
 func (s *Service) SetNotifier(n Notifier) {
@@ -90,34 +167,95 @@ func (s *Service) SetNotifier(n Notifier) {
 	s.mu.Unlock()
 }
 
+// Events returns the bus on which the service publishes live events.
+func (s *Service) Events() *events.Bus {
+	return s.bus
+}
+
 func (s *Service) Bootstrap(ctx context.Context) error {
 	return s.RefreshCache(ctx, "startup", true)
 }
 
 func (s *Service) Run(ctx context.Context) {
+	s.mu.Lock()
+	s.started = time.Now()
+	s.lastDequeue = s.started
+	s.workerBusy = make([]time.Time, s.cfg.WorkerCount)
+	workCtx, stopWorkers := context.WithCancel(ctx)
+	s.stopWorkers = stopWorkers
+	s.mu.Unlock()
 	for i := 0; i < s.cfg.WorkerCount; i++ {
-		go s.worker(ctx, i+1)
+		s.workers.Add(1)
+		go s.worker(workCtx, i+1)
 	}
 	go s.refreshLoop(ctx)
 }
 
+// Tuning is the part of the config that ApplyTuning can change while running.
+type Tuning struct {
+	RefreshInterval     time.Duration
+	RefreshMaxBackoff   time.Duration
+	SubmitRetry         int
+	SubmitRetryDelay    time.Duration
+	SubmitRetryMaxDelay time.Duration
+}
+
+// ApplyTuning swaps in new refresh and retry settings; they take effect from
+// the next refresh wait and the next submit.
+func (s *Service) ApplyTuning(t Tuning) {
+	s.mu.Lock()
+	s.cfg.RefreshInterval = t.RefreshInterval
+	s.cfg.RefreshMaxBackoff = t.RefreshMaxBackoff
+	s.cfg.SubmitRetry = t.SubmitRetry
+	s.cfg.SubmitRetryDelay = t.SubmitRetryDelay
+	s.cfg.SubmitRetryMaxDelay = t.SubmitRetryMaxDelay
+	s.mu.Unlock()
+}
+
+func (s *Service) tuning() Tuning {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return Tuning{
+		RefreshInterval:     s.cfg.RefreshInterval,
+		RefreshMaxBackoff:   s.cfg.RefreshMaxBackoff,
+		SubmitRetry:         s.cfg.SubmitRetry,
+		SubmitRetryDelay:    s.cfg.SubmitRetryDelay,
+		SubmitRetryMaxDelay: s.cfg.SubmitRetryMaxDelay,
+	}
+}
+
 func (s *Service) refreshLoop(ctx context.Context) {
-	ticker := time.NewTicker(s.cfg.RefreshInterval)
-	defer ticker.Stop()
+	failures := 0
+	wait := s.tuning().RefreshInterval
 
 	for {
-		select {
-		case <-ctx.Done():
+		s.mu.Lock()
+		s.stats.RefreshFailures = failures
+		s.stats.NextRefreshAt = time.Now().Add(wait)
+		s.mu.Unlock()
+
+		if !sleepContext(ctx, wait) {
+			return
+		}
+
+		err := s.RefreshCache(ctx, "periodic", false)
+		tune := s.tuning()
+		if err == nil {
+			failures = 0
+			wait = tune.RefreshInterval
+			continue
+		}
+		if ctx.Err() != nil {
 			return
-		case <-ticker.C:
-			if err := s.RefreshCache(ctx, "periodic", false); err != nil {
-				log.Printf("[bot] periodic refresh failed: %v", err)
-			}
 		}
+
+		wait = backoffDelay(tune.RefreshInterval, tune.RefreshMaxBackoff, failures, erp.RetryAfter(err))
+		failures++
+		log.Printf("[bot] periodic refresh failed (streak=%d, next in %s): %v", failures, wait.Round(time.Millisecond), err)
 	}
 }
 
-func (s *Service) RefreshCache(parent context.Context, reason string, notify bool) error {
+func (s *Service) RefreshCache(parent context.Context, reason string, announce bool) error {
 	ctx, cancel := context.WithTimeout(parent, s.cfg.RequestTimeout)
 	defer cancel()
 
@@ -130,6 +268,7 @@ func (s *Service) RefreshCache(parent context.Context, reason string, notify boo
 		s.stats.LastRefreshOK = false
 		s.stats.LastError = s.lastErr
 		s.mu.Unlock()
+		s.bus.Publish(events.TypeRefresh, "", RefreshEvent{Reason: reason, Error: err.Error()})
 		return err
 	}
 
@@ -142,6 +281,7 @@ func (s *Service) RefreshCache(parent context.Context, reason string, notify boo
 	prevDraftCount := s.draftCount
 	s.draftCount = res.DraftCount
 	s.lastRefresh = now
+	s.lastGoodRefresh = now
 	s.lastErr = ""
 	s.stats.CacheSize = s.cache.Size()
 	s.stats.DraftCount = s.draftCount
@@ -149,6 +289,7 @@ func (s *Service) RefreshCache(parent context.Context, reason string, notify boo
 	s.stats.LastRefreshOK = true
 	s.stats.LastError = ""
 	s.mu.Unlock()
+	s.bus.Publish(events.TypeRefresh, "", RefreshEvent{Reason: reason, OK: true, Drafts: res.DraftCount, EPCs: len(res.EPCs)})
 
 	if s.ScanActive() {
 		for _, epc := range replay {
@@ -159,15 +300,15 @@ func (s *Service) RefreshCache(parent context.Context, reason string, notify boo
 	if reason != "startup" {
 		cacheSize := s.cache.Size()
 		if len(newEPCs) > 0 {
-			s.notify(fmt.Sprintf("Yangi draft ERP'dan keldi: +%d EPC (cache=%d). Namuna: %s",
+			s.notifyAs(notify.CategoryDrafts, fmt.Sprintf("Yangi draft ERP'dan keldi: +%d EPC (cache=%d). Namuna: %s",
 				len(newEPCs), cacheSize, summarizeEPCs(newEPCs, 3)))
 		} else if res.DraftCount > prevDraftCount {
-			s.notify(fmt.Sprintf("Yangi draft ERP'dan keldi: draft +%d (cache=%d, EPC diff=0)",
+			s.notifyAs(notify.CategoryDrafts, fmt.Sprintf("Yangi draft ERP'dan keldi: draft +%d (cache=%d, EPC diff=0)",
 				res.DraftCount-prevDraftCount, cacheSize))
 		}
 	}
 
-	if notify {
+	if announce {
 		s.notify(fmt.Sprintf("Turbo tayyor: %d ta draft, %d ta EPC cache ga yangilandi.", res.DraftCount, len(res.EPCs)))
 	} else {
 		log.Printf("[bot] cache refresh (%s): drafts=%d epcs=%d replay=%d", reason, res.DraftCount, len(res.EPCs), len(replay))
@@ -189,13 +330,25 @@ func (s *Service) AddDraftEPCs(_ context.Context, epcs []string) (int, int) {
 	}
 	if added > 0 {
 		cacheSize := s.cache.Size()
-		s.notify(fmt.Sprintf("Yangi draft webhook: +%d EPC (cache=%d). Namuna: %s",
+		s.notifyAs(notify.CategoryDrafts, fmt.Sprintf("Yangi draft webhook: +%d EPC (cache=%d). Namuna: %s",
 			added, cacheSize, summarizeEPCs(newEPCs, 3)))
 	}
 	return added, len(replay)
 }
 
-func (s *Service) HandleEPC(_ context.Context, rawEPC, _ string) IngestResult {
+func (s *Service) HandleEPC(ctx context.Context, rawEPC, source string) IngestResult {
+	return s.HandleReaderEPC(ctx, rawEPC, source, "")
+}
+
+// HandleReaderEPC is HandleEPC for a tag read by the reader at endpoint, so
+// ingest events can be filtered by reader like tag and state events.
+func (s *Service) HandleReaderEPC(ctx context.Context, rawEPC, source, endpoint string) IngestResult {
+	res := s.handleEPC(ctx, rawEPC)
+	s.bus.Publish(events.TypeIngest, endpoint, IngestEvent{IngestResult: res, Source: source})
+	return res
+}
+
+func (s *Service) handleEPC(_ context.Context, rawEPC string) IngestResult {
 	epc := erp.NormalizeEPC(rawEPC)
 	if epc == "" {
 		return IngestResult{Action: "invalid", Error: "epc is empty"}
@@ -203,6 +356,10 @@ func (s *Service) HandleEPC(_ context.Context, rawEPC, _ string) IngestResult {
 
 	now := time.Now()
 	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return IngestResult{EPC: epc, Action: "shutting_down"}
+	}
 	s.recentSeen[epc] = now
 	s.gcRecentSeenLocked(now)
 	s.stats.SeenTotal++
@@ -276,13 +433,43 @@ func (s *Service) Status() Stats {
 	s.stats.DraftCount = s.draftCount
 	s.stats.ScanActive = s.scanActive
 	s.stats.ScanSince = s.scanSince
+	s.stats.QueueDepth = len(s.queue)
+	s.stats.QueueCapacity = cap(s.queue)
+	s.stats.Inflight = len(s.inflight)
+	br := s.erp.Breaker().Status()
+	s.stats.ERPBreaker = br.State
+	s.stats.ERPBreakerSince = br.Since
+	s.stats.ERPBreakerTrips = br.Trips
 	return s.stats
 }
 
+// ERPLatency returns latency histograms of ERP calls keyed by operation.
+func (s *Service) ERPLatency() map[string]metrics.HistogramSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]metrics.HistogramSnapshot, len(s.erpLatency))
+	for op, h := range s.erpLatency {
+		out[op] = h.Snapshot()
+	}
+	return out
+}
+
+func (s *Service) observeERP(op string, elapsed time.Duration, _ error) {
+	op = strings.ReplaceAll(op, " ", "_")
+	s.mu.Lock()
+	h, ok := s.erpLatency[op]
+	if !ok {
+		h = metrics.NewHistogram(metrics.LatencyBuckets)
+		s.erpLatency[op] = h
+	}
+	s.mu.Unlock()
+	h.Observe(elapsed.Seconds())
+}
+
 func (s *Service) StatusText() string {
 	st := s.Status()
 	return fmt.Sprintf(
-		"Scan: active=%v since=%s\nCache: %d EPC (draft=%d)\nSeen: %d | hit=%d miss=%d inactive=%d\nSubmit: ok=%d not_found=%d err=%d\nLast refresh: %s (ok=%v)",
+		"Scan: active=%v since=%s\nCache: %d EPC (draft=%d)\nSeen: %d | hit=%d miss=%d inactive=%d\nSubmit: ok=%d not_found=%d err=%d\nLast refresh: %s (ok=%v)\nERP breaker: %s (trips=%d)",
 		st.ScanActive,
 		formatTime(st.ScanSince),
 		st.CacheSize,
@@ -296,22 +483,39 @@ func (s *Service) StatusText() string {
 		st.SubmitErrors,
 		formatTime(st.LastRefreshAt),
 		st.LastRefreshOK,
+		st.ERPBreaker,
+		st.ERPBreakerTrips,
 	)
 }
 
 func (s *Service) worker(ctx context.Context, workerID int) {
+	defer s.workers.Done()
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case epc := <-s.queue:
+			now := time.Now()
 			s.mu.Lock()
 			delete(s.queued, epc)
+			s.lastDequeue = now
+			s.workerBusy[workerID-1] = now
 			s.mu.Unlock()
 
-			if err := s.processSubmit(ctx, epc); err != nil {
+			err := s.processSubmit(ctx, epc)
+			held := errors.Is(err, erp.ErrCircuitOpen)
+			if held {
+				s.holdForBreaker(epc, erp.RetryAfter(err))
+			} else if err != nil && ctx.Err() == nil {
 				log.Printf("[bot] worker=%d submit failed epc=%s err=%v", workerID, epc, err)
 			}
+
+			s.mu.Lock()
+			s.workerBusy[workerID-1] = time.Time{}
+			if err != nil && ctx.Err() != nil && !held {
+				s.unfinished = append(s.unfinished, epc)
+			}
+			s.mu.Unlock()
 		}
 	}
 }
@@ -330,21 +534,23 @@ func (s *Service) processSubmit(parent context.Context, epc string) error {
 	defer s.unlockInflight(epc)
 
 	var lastErr error
-	retries := s.cfg.SubmitRetry
+	tune := s.tuning()
+	retries := tune.SubmitRetry
 	for attempt := 0; attempt <= retries; attempt++ {
 		ctx, cancel := context.WithTimeout(parent, s.cfg.RequestTimeout)
-		status, err := s.erp.SubmitByEPC(ctx, epc)
+		res, err := s.erp.Submit(ctx, epc)
 		cancel()
 
 		if err == nil {
-			switch status {
+			switch res.Status {
 			case erp.SubmitStatusSubmitted:
 				s.cache.Remove(epc)
 				s.mu.Lock()
 				s.stats.SubmittedOK++
 				s.stats.CacheSize = s.cache.Size()
 				s.mu.Unlock()
-				s.notify("Submit OK: " + trimEPC(epc))
+				s.bus.Publish(events.TypeSubmit, "", SubmitEvent{EPC: epc, Status: string(res.Status), Draft: res.Draft, Attempts: attempt + 1})
+				s.count(notify.CategorySubmits, "Submit OK", trimEPC(epc), res.Draft)
 				return nil
 			case erp.SubmitStatusNotFound:
 				s.cache.Remove(epc)
@@ -352,32 +558,91 @@ func (s *Service) processSubmit(parent context.Context, epc string) error {
 				s.stats.SubmitNotFound++
 				s.stats.CacheSize = s.cache.Size()
 				s.mu.Unlock()
+				s.bus.Publish(events.TypeSubmit, "", SubmitEvent{EPC: epc, Status: string(res.Status), Attempts: attempt + 1})
 				return nil
 			default:
-				lastErr = fmt.Errorf("unexpected submit status: %s", status)
+				lastErr = fmt.Errorf("unexpected submit status: %s", res.Status)
 			}
 		} else {
 			lastErr = err
 		}
 
+		if errors.Is(lastErr, erp.ErrCircuitOpen) {
+			// No point sleeping on the breaker: the worker holds epc
+			// and re-queues it when a probe may go through.
+			return lastErr
+		}
+		if parent.Err() != nil {
+			return parent.Err()
+		}
 		if attempt < retries {
-			time.Sleep(s.cfg.SubmitRetryDelay)
+			delay := backoffDelay(tune.SubmitRetryDelay, tune.SubmitRetryMaxDelay, attempt, erp.RetryAfter(lastErr))
+			if !sleepContext(parent, delay) {
+				return parent.Err()
+			}
 		}
 	}
 
 	s.mu.Lock()
 	s.stats.SubmitErrors++
 	s.mu.Unlock()
-	s.notify("Submit xato: " + trimEPC(epc))
+	s.bus.Publish(events.TypeSubmit, "", SubmitEvent{EPC: epc, Status: "error", Attempts: retries + 1, Error: lastErr.Error()})
+	s.count(notify.CategoryErrors, "Submit xato", trimEPC(epc), "")
 	return lastErr
 }
 
+// holdForBreaker parks epc while the ERP breaker is open. All held EPCs are
+// re-queued together once wait has passed; the first one through becomes the
+// half-open probe and the rest are held again until the breaker settles.
+func (s *Service) holdForBreaker(epc string, wait time.Duration) {
+	if wait <= 0 {
+		wait = time.Second
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.held == nil {
+		s.held = make(map[string]struct{})
+	}
+	s.held[epc] = struct{}{}
+	if s.heldTimer == nil && !s.draining {
+		s.heldTimer = time.AfterFunc(wait, s.releaseHeld)
+	}
+}
+
+// releaseHeld re-queues the EPCs parked by holdForBreaker. While draining
+// they stay held and Drain reports them as remaining.
+func (s *Service) releaseHeld() {
+	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return
+	}
+	if s.heldTimer != nil {
+		s.heldTimer.Stop()
+		s.heldTimer = nil
+	}
+	epcs := make([]string, 0, len(s.held))
+	for epc := range s.held {
+		epcs = append(epcs, epc)
+	}
+	s.held = nil
+	s.mu.Unlock()
+
+	for _, epc := range epcs {
+		s.enqueue(epc)
+	}
+}
+
 func (s *Service) enqueue(epc string) bool {
 	if epc == "" {
 		return false
 	}
 
 	s.mu.Lock()
+	if s.draining {
+		s.mu.Unlock()
+		return false
+	}
 	if _, ok := s.queued[epc]; ok {
 		s.mu.Unlock()
 		return false
@@ -457,16 +722,55 @@ func (s *Service) gcRecentSeenLocked(now time.Time) {
 	}
 }
 
+func (s *Service) onBreakerChange(from, to erp.BreakerState, st erp.BreakerStatus) {
+	log.Printf("[bot] erp breaker %s -> %s (failures=%d trips=%d)", from, to, st.Failures, st.Trips)
+	switch to {
+	case erp.BreakerOpen:
+		s.notifyAs(notify.CategoryErrors, fmt.Sprintf("ERP javob bermayapti: circuit breaker ochildi (%d ketma-ket xato). So'rovlar vaqtincha to'xtatildi.", st.Failures))
+	case erp.BreakerClosed:
+		s.notifyAs(notify.CategoryErrors, "ERP tiklandi: circuit breaker yopildi.")
+		s.releaseHeld()
+	}
+}
+
 func (s *Service) notify(text string) {
+	s.notifyAs(notify.CategorySystem, text)
+}
+
+func (s *Service) notifyAs(category, text string) {
 	text = strings.TrimSpace(text)
 	if text == "" {
 		return
 	}
 
+	s.bus.Publish(events.TypeNotification, "", NotificationEvent{Category: category, Text: text})
+
+	s.mu.Lock()
+	n := s.notifier
+	s.mu.Unlock()
+	if cn, ok := n.(CategoryNotifier); ok {
+		cn.NotifyCategory(category, text)
+	} else if n != nil {
+		n.Notify(text)
+	}
+}
+
+// count reports one item of a high-volume notification (e.g. a submitted EPC
+// and its draft) so a CategoryNotifier can summarise them instead of sending
+// one message each.
+func (s *Service) count(category, label, item, draft string) {
+	text := label + ": " + item
+	if draft != "" {
+		text += " (" + draft + ")"
+	}
+	s.bus.Publish(events.TypeNotification, "", NotificationEvent{Category: category, Text: text})
+
 	s.mu.Lock()
 	n := s.notifier
 	s.mu.Unlock()
-	if n != nil {
+	if cn, ok := n.(CategoryNotifier); ok {
+		cn.CountCategory(category, label, item, draft)
+	} else if n != nil {
 		n.Notify(text)
 	}
 }
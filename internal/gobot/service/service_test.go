@@ -2,15 +2,22 @@ package service
 
 import (
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"new_era_go/internal/gobot/cache"
 	"new_era_go/internal/gobot/config"
 	"new_era_go/internal/gobot/erp"
+	"new_era_go/internal/gobot/health"
 )
 
 func testConfig() config.Config {
@@ -172,3 +179,187 @@ func TestRefreshCacheNotifiesWhenDraftCountIncreasesWithoutNewEPC(t *testing.T)
 		t.Fatalf("expected draft +1 message, got %q", last)
 	}
 }
+
+func TestBackoffDelayGrowsAndHonorsRetryAfter(t *testing.T) {
+	base := 100 * time.Millisecond
+	max := time.Second
+
+	for attempt, want := range []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second} {
+		got := backoffDelay(base, max, attempt, 0)
+		if got < want/2 || got > want {
+			t.Fatalf("attempt %d: delay %s outside [%s, %s]", attempt, got, want/2, want)
+		}
+	}
+
+	if got := backoffDelay(base, max, 0, 5*time.Second); got != 5*time.Second {
+		t.Fatalf("expected Retry-After to win, got %s", got)
+	}
+}
+
+func TestOpenBreakerHoldsEPCsUntilProbe(t *testing.T) {
+	const (
+		first  = "E200001122334411"
+		second = "E200001122334412"
+	)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":{"ok":true,"status":"submitted"}}`))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	cfg.SubmitRetry = 3
+	client := erp.New(srv.URL, "k", "s", cfg.RequestTimeout)
+	client.SetBreaker(erp.NewBreaker(erp.BreakerConfig{FailureThreshold: 1, OpenTimeout: 200 * time.Millisecond, HalfOpenProbes: 1}))
+	c := cache.New()
+	c.Add([]string{first, second})
+	svc := New(cfg, client, c)
+	svc.SetScanActive(true, "unit_test")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.Run(ctx)
+
+	svc.HandleEPC(context.Background(), first, "test")
+	waitFor(t, time.Second, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		_, ok := svc.held[first]
+		return ok
+	})
+	svc.HandleEPC(context.Background(), second, "test")
+	waitFor(t, 100*time.Millisecond, func() bool {
+		svc.mu.Lock()
+		defer svc.mu.Unlock()
+		return len(svc.held) == 2
+	})
+	if st := svc.Status(); st.SubmitErrors != 0 {
+		t.Fatalf("held EPCs must not count as submit errors, got %d", st.SubmitErrors)
+	}
+
+	waitFor(t, 2*time.Second, func() bool { return svc.Status().SubmittedOK == 2 })
+	if st := svc.Status(); st.SubmitErrors != 0 || st.ERPBreaker != erp.BreakerClosed {
+		t.Fatalf("unexpected stats after probe: errors=%d breaker=%s", st.SubmitErrors, st.ERPBreaker)
+	}
+	if n := calls.Load(); n != 3 {
+		t.Fatalf("expected one failed call and two submits, got %d calls", n)
+	}
+}
+
+func waitFor(t *testing.T, limit time.Duration, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(limit)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met within %s", limit)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestHealthChecksReportStalledQueueAndStaleRefresh(t *testing.T) {
+	cfg := testConfig()
+	cfg.HealthStall = time.Minute
+	cfg.HealthRefreshMaxAge = 10 * time.Minute
+	svc := New(cfg, nil, cache.New())
+	now := time.Now()
+
+	if c := svc.CheckWorkers(now); c.State != health.StateFail {
+		t.Fatalf("workers must fail before Run, got %+v", c)
+	}
+	// What Run records, without starting workers that would drain the queue.
+	svc.mu.Lock()
+	svc.started = now
+	svc.lastDequeue = now
+	svc.workerBusy = make([]time.Time, cfg.WorkerCount)
+	svc.mu.Unlock()
+	if c := svc.CheckWorkers(now); c.State != health.StateOK {
+		t.Fatalf("workers should be ok after Run, got %+v", c)
+	}
+
+	if c := svc.CheckRefresh(now); c.State != health.StateFail {
+		t.Fatalf("refresh must fail before the first success, got %+v", c)
+	}
+	svc.mu.Lock()
+	svc.lastGoodRefresh = now.Add(-time.Minute)
+	svc.lastErr = "erp down"
+	svc.mu.Unlock()
+	if c := svc.CheckRefresh(now); c.State != health.StateDegraded {
+		t.Fatalf("recent failure should degrade, got %+v", c)
+	}
+	if c := svc.CheckRefresh(now.Add(time.Hour)); c.State != health.StateFail || !strings.Contains(c.Detail, "erp down") {
+		t.Fatalf("hour-old refresh should fail, got %+v", c)
+	}
+
+	svc.queue <- "E1"
+	if c := svc.CheckQueue(now.Add(30 * time.Second)); c.State != health.StateOK {
+		t.Fatalf("fresh backlog is ok, got %+v", c)
+	}
+	if c := svc.CheckQueue(now.Add(2 * time.Minute)); c.State != health.StateFail {
+		t.Fatalf("stalled backlog should fail, got %+v", c)
+	}
+}
+
+func TestDrainFinishesQueueThenSavesInterruptedEPCs(t *testing.T) {
+	const (
+		fast = "E200001122334401"
+		slow = "E200001122334402"
+		last = "E200001122334403"
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), slow) {
+			<-r.Context().Done()
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"message":{"ok":true,"status":"submitted"}}`))
+	}))
+	defer srv.Close()
+
+	cfg := testConfig()
+	c := cache.New()
+	c.Add([]string{fast, slow, last})
+	svc := New(cfg, erp.New(srv.URL, "k", "s", cfg.RequestTimeout), c)
+	svc.SetScanActive(true, "unit_test")
+	for _, epc := range []string{fast, slow, last} {
+		if res := svc.HandleEPC(context.Background(), epc, "test"); res.Action != "queued" {
+			t.Fatalf("%s: expected queued, got %q", epc, res.Action)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	svc.Run(ctx)
+
+	drainCtx, stopDrain := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer stopDrain()
+	sum := svc.Drain(drainCtx)
+	if !sum.TimedOut || sum.Submitted != 1 || sum.Failed != 0 {
+		t.Fatalf("unexpected summary: %s", sum)
+	}
+	if !slices.Equal(sum.Remaining, []string{slow, last}) {
+		t.Fatalf("expected %s and %s remaining, got %v", slow, last, sum.Remaining)
+	}
+	if res := svc.HandleEPC(context.Background(), fast, "test"); res.Action != "shutting_down" {
+		t.Fatalf("expected ingest refused while draining, got %q", res.Action)
+	}
+
+	path := filepath.Join(t.TempDir(), "pending.json")
+	if err := SavePending(path, sum.Remaining); err != nil {
+		t.Fatal(err)
+	}
+	next := cache.New()
+	next.Add([]string{slow})
+	restarted := New(cfg, nil, next)
+	queued, dropped, err := restarted.RestorePending(path)
+	if err != nil || queued != 1 || dropped != 1 {
+		t.Fatalf("restore: queued=%d dropped=%d err=%v", queued, dropped, err)
+	}
+	if _, err := os.Stat(path); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("expected pending file removed after restore, got %v", err)
+	}
+}
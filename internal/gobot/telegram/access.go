@@ -0,0 +1,319 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Role is the access level of a Telegram chat or user.
+type Role string
+
+const (
+	RolePending  Role = "pending"
+	RoleViewer   Role = "viewer"
+	RoleOperator Role = "operator"
+	RoleAdmin    Role = "admin"
+)
+
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	}
+	return 0
+}
+
+func (r Role) atLeast(min Role) bool {
+	return r.rank() >= min.rank()
+}
+
+func parseRole(raw string) (Role, bool) {
+	switch Role(strings.ToLower(strings.TrimSpace(raw))) {
+	case RoleViewer:
+		return RoleViewer, true
+	case RoleOperator:
+		return RoleOperator, true
+	case RoleAdmin:
+		return RoleAdmin, true
+	}
+	return "", false
+}
+
+// commandRoles is the minimum role for each command. Commands not listed
+// (/start, /help) are open so unknown chats can request access.
+var commandRoles = map[string]Role{
+	"/status":   RoleViewer,
+	"/scan":     RoleOperator,
+	"/read":     RoleOperator,
+	"/stop":     RoleOperator,
+	"/turbo":    RoleOperator,
+	"/chats":    RoleAdmin,
+	"/approve":  RoleAdmin,
+	"/revoke":   RoleAdmin,
+	"/panel":    RoleViewer,
+	"/notify":   RoleViewer,
+	"/report":   RoleViewer,
+	"/power":    RoleOperator,
+	"/antennas": RoleOperator,
+	"/antcheck": RoleOperator,
+}
+
+// chatRecord is one registered chat in the chat store.
+type chatRecord struct {
+	ID    int64     `json:"id"`
+	Role  Role      `json:"role"`
+	Title string    `json:"title,omitempty"`
+	Since time.Time `json:"since"`
+	// PanelMessageID is the pinned /panel message edited in place by callbacks.
+	PanelMessageID int64 `json:"panel_message_id,omitempty"`
+	// Muted lists notification categories this chat opted out of.
+	Muted []string `json:"muted,omitempty"`
+}
+
+// SetAccessList turns on access control with IDs from configuration. These
+// roles are fixed: /approve and /revoke only change chats in the chat store.
+// With every list empty the bot stays open to all chats, as before.
+func (b *Bot) SetAccessList(admins, operators, viewers []int64) {
+	static := make(map[int64]Role)
+	for _, group := range []struct {
+		ids  []int64
+		role Role
+	}{
+		{viewers, RoleViewer},
+		{operators, RoleOperator},
+		{admins, RoleAdmin},
+	} {
+		for _, id := range group.ids {
+			if id != 0 && group.role.rank() > static[id].rank() {
+				static[id] = group.role
+			}
+		}
+	}
+
+	b.mu.Lock()
+	b.static = static
+	b.enforce = len(static) > 0
+	b.mu.Unlock()
+
+	if len(static) == 0 {
+		log.Printf("[bot] telegram access list empty; every chat can control the reader")
+	}
+}
+
+// roleFor returns the effective role for a message: the best of the
+// configured user, configured chat and stored chat roles.
+func (b *Bot) roleFor(chatID, userID int64) Role {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.enforce {
+		return RoleOperator
+	}
+	return b.roleLocked(chatID, userID)
+}
+
+func (b *Bot) roleLocked(chatID, userID int64) Role {
+	role := RolePending
+	for _, r := range []Role{b.static[userID], b.static[chatID]} {
+		if r.rank() > role.rank() {
+			role = r
+		}
+	}
+	if rec, ok := b.chats[chatID]; ok && rec.Role.rank() > role.rank() {
+		role = rec.Role
+	}
+	return role
+}
+
+// authorize checks cmd against the sender's role, auditing every decision
+// for protected commands.
+func (b *Bot) authorize(ctx context.Context, msg message, cmd string) bool {
+	role, need, allowed := b.checkRole(msg.Chat.ID, msg.From, cmd)
+	if allowed {
+		return true
+	}
+
+	text := "Ruxsat yo'q: bu buyruq uchun " + string(need) + " roli kerak."
+	if role == RolePending {
+		b.registerPending(ctx, msg)
+		text = "Ruxsat yo'q. So'rov adminlarga yuborildi, tasdiqlashni kuting."
+	}
+	_ = b.sendMessage(ctx, msg.Chat.ID, text)
+	return false
+}
+
+// checkRole reports whether from may run cmd in chatID and audits protected commands.
+func (b *Bot) checkRole(chatID int64, from user, cmd string) (role, need Role, allowed bool) {
+	need, protected := commandRoles[cmd]
+	if !protected {
+		return RoleOperator, need, true
+	}
+	role = b.roleFor(chatID, from.ID)
+	allowed = role.atLeast(need)
+	log.Printf("[bot] telegram audit chat=%d user=%d (%s) cmd=%s role=%s allowed=%t",
+		chatID, from.ID, from.label(), cmd, role, allowed)
+	return role, need, allowed
+}
+
+// registerPending records an unknown chat and tells admins once.
+func (b *Bot) registerPending(ctx context.Context, msg message) {
+	b.mu.Lock()
+	if !b.enforce {
+		b.mu.Unlock()
+		return
+	}
+	if _, ok := b.chats[msg.Chat.ID]; ok {
+		b.mu.Unlock()
+		return
+	}
+	b.chats[msg.Chat.ID] = &chatRecord{ID: msg.Chat.ID, Role: RolePending, Title: msg.Chat.label(), Since: time.Now()}
+	snapshot := b.snapshotRecordsLocked()
+	admins := b.adminChatsLocked()
+	b.mu.Unlock()
+
+	b.persistChats(snapshot)
+	log.Printf("[bot] telegram chat=%d (%s) pending approval", msg.Chat.ID, msg.Chat.label())
+
+	text := fmt.Sprintf("Yangi chat ruxsat so'ramoqda: %d (%s)\nTasdiqlash: /approve %d operator", msg.Chat.ID, msg.Chat.label(), msg.Chat.ID)
+	for _, id := range admins {
+		_ = b.sendMessage(ctx, id, text)
+	}
+}
+
+func (b *Bot) adminChatsLocked() []int64 {
+	seen := make(map[int64]struct{})
+	var out []int64
+	add := func(id int64) {
+		if _, ok := seen[id]; !ok {
+			seen[id] = struct{}{}
+			out = append(out, id)
+		}
+	}
+	for id, role := range b.static {
+		if role == RoleAdmin {
+			add(id)
+		}
+	}
+	for id, rec := range b.chats {
+		if rec.Role == RoleAdmin {
+			add(id)
+		}
+	}
+	return out
+}
+
+func (b *Bot) handleChats(ctx context.Context, chatID int64) error {
+	b.mu.Lock()
+	records := b.snapshotRecordsLocked()
+	if b.enforce {
+		for i := range records {
+			records[i].Role = b.roleLocked(records[i].ID, records[i].ID)
+		}
+	}
+	static := make(map[int64]Role, len(b.static))
+	for id, role := range b.static {
+		static[id] = role
+	}
+	b.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("Chatlar:\n")
+	if len(records) == 0 {
+		sb.WriteString("(bo'sh)\n")
+	}
+	for _, rec := range records {
+		fmt.Fprintf(&sb, "%d %s %s\n", rec.ID, rec.Role, rec.Title)
+	}
+	if len(static) > 0 {
+		ids := make([]int64, 0, len(static))
+		for id := range static {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		sb.WriteString("\nConfig:\n")
+		for _, id := range ids {
+			fmt.Fprintf(&sb, "%d %s\n", id, static[id])
+		}
+	}
+	return b.sendMessage(ctx, chatID, strings.TrimSpace(sb.String()))
+}
+
+func (b *Bot) handleApprove(ctx context.Context, msg message, args []string) error {
+	if len(args) == 0 {
+		return b.sendMessage(ctx, msg.Chat.ID, "Foydalanish: /approve <chat_id> [viewer|operator|admin]")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id == 0 {
+		return b.sendMessage(ctx, msg.Chat.ID, "Noto'g'ri chat_id: "+args[0])
+	}
+	role := RoleViewer
+	if len(args) > 1 {
+		r, ok := parseRole(args[1])
+		if !ok {
+			return b.sendMessage(ctx, msg.Chat.ID, "Noma'lum rol: "+args[1])
+		}
+		role = r
+	}
+
+	b.mu.Lock()
+	rec, ok := b.chats[id]
+	if !ok {
+		rec = &chatRecord{ID: id, Since: time.Now()}
+		b.chats[id] = rec
+	}
+	rec.Role = role
+	snapshot := b.snapshotRecordsLocked()
+	b.mu.Unlock()
+
+	b.persistChats(snapshot)
+	log.Printf("[bot] telegram audit chat=%d role=%s approved_by=%d", id, role, msg.From.ID)
+	_ = b.sendMessage(ctx, id, "Chat tasdiqlandi. Rol: "+string(role))
+	return b.sendMessage(ctx, msg.Chat.ID, fmt.Sprintf("Tasdiqlandi: %d -> %s", id, role))
+}
+
+func (b *Bot) handleRevoke(ctx context.Context, msg message, args []string) error {
+	if len(args) == 0 {
+		return b.sendMessage(ctx, msg.Chat.ID, "Foydalanish: /revoke <chat_id>")
+	}
+	id, err := strconv.ParseInt(args[0], 10, 64)
+	if err != nil || id == 0 {
+		return b.sendMessage(ctx, msg.Chat.ID, "Noto'g'ri chat_id: "+args[0])
+	}
+
+	b.mu.Lock()
+	_, stored := b.chats[id]
+	delete(b.chats, id)
+	staticRole := b.static[id]
+	snapshot := b.snapshotRecordsLocked()
+	b.mu.Unlock()
+
+	if stored {
+		b.persistChats(snapshot)
+		log.Printf("[bot] telegram audit chat=%d revoked_by=%d", id, msg.From.ID)
+	}
+	text := fmt.Sprintf("Bekor qilindi: %d", id)
+	if !stored {
+		text = fmt.Sprintf("Chat topilmadi: %d", id)
+	}
+	if staticRole != "" {
+		text += fmt.Sprintf("\nDiqqat: %d config orqali %s, uni BOT_TELEGRAM_* dan olib tashlang.", id, staticRole)
+	}
+	return b.sendMessage(ctx, msg.Chat.ID, text)
+}
+
+func (b *Bot) snapshotRecordsLocked() []chatRecord {
+	out := make([]chatRecord, 0, len(b.chats))
+	for _, rec := range b.chats {
+		out = append(out, *rec)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
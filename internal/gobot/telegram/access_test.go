@@ -0,0 +1,257 @@
+package telegram
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"new_era_go/internal/gobot/cache"
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/history"
+	"new_era_go/internal/gobot/reader"
+	"new_era_go/internal/gobot/service"
+)
+
+type fakeScanner struct {
+	mu    sync.Mutex
+	stops int
+}
+
+func (f *fakeScanner) Start(context.Context) error { return nil }
+func (f *fakeScanner) StatusText() string          { return "fake" }
+func (f *fakeScanner) Stop() {
+	f.mu.Lock()
+	f.stops++
+	f.mu.Unlock()
+}
+
+type apiCall struct {
+	Method string
+	Form   url.Values
+}
+
+type sentLog struct {
+	mu    sync.Mutex
+	sent  map[string][]string
+	calls []apiCall
+}
+
+func (s *sentLog) method(name string) []apiCall {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []apiCall
+	for _, c := range s.calls {
+		if c.Method == name {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+func (s *sentLog) to(chatID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.sent[chatID]...)
+}
+
+func newTestBot(t *testing.T) (*Bot, *fakeScanner, *sentLog) {
+	t.Helper()
+	sent := &sentLog{sent: make(map[string][]string)}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		method := strings.TrimPrefix(r.URL.Path, "/")
+		sent.mu.Lock()
+		sent.calls = append(sent.calls, apiCall{Method: method, Form: r.PostForm})
+		if method == "sendMessage" {
+			sent.sent[r.FormValue("chat_id")] = append(sent.sent[r.FormValue("chat_id")], r.FormValue("text"))
+		}
+		n := len(sent.calls)
+		sent.mu.Unlock()
+		_, _ = fmt.Fprintf(w, `{"ok":true,"result":{"message_id":%d}}`, n)
+	}))
+	t.Cleanup(api.Close)
+
+	t.Setenv("BOT_CHAT_STORE_FILE", filepath.Join(t.TempDir(), "chats.json"))
+	svc := service.New(config.Config{RequestTimeout: time.Second, QueueSize: 16, RecentSeenTTL: time.Minute}, nil, cache.New())
+	scanner := &fakeScanner{}
+	b := New("token", time.Second, 5*time.Second, svc, scanner)
+	b.baseURL = api.URL
+	return b, scanner, sent
+}
+
+func send(t *testing.T, b *Bot, chatID, userID int64, text string) {
+	t.Helper()
+	upd := update{Message: message{Text: text, Chat: chat{ID: chatID}, From: user{ID: userID}}}
+	if err := b.handleUpdate(context.Background(), upd); err != nil {
+		t.Fatalf("handle %q: %v", text, err)
+	}
+}
+
+func TestUnknownChatCannotControlReaderUntilApproved(t *testing.T) {
+	b, scanner, sent := newTestBot(t)
+	b.SetAccessList([]int64{100}, nil, nil)
+
+	send(t, b, 555, 555, "/stop")
+	if scanner.stops != 0 {
+		t.Fatalf("unauthorized /stop reached the scanner")
+	}
+	if got := sent.to("100"); len(got) != 1 || !strings.Contains(got[0], "/approve 555") {
+		t.Fatalf("admin was not asked to approve: %v", got)
+	}
+	if ids := b.snapshotChats(); len(ids) != 0 {
+		t.Fatalf("pending chat must not receive notifications: %v", ids)
+	}
+
+	send(t, b, 555, 555, "/approve 555 operator")
+	if got := sent.to("555"); len(got) != 2 || !strings.Contains(got[1], "Ruxsat yo'q") {
+		t.Fatalf("pending chat cannot approve itself, got %v", got)
+	}
+
+	send(t, b, 100, 100, "/approve 555 viewer")
+	send(t, b, 555, 555, "/approve 555 admin")
+	if got := sent.to("555"); !strings.Contains(got[len(got)-1], "admin roli kerak") {
+		t.Fatalf("viewer /approve should be rejected, got %v", got)
+	}
+	send(t, b, 555, 555, "/stop")
+	if scanner.stops != 0 {
+		t.Fatalf("viewer must not stop the scanner")
+	}
+
+	send(t, b, 100, 100, "/approve 555 operator")
+	send(t, b, 555, 555, "/stop")
+	if scanner.stops != 1 {
+		t.Fatalf("operator /stop should reach the scanner, stops=%d", scanner.stops)
+	}
+
+	send(t, b, 100, 100, "/revoke 555")
+	send(t, b, 555, 555, "/stop")
+	if scanner.stops != 1 {
+		t.Fatalf("revoked chat still controls the scanner")
+	}
+}
+
+func TestAccessListEmptyKeepsLegacyBehaviour(t *testing.T) {
+	b, scanner, _ := newTestBot(t)
+	b.SetAccessList(nil, nil, nil)
+
+	send(t, b, 777, 777, "/stop")
+	if scanner.stops != 1 {
+		t.Fatalf("open bot should accept /stop, stops=%d", scanner.stops)
+	}
+	if ids := b.snapshotChats(); len(ids) != 1 || ids[0] != 777 {
+		t.Fatalf("chat should be registered for notifications: %v", ids)
+	}
+}
+
+func TestLegacyChatStoreLoadsAsPending(t *testing.T) {
+	dir := t.TempDir()
+	store := filepath.Join(dir, "chats.json")
+	if err := os.WriteFile(store, []byte(`[42, 43]`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BOT_CHAT_STORE_FILE", store)
+	b := New("token", time.Second, 5*time.Second, nil, nil)
+
+	if len(b.chats) != 2 || b.chats[42].Role != RolePending {
+		t.Fatalf("legacy ids not migrated: %+v", b.chats)
+	}
+	b.SetAccessList(nil, []int64{43}, nil)
+	if ids := b.snapshotChats(); len(ids) != 1 || ids[0] != 43 {
+		t.Fatalf("only configured chat should be notified, got %v", ids)
+	}
+}
+
+func TestNotifyMutesCategoryPerChat(t *testing.T) {
+	b, _, sent := newTestBot(t)
+	b.SetAccessList(nil, []int64{10, 11}, nil)
+	send(t, b, 10, 10, "/status")
+	send(t, b, 11, 11, "/status")
+	b.mu.Lock()
+	for _, id := range []int64{10, 11} {
+		b.chats[id] = &chatRecord{ID: id, Role: RoleOperator}
+	}
+	b.mu.Unlock()
+
+	send(t, b, 10, 10, "/notify submits off")
+	if got := sent.to("10"); !strings.Contains(got[len(got)-1], "submits: off") {
+		t.Fatalf("expected submits muted, got %v", got)
+	}
+	if ids := b.Recipients("submits"); len(ids) != 1 || ids[0] != 11 {
+		t.Fatalf("muted chat still receives submits: %v", ids)
+	}
+	if ids := b.Recipients("errors"); len(ids) != 2 {
+		t.Fatalf("other categories should stay on: %v", ids)
+	}
+
+	send(t, b, 10, 10, "/notify all on")
+	if ids := b.Recipients("submits"); len(ids) != 2 {
+		t.Fatalf("/notify all on should unmute: %v", ids)
+	}
+}
+
+func TestReportSendsSummaryAndCSV(t *testing.T) {
+	b, _, sent := newTestBot(t)
+	store, err := history.Open(history.Config{Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Append(history.Record{Time: time.Now(), Kind: history.KindIngest, EPC: "E1", Outcome: "queued"})
+	b.SetReports(store, -1)
+
+	send(t, b, 5, 5, "/report today")
+	if got := sent.to("5"); len(got) != 1 || !strings.Contains(got[0], "O'qishlar: 1") {
+		t.Fatalf("unexpected report text: %v", got)
+	}
+	if docs := sent.method("sendDocument"); len(docs) != 1 {
+		t.Fatalf("expected one CSV document, got %d", len(docs))
+	}
+
+	send(t, b, 5, 5, "/report month")
+	if got := sent.to("5"); !strings.Contains(got[len(got)-1], "Foydalanish") {
+		t.Fatalf("unknown range should print usage, got %v", got)
+	}
+}
+
+func TestNextDigest(t *testing.T) {
+	now := time.Date(2026, 3, 10, 9, 30, 0, 0, time.Local)
+	if got := nextDigest(now, 8*time.Hour); !got.Equal(time.Date(2026, 3, 11, 8, 0, 0, 0, time.Local)) {
+		t.Fatalf("past slot should move to tomorrow, got %s", got)
+	}
+	if got := nextDigest(now, 10*time.Hour); !got.Equal(time.Date(2026, 3, 10, 10, 0, 0, 0, time.Local)) {
+		t.Fatalf("later slot should be today, got %s", got)
+	}
+}
+
+func TestPowerAndAntennasUpdateReaderConfig(t *testing.T) {
+	b, _, sent := newTestBot(t)
+	rc := reader.New(config.Config{ReaderInventory: config.Inventory{Q: 4, Session: 1, Power: 30, ScanTime: 1, Antennas: []int{1}}}, nil, nil)
+	b.SetReaderConfig(rc)
+	b.SetAccessList([]int64{100}, []int64{200}, []int64{300})
+
+	send(t, b, 300, 300, "/power 10")
+	if rc.Inventory().Power != 30 {
+		t.Fatalf("viewer must not change power")
+	}
+
+	send(t, b, 200, 200, "/power 25")
+	send(t, b, 200, 200, "/antennas 1,2")
+	if inv := rc.Inventory(); inv.Power != 25 || len(inv.Antennas) != 2 {
+		t.Fatalf("operator update not applied: %+v", inv)
+	}
+	if got := sent.to("200"); !strings.Contains(got[len(got)-1], "Antennalar: 1, 2") {
+		t.Fatalf("unexpected reply: %v", got)
+	}
+
+	send(t, b, 200, 200, "/power 40")
+	if got := sent.to("200"); !strings.Contains(got[len(got)-1], "Noto'g'ri qiymat") || rc.Inventory().Power != 25 {
+		t.Fatalf("out-of-range power must be rejected: %v", got)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"sync"
 	"time"
 
+	"new_era_go/internal/gobot/history"
 	"new_era_go/internal/gobot/service"
 )
 
@@ -28,8 +29,17 @@ type Bot struct {
 	chatsFile   string
 	notifyRetry int
 
-	mu    sync.Mutex
-	chats map[int64]struct{}
+	reports      *history.Store
+	digestAt     time.Duration
+	readerConfig ReaderConfig
+	antennas     AntennaMonitor
+
+	mu      sync.Mutex
+	chats   map[int64]*chatRecord
+	static  map[int64]Role
+	enforce bool
+	// readerSweep is set while a panel reader sweep runs; guarded by mu.
+	readerSweep bool
 }
 
 type Scanner interface {
@@ -53,7 +63,8 @@ func New(token string, requestTimeout, pollTimeout time.Duration, svc *service.S
 		scanner:     scanner,
 		chatsFile:   chatsFile,
 		notifyRetry: 2,
-		chats:       make(map[int64]struct{}),
+		chats:       make(map[int64]*chatRecord),
+		digestAt:    -1,
 	}
 	b.loadChats()
 	return b
@@ -65,6 +76,10 @@ func (b *Bot) Run(ctx context.Context) {
 		return
 	}
 
+	if b.reports != nil && b.digestAt >= 0 {
+		go b.runDigest(ctx)
+	}
+
 	var offset int64
 	for {
 		select {
@@ -105,14 +120,27 @@ func (b *Bot) Notify(text string) {
 }
 
 func (b *Bot) handleUpdate(ctx context.Context, upd update) error {
+	if upd.CallbackQuery != nil {
+		return b.handleCallback(ctx, *upd.CallbackQuery)
+	}
+
 	msg := upd.Message
 	if msg.Chat.ID == 0 || strings.TrimSpace(msg.Text) == "" {
 		return nil
 	}
 
 	cmd, args := parseCommand(msg.Text)
+	if !b.authorize(ctx, msg, cmd) {
+		return nil
+	}
+
 	switch cmd {
 	case "/start", "/help":
+		role := b.roleFor(msg.Chat.ID, msg.From.ID)
+		if role == RolePending {
+			b.registerPending(ctx, msg)
+			return b.sendMessage(ctx, msg.Chat.ID, "RFID Go bot. Ruxsat so'rovi adminlarga yuborildi, tasdiqlashni kuting.")
+		}
 		b.addChat(msg.Chat.ID)
 		text := "RFID Go bot tayyor.\n" +
 			"Buyruqlar:\n" +
@@ -121,9 +149,53 @@ func (b *Bot) handleUpdate(ctx context.Context, upd update) error {
 			"/read stop - /stop bilan bir xil\n" +
 			"/stop - reader scan ni to'xtatish\n" +
 			"/status - holat\n" +
-			"/turbo - cache ni darrov yangilash"
+			"/turbo - cache ni darrov yangilash\n" +
+			"/panel - tugmali boshqaruv paneli\n" +
+			"/notify - bildirishnoma turlari\n" +
+			"/report [today|yesterday|week] - hisobot va CSV\n" +
+			"/power [0-30] - reader quvvati (dBm)\n" +
+			"/antennas [1,2] - faol antennalar\n" +
+			"/antcheck - antennalarni tekshirish"
+		if role == RoleAdmin && b.accessEnforced() {
+			text += "\n/chats - chatlar ro'yxati\n" +
+				"/approve <chat_id> [rol] - chatni tasdiqlash\n" +
+				"/revoke <chat_id> - ruxsatni bekor qilish"
+		}
 		return b.sendMessage(ctx, msg.Chat.ID, text)
 
+	case "/panel":
+		b.addChat(msg.Chat.ID)
+		return b.handlePanel(ctx, msg.Chat.ID)
+
+	case "/notify":
+		b.addChat(msg.Chat.ID)
+		return b.handleNotify(ctx, msg.Chat.ID, args)
+
+	case "/report":
+		b.addChat(msg.Chat.ID)
+		return b.handleReport(ctx, msg.Chat.ID, args)
+
+	case "/power":
+		b.addChat(msg.Chat.ID)
+		return b.handlePower(ctx, msg.Chat.ID, args)
+
+	case "/antennas":
+		b.addChat(msg.Chat.ID)
+		return b.handleAntennas(ctx, msg.Chat.ID, args)
+
+	case "/antcheck":
+		b.addChat(msg.Chat.ID)
+		return b.handleAntennaCheck(ctx, msg.Chat.ID)
+
+	case "/chats":
+		return b.handleChats(ctx, msg.Chat.ID)
+
+	case "/approve":
+		return b.handleApprove(ctx, msg, args)
+
+	case "/revoke":
+		return b.handleRevoke(ctx, msg, args)
+
 	case "/scan":
 		return b.handleScanStart(ctx, msg.Chat.ID, "telegram_scan")
 
@@ -144,21 +216,14 @@ func (b *Bot) handleUpdate(ctx context.Context, upd update) error {
 
 	case "/status":
 		b.addChat(msg.Chat.ID)
-		text := b.svc.StatusText()
-		if b.scanner != nil {
-			text += "\n\nReader:\n" + b.scanner.StatusText()
-		}
-		return b.sendMessage(ctx, msg.Chat.ID, text)
+		return b.sendMessage(ctx, msg.Chat.ID, b.statusText())
 
 	case "/turbo":
 		b.addChat(msg.Chat.ID)
 		if err := b.sendMessage(ctx, msg.Chat.ID, "Turbo rejim: ERPNext dan cache yangilanmoqda..."); err != nil {
 			return err
 		}
-		if err := b.svc.RefreshCache(ctx, "telegram_turbo", false); err != nil {
-			return b.sendMessage(ctx, msg.Chat.ID, "Turbo xato: "+err.Error())
-		}
-		return b.sendMessage(ctx, msg.Chat.ID, "Turbo tayyor: cache yangilandi.")
+		return b.sendMessage(ctx, msg.Chat.ID, b.turbo(ctx, "telegram_turbo"))
 	}
 
 	return nil
@@ -166,31 +231,61 @@ func (b *Bot) handleUpdate(ctx context.Context, upd update) error {
 
 func (b *Bot) handleScanStart(ctx context.Context, chatID int64, reason string) error {
 	b.addChat(chatID)
+	warn, result := b.startScan(ctx, reason)
+	if warn != "" {
+		_ = b.sendMessage(ctx, chatID, warn)
+	}
+	return b.sendMessage(ctx, chatID, result)
+}
+
+func (b *Bot) handleScanStop(ctx context.Context, chatID int64, reason string) error {
+	b.addChat(chatID)
+	return b.sendMessage(ctx, chatID, b.stopScan(reason))
+}
+
+// startScan refreshes the cache and starts scanning. warn is set when the
+// refresh failed but scanning went ahead anyway.
+func (b *Bot) startScan(ctx context.Context, reason string) (warn, result string) {
 	if err := b.svc.RefreshCache(ctx, reason, false); err != nil {
-		_ = b.sendMessage(ctx, chatID, "Ogohlantirish: cache refresh xato: "+err.Error())
+		warn = "Ogohlantirish: cache refresh xato: " + err.Error()
 	}
 	replay := b.svc.SetScanActive(true, reason)
 	if b.scanner != nil {
 		if err := b.scanner.Start(ctx); err != nil {
-			return b.sendMessage(ctx, chatID, fmt.Sprintf("Scan active, lekin reader start xato: %v", err))
+			return warn, fmt.Sprintf("Scan active, lekin reader start xato: %v", err)
 		}
 	}
-	return b.sendMessage(ctx, chatID, fmt.Sprintf("Scan boshlandi. Replay navbati: %d", replay))
+	return warn, fmt.Sprintf("Scan boshlandi. Replay navbati: %d", replay)
 }
 
-func (b *Bot) handleScanStop(ctx context.Context, chatID int64, reason string) error {
-	b.addChat(chatID)
+func (b *Bot) stopScan(reason string) string {
 	if b.scanner != nil {
 		b.scanner.Stop()
 	}
 	b.svc.SetScanActive(false, reason)
-	return b.sendMessage(ctx, chatID, "Scan to'xtatildi.")
+	return "Scan to'xtatildi."
+}
+
+func (b *Bot) turbo(ctx context.Context, reason string) string {
+	if err := b.svc.RefreshCache(ctx, reason, false); err != nil {
+		return "Turbo xato: " + err.Error()
+	}
+	return "Turbo tayyor: cache yangilandi."
+}
+
+func (b *Bot) statusText() string {
+	text := b.svc.StatusText()
+	if b.scanner != nil {
+		text += "\n\nReader:\n" + b.scanner.StatusText()
+	}
+	return text
 }
 
 func (b *Bot) getUpdates(ctx context.Context, offset int64) ([]update, error) {
 	values := url.Values{}
 	values.Set("timeout", strconv.Itoa(int(b.pollTimeout/time.Second)))
 	values.Set("offset", strconv.FormatInt(offset, 10))
+	values.Set("allowed_updates", `["message","callback_query"]`)
 
 	reqURL := b.baseURL + "/getUpdates?" + values.Encode()
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
@@ -224,12 +319,20 @@ func (b *Bot) sendMessage(ctx context.Context, chatID int64, text string) error
 	form.Set("chat_id", strconv.FormatInt(chatID, 10))
 	form.Set("text", text)
 	form.Set("disable_web_page_preview", "true")
+	return b.call(ctx, "sendMessage", form, nil)
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/sendMessage", strings.NewReader(form.Encode()))
+// call posts a Bot API method and decodes its result into out when non-nil.
+func (b *Bot) call(ctx context.Context, method string, form url.Values, out any) error {
+	return b.post(ctx, method, "application/x-www-form-urlencoded", strings.NewReader(form.Encode()), out)
+}
+
+func (b *Bot) post(ctx context.Context, method, contentType string, payload io.Reader, out any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.baseURL+"/"+method, payload)
 	if err != nil {
 		return err
 	}
-	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Content-Type", contentType)
 
 	resp, err := b.http.Do(req)
 	if err != nil {
@@ -237,11 +340,35 @@ func (b *Bot) sendMessage(ctx context.Context, chatID int64, text string) error
 	}
 	defer resp.Body.Close()
 
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
 	if resp.StatusCode < 200 || resp.StatusCode > 299 {
-		body, _ := io.ReadAll(io.LimitReader(resp.Body, 2<<20))
-		return fmt.Errorf("telegram sendMessage HTTP %d: %s", resp.StatusCode, string(body))
+		apiErr := &APIError{Method: method, StatusCode: resp.StatusCode, Body: string(body)}
+		var env struct {
+			Parameters struct {
+				RetryAfter int `json:"retry_after"`
+			} `json:"parameters"`
+		}
+		if json.Unmarshal(body, &env) == nil && env.Parameters.RetryAfter > 0 {
+			apiErr.Wait = time.Duration(env.Parameters.RetryAfter) * time.Second
+		}
+		return apiErr
 	}
-	return nil
+	if out == nil {
+		return nil
+	}
+
+	var env struct {
+		OK          bool            `json:"ok"`
+		Result      json.RawMessage `json:"result"`
+		Description string          `json:"description"`
+	}
+	if err := json.Unmarshal(body, &env); err != nil {
+		return err
+	}
+	if !env.OK {
+		return fmt.Errorf("telegram %s not ok: %s", method, env.Description)
+	}
+	return json.Unmarshal(env.Result, out)
 }
 
 func (b *Bot) addChat(chatID int64) {
@@ -254,26 +381,34 @@ func (b *Bot) addChat(chatID int64) {
 		b.mu.Unlock()
 		return
 	}
-	b.chats[chatID] = struct{}{}
-	snapshot := make([]int64, 0, len(b.chats))
-	for id := range b.chats {
-		snapshot = append(snapshot, id)
-	}
+	// The stored role stays pending; access for config-listed chats comes from the config.
+	b.chats[chatID] = &chatRecord{ID: chatID, Role: RolePending, Since: time.Now()}
+	snapshot := b.snapshotRecordsLocked()
 	b.mu.Unlock()
 
 	b.persistChats(snapshot)
 }
 
+// snapshotChats returns the chats that receive notifications.
 func (b *Bot) snapshotChats() []int64 {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 	out := make([]int64, 0, len(b.chats))
 	for chatID := range b.chats {
+		if b.enforce && !b.roleLocked(chatID, chatID).atLeast(RoleViewer) {
+			continue
+		}
 		out = append(out, chatID)
 	}
 	return out
 }
 
+func (b *Bot) accessEnforced() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.enforce
+}
+
 func parseCommand(text string) (string, []string) {
 	text = strings.TrimSpace(text)
 	if text == "" {
@@ -326,17 +461,29 @@ func (b *Bot) loadChats() {
 		return
 	}
 
-	var ids []int64
-	if err := json.Unmarshal(data, &ids); err != nil {
-		log.Printf("[bot] chat store decode failed: %v", err)
-		return
+	var records []chatRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		// Older stores are a plain list of chat IDs; those chats start out pending.
+		var ids []int64
+		if legacyErr := json.Unmarshal(data, &ids); legacyErr != nil {
+			log.Printf("[bot] chat store decode failed: %v", err)
+			return
+		}
+		for _, id := range ids {
+			records = append(records, chatRecord{ID: id, Role: RolePending})
+		}
 	}
 
 	b.mu.Lock()
-	for _, id := range ids {
-		if id != 0 {
-			b.chats[id] = struct{}{}
+	for _, rec := range records {
+		if rec.ID == 0 {
+			continue
+		}
+		if rec.Role == "" {
+			rec.Role = RolePending
 		}
+		rec := rec
+		b.chats[rec.ID] = &rec
 	}
 	count := len(b.chats)
 	b.mu.Unlock()
@@ -346,7 +493,7 @@ func (b *Bot) loadChats() {
 	}
 }
 
-func (b *Bot) persistChats(ids []int64) {
+func (b *Bot) persistChats(records []chatRecord) {
 	if strings.TrimSpace(b.chatsFile) == "" {
 		return
 	}
@@ -359,14 +506,14 @@ func (b *Bot) persistChats(ids []int64) {
 		}
 	}
 
-	data, err := json.Marshal(ids)
+	data, err := json.MarshalIndent(records, "", "  ")
 	if err != nil {
 		log.Printf("[bot] chat store encode failed: %v", err)
 		return
 	}
 
 	tmp := b.chatsFile + ".tmp"
-	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
 		log.Printf("[bot] chat store write failed: %v", err)
 		return
 	}
@@ -376,21 +523,87 @@ func (b *Bot) persistChats(ids []int64) {
 	}
 }
 
+// APIError is a non-2xx Bot API response. Wait is set from retry_after on 429.
+type APIError struct {
+	Method     string
+	StatusCode int
+	Body       string
+	Wait       time.Duration
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram %s HTTP %d: %s", e.Method, e.StatusCode, e.Body)
+}
+
+// RetryAfter lets notify.Hub back off when Telegram rate limits a send.
+func (e *APIError) RetryAfter() time.Duration {
+	if e.StatusCode != http.StatusTooManyRequests {
+		return 0
+	}
+	if e.Wait <= 0 {
+		return time.Second
+	}
+	return e.Wait
+}
+
 type updatesEnvelope struct {
 	OK     bool     `json:"ok"`
 	Result []update `json:"result"`
 }
 
 type update struct {
-	UpdateID int64   `json:"update_id"`
-	Message  message `json:"message"`
+	UpdateID      int64          `json:"update_id"`
+	Message       message        `json:"message"`
+	CallbackQuery *callbackQuery `json:"callback_query,omitempty"`
+}
+
+type callbackQuery struct {
+	ID      string   `json:"id"`
+	From    user     `json:"from"`
+	Message *message `json:"message,omitempty"`
+	Data    string   `json:"data"`
 }
 
 type message struct {
+	MessageID int64 `json:"message_id"`
+
 	Text string `json:"text"`
 	Chat chat   `json:"chat"`
+	From user   `json:"from"`
 }
 
 type chat struct {
-	ID int64 `json:"id"`
+	ID        int64  `json:"id"`
+	Type      string `json:"type,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+}
+
+func (c chat) label() string {
+	switch {
+	case c.Title != "":
+		return c.Title
+	case c.Username != "":
+		return "@" + c.Username
+	case c.FirstName != "":
+		return c.FirstName
+	}
+	return strconv.FormatInt(c.ID, 10)
+}
+
+type user struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username,omitempty"`
+	FirstName string `json:"first_name,omitempty"`
+}
+
+func (u user) label() string {
+	switch {
+	case u.Username != "":
+		return "@" + u.Username
+	case u.FirstName != "":
+		return u.FirstName
+	}
+	return "-"
 }
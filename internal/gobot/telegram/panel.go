@@ -0,0 +1,274 @@
+package telegram
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ReaderSelector is implemented by scanners that can list and switch reader
+// endpoints; the panel shows reader selection only when it is available.
+// KnownReaders must answer without network I/O; DiscoverReaders may sweep
+// the LAN for many seconds.
+type ReaderSelector interface {
+	KnownReaders() []string
+	DiscoverReaders(ctx context.Context) ([]string, error)
+	SelectReader(ctx context.Context, endpoint string) error
+}
+
+const (
+	panelPrefix     = "panel:"
+	panelMaxReaders = 8
+)
+
+// panelActions maps callback actions to the command whose role they require.
+var panelActions = map[string]string{
+	"start":   "/scan",
+	"stop":    "/stop",
+	"turbo":   "/turbo",
+	"refresh": "/status",
+	"readers": "/scan",
+	"reader":  "/scan",
+	"back":    "/status",
+}
+
+type inlineButton struct {
+	Text         string `json:"text"`
+	CallbackData string `json:"callback_data"`
+}
+
+type inlineKeyboard struct {
+	InlineKeyboard [][]inlineButton `json:"inline_keyboard"`
+}
+
+func (b *Bot) mainKeyboard() inlineKeyboard {
+	rows := [][]inlineButton{
+		{{Text: "Start", CallbackData: panelPrefix + "start"}, {Text: "Stop", CallbackData: panelPrefix + "stop"}},
+		{{Text: "Turbo", CallbackData: panelPrefix + "turbo"}, {Text: "Yangilash", CallbackData: panelPrefix + "refresh"}},
+	}
+	if _, ok := b.scanner.(ReaderSelector); ok {
+		rows = append(rows, []inlineButton{{Text: "Reader tanlash", CallbackData: panelPrefix + "readers"}})
+	}
+	return inlineKeyboard{InlineKeyboard: rows}
+}
+
+func readerKeyboard(endpoints []string) inlineKeyboard {
+	rows := make([][]inlineButton, 0, len(endpoints)+1)
+	for i, endpoint := range endpoints {
+		if i >= panelMaxReaders {
+			break
+		}
+		rows = append(rows, []inlineButton{{Text: endpoint, CallbackData: panelPrefix + "reader:" + endpoint}})
+	}
+	rows = append(rows, []inlineButton{
+		{Text: "Avto", CallbackData: panelPrefix + "reader:"},
+		{Text: "Orqaga", CallbackData: panelPrefix + "back"},
+	})
+	return inlineKeyboard{InlineKeyboard: rows}
+}
+
+func (b *Bot) panelText(note string) string {
+	text := "RFID panel\n\n" + b.statusText()
+	if note != "" {
+		text += "\n\n" + note
+	}
+	// The timestamp keeps every edit distinct, so Telegram never rejects it as unchanged.
+	return text + "\n\nYangilandi: " + time.Now().Format("15:04:05")
+}
+
+// handlePanel posts a fresh panel, pins it and removes the previous one so each chat has a single panel.
+func (b *Bot) handlePanel(ctx context.Context, chatID int64) error {
+	var sent message
+	if err := b.sendWithKeyboard(ctx, chatID, b.panelText(""), b.mainKeyboard(), &sent); err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	var old int64
+	if rec, ok := b.chats[chatID]; ok {
+		old = rec.PanelMessageID
+		rec.PanelMessageID = sent.MessageID
+	}
+	snapshot := b.snapshotRecordsLocked()
+	b.mu.Unlock()
+	b.persistChats(snapshot)
+
+	form := url.Values{}
+	form.Set("chat_id", strconv.FormatInt(chatID, 10))
+	form.Set("message_id", strconv.FormatInt(sent.MessageID, 10))
+	form.Set("disable_notification", "true")
+	if err := b.call(ctx, "pinChatMessage", form, nil); err != nil {
+		log.Printf("[bot] telegram pin panel chat=%d failed: %v", chatID, err)
+	}
+	if old != 0 && old != sent.MessageID {
+		del := url.Values{}
+		del.Set("chat_id", strconv.FormatInt(chatID, 10))
+		del.Set("message_id", strconv.FormatInt(old, 10))
+		_ = b.call(ctx, "deleteMessage", del, nil)
+	}
+	return nil
+}
+
+func (b *Bot) handleCallback(ctx context.Context, cq callbackQuery) error {
+	if cq.Message == nil || !strings.HasPrefix(cq.Data, panelPrefix) {
+		return b.answerCallback(ctx, cq.ID, "", false)
+	}
+	chatID := cq.Message.Chat.ID
+	action, arg, _ := strings.Cut(strings.TrimPrefix(cq.Data, panelPrefix), ":")
+
+	cmd, ok := panelActions[action]
+	if !ok {
+		return b.answerCallback(ctx, cq.ID, "Noma'lum amal", false)
+	}
+	if role, need, allowed := b.checkRole(chatID, cq.From, cmd); !allowed {
+		text := "Ruxsat yo'q: " + string(need) + " roli kerak."
+		if role == RolePending {
+			text = "Ruxsat yo'q. Admin tasdiqlashini kuting."
+		}
+		return b.answerCallback(ctx, cq.ID, text, true)
+	}
+
+	keyboard := b.mainKeyboard()
+	var toast, note string
+	switch action {
+	case "start":
+		warn, result := b.startScan(ctx, "telegram_panel_start")
+		toast, note = result, warn
+	case "stop":
+		toast = b.stopScan("telegram_panel_stop")
+	case "turbo":
+		toast = b.turbo(ctx, "telegram_panel_turbo")
+	case "refresh", "back":
+		toast = "Yangilandi"
+	case "readers":
+		sel, ok := b.scanner.(ReaderSelector)
+		if !ok {
+			return b.answerCallback(ctx, cq.ID, "Reader tanlash mavjud emas", true)
+		}
+		// Known readers are listed at once; the LAN sweep runs in the
+		// background so the update loop keeps serving other chats.
+		_ = b.answerCallback(ctx, cq.ID, "Readerlar qidirilmoqda...", false)
+		known := sel.KnownReaders()
+		if b.beginReaderSweep() {
+			go b.sweepReaders(ctx, sel, chatID, cq.Message.MessageID, known)
+		}
+		note = "Ma'lum readerlar. LAN qidiruvi davom etmoqda..."
+		return b.editPanel(ctx, chatID, cq.Message.MessageID, b.panelText(note), readerKeyboard(known))
+	case "reader":
+		sel, ok := b.scanner.(ReaderSelector)
+		if !ok {
+			return b.answerCallback(ctx, cq.ID, "Reader tanlash mavjud emas", true)
+		}
+		if err := sel.SelectReader(ctx, arg); err != nil {
+			toast = "Reader xato: " + err.Error()
+		} else if arg == "" {
+			toast = "Reader: avto"
+		} else {
+			toast = "Reader: " + arg
+		}
+	}
+
+	_ = b.answerCallback(ctx, cq.ID, toast, false)
+	return b.editPanel(ctx, chatID, cq.Message.MessageID, b.panelText(note), keyboard)
+}
+
+// beginReaderSweep reports whether the caller should start a LAN sweep; only
+// one runs at a time.
+func (b *Bot) beginReaderSweep() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.readerSweep {
+		return false
+	}
+	b.readerSweep = true
+	return true
+}
+
+// sweepReaders lists the known readers plus every reader the sweep finds on
+// the panel message.
+func (b *Bot) sweepReaders(ctx context.Context, sel ReaderSelector, chatID, messageID int64, known []string) {
+	found, err := sel.DiscoverReaders(ctx)
+	b.mu.Lock()
+	b.readerSweep = false
+	b.mu.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+
+	endpoints := append([]string(nil), known...)
+	for _, endpoint := range found {
+		if !slices.Contains(endpoints, endpoint) {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	var note string
+	switch {
+	case err != nil:
+		note = "Reader qidiruv xato: " + err.Error()
+	case len(endpoints) == 0:
+		note = "Reader topilmadi."
+	default:
+		note = "Readerni tanlang:"
+	}
+	if err := b.editPanel(ctx, chatID, messageID, b.panelText(note), readerKeyboard(endpoints)); err != nil {
+		log.Printf("[bot] telegram reader list chat=%d failed: %v", chatID, err)
+	}
+}
+
+func (b *Bot) editPanel(ctx context.Context, chatID, messageID int64, text string, keyboard inlineKeyboard) error {
+	markup, _ := json.Marshal(keyboard)
+	form := url.Values{}
+	form.Set("chat_id", strconv.FormatInt(chatID, 10))
+	form.Set("message_id", strconv.FormatInt(messageID, 10))
+	form.Set("text", text)
+	form.Set("disable_web_page_preview", "true")
+	form.Set("reply_markup", string(markup))
+	err := b.call(ctx, "editMessageText", form, nil)
+	if err != nil && strings.Contains(err.Error(), "message is not modified") {
+		return nil
+	}
+	return err
+}
+
+func (b *Bot) sendWithKeyboard(ctx context.Context, chatID int64, text string, keyboard inlineKeyboard, out *message) error {
+	markup, _ := json.Marshal(keyboard)
+	form := url.Values{}
+	form.Set("chat_id", strconv.FormatInt(chatID, 10))
+	form.Set("text", text)
+	form.Set("disable_web_page_preview", "true")
+	form.Set("reply_markup", string(markup))
+	return b.call(ctx, "sendMessage", form, out)
+}
+
+func (b *Bot) answerCallback(ctx context.Context, id, text string, alert bool) error {
+	if id == "" {
+		return nil
+	}
+	form := url.Values{}
+	form.Set("callback_query_id", id)
+	if text != "" {
+		form.Set("text", trimCallbackText(text))
+	}
+	if alert {
+		form.Set("show_alert", "true")
+	}
+	if err := b.call(ctx, "answerCallbackQuery", form, nil); err != nil {
+		return fmt.Errorf("answer callback: %w", err)
+	}
+	return nil
+}
+
+// trimCallbackText keeps callback answers within Telegram's 200 character limit.
+func trimCallbackText(text string) string {
+	r := []rune(text)
+	if len(r) <= 200 {
+		return text
+	}
+	return string(r[:197]) + "..."
+}
@@ -0,0 +1,123 @@
+package telegram
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPanelPinsAndEditsInPlace(t *testing.T) {
+	b, scanner, sent := newTestBot(t)
+	b.SetAccessList([]int64{100}, nil, []int64{200})
+
+	send(t, b, 100, 100, "/panel")
+	msgs := sent.method("sendMessage")
+	if len(msgs) != 1 || !strings.Contains(msgs[0].Form.Get("reply_markup"), "panel:stop") {
+		t.Fatalf("panel should be sent with an inline keyboard: %+v", msgs)
+	}
+	pins := sent.method("pinChatMessage")
+	if len(pins) != 1 {
+		t.Fatalf("panel was not pinned")
+	}
+	panelID := pins[0].Form.Get("message_id")
+
+	cq := callbackQuery{ID: "cb1", From: user{ID: 100}, Data: "panel:stop", Message: &message{Chat: chat{ID: 100}}}
+	cq.Message.MessageID = b.chats[100].PanelMessageID
+	if err := b.handleUpdate(context.Background(), update{CallbackQuery: &cq}); err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if scanner.stops != 1 {
+		t.Fatalf("stop button did not stop the scanner")
+	}
+	edits := sent.method("editMessageText")
+	if len(edits) != 1 || edits[0].Form.Get("message_id") != panelID {
+		t.Fatalf("panel should be edited in place, edits=%+v", edits)
+	}
+	if len(sent.method("sendMessage")) != 1 {
+		t.Fatalf("callback must not post new messages")
+	}
+
+	send(t, b, 100, 100, "/panel")
+	if dels := sent.method("deleteMessage"); len(dels) != 1 || dels[0].Form.Get("message_id") != panelID {
+		t.Fatalf("old panel should be deleted, got %+v", dels)
+	}
+}
+
+func TestPanelCallbackRespectsRoles(t *testing.T) {
+	b, scanner, sent := newTestBot(t)
+	b.SetAccessList([]int64{100}, nil, []int64{200})
+
+	cq := callbackQuery{ID: "cb2", From: user{ID: 200}, Data: "panel:stop", Message: &message{MessageID: 7, Chat: chat{ID: 200}}}
+	if err := b.handleUpdate(context.Background(), update{CallbackQuery: &cq}); err != nil {
+		t.Fatalf("callback: %v", err)
+	}
+	if scanner.stops != 0 {
+		t.Fatalf("viewer stopped the scanner from the panel")
+	}
+	answers := sent.method("answerCallbackQuery")
+	if len(answers) != 1 || answers[0].Form.Get("show_alert") != "true" {
+		t.Fatalf("denied callback should alert, got %+v", answers)
+	}
+	if len(sent.method("editMessageText")) != 0 {
+		t.Fatalf("denied callback must not edit the panel")
+	}
+}
+
+// slowSelector is a scanner whose LAN sweep blocks until release is closed.
+type slowSelector struct {
+	fakeScanner
+	release chan struct{}
+}
+
+func (s *slowSelector) KnownReaders() []string { return []string{"10.0.0.5:6000"} }
+
+func (s *slowSelector) DiscoverReaders(ctx context.Context) ([]string, error) {
+	select {
+	case <-s.release:
+		return []string{"10.0.0.7:6000", "10.0.0.5:6000"}, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (s *slowSelector) SelectReader(context.Context, string) error { return nil }
+
+func TestPanelListsKnownReadersWithoutWaitingForSweep(t *testing.T) {
+	b, _, sent := newTestBot(t)
+	b.SetAccessList([]int64{100}, nil, nil)
+	sel := &slowSelector{release: make(chan struct{})}
+	b.scanner = sel
+
+	cq := callbackQuery{ID: "cb3", From: user{ID: 100}, Data: "panel:readers", Message: &message{MessageID: 9, Chat: chat{ID: 100}}}
+	done := make(chan error, 1)
+	go func() { done <- b.handleUpdate(context.Background(), update{CallbackQuery: &cq}) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("callback: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("callback blocked on the LAN sweep")
+	}
+	if len(sent.method("answerCallbackQuery")) != 1 {
+		t.Fatal("callback was not answered before the sweep")
+	}
+	edits := sent.method("editMessageText")
+	if len(edits) != 1 || !strings.Contains(edits[0].Form.Get("reply_markup"), "10.0.0.5:6000") {
+		t.Fatalf("known readers should be listed at once, edits=%+v", edits)
+	}
+
+	close(sel.release)
+	deadline := time.Now().Add(2 * time.Second)
+	for len(sent.method("editMessageText")) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatal("panel was not updated after the sweep")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	markup := sent.method("editMessageText")[1].Form.Get("reply_markup")
+	if strings.Count(markup, "10.0.0.5:6000") != 2 || !strings.Contains(markup, "10.0.0.7:6000") {
+		t.Fatalf("swept list should add new readers once: %s", markup)
+	}
+}
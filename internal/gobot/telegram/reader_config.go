@@ -0,0 +1,109 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"new_era_go/internal/gobot/config"
+	"new_era_go/sdk"
+)
+
+// ReaderConfig reads and changes the reader's inventory tuning.
+type ReaderConfig interface {
+	Inventory() config.Inventory
+	UpdateInventory(ctx context.Context, u config.InventoryUpdate) (config.Inventory, error)
+}
+
+// SetReaderConfig enables /power and /antennas.
+func (b *Bot) SetReaderConfig(rc ReaderConfig) {
+	b.readerConfig = rc
+}
+
+// handlePower shows the reader tuning or sets the output power: /power [dBm].
+func (b *Bot) handlePower(ctx context.Context, chatID int64, args []string) error {
+	if b.readerConfig == nil {
+		return b.sendMessage(ctx, chatID, "SDK reader yoqilmagan.")
+	}
+	if len(args) == 0 {
+		return b.sendMessage(ctx, chatID, inventoryText(b.readerConfig.Inventory()))
+	}
+	power, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[0]), "dbm"))
+	if err != nil {
+		return b.sendMessage(ctx, chatID, "Foydalanish: /power <0-30>")
+	}
+	return b.updateInventory(ctx, chatID, config.InventoryUpdate{Power: &power})
+}
+
+// handleAntennas shows the reader tuning or sets the active ports: /antennas [1,2].
+func (b *Bot) handleAntennas(ctx context.Context, chatID int64, args []string) error {
+	if b.readerConfig == nil {
+		return b.sendMessage(ctx, chatID, "SDK reader yoqilmagan.")
+	}
+	if len(args) == 0 {
+		return b.sendMessage(ctx, chatID, inventoryText(b.readerConfig.Inventory()))
+	}
+	ports, err := config.ParseAntennas(strings.Join(args, ","))
+	if err != nil {
+		return b.sendMessage(ctx, chatID, "Foydalanish: /antennas 1,2 (portlar 1-8)")
+	}
+	return b.updateInventory(ctx, chatID, config.InventoryUpdate{Antennas: ports})
+}
+
+func (b *Bot) updateInventory(ctx context.Context, chatID int64, u config.InventoryUpdate) error {
+	inv, err := b.readerConfig.UpdateInventory(ctx, u)
+	var verr *config.ValidationError
+	switch {
+	case errors.As(err, &verr):
+		return b.sendMessage(ctx, chatID, "Noto'g'ri qiymat: "+strings.Join(verr.Problems, "; "))
+	case err != nil:
+		return b.sendMessage(ctx, chatID, "Saqlandi, lekin reader qabul qilmadi: "+err.Error()+"\n\n"+inventoryText(inv))
+	}
+	return b.sendMessage(ctx, chatID, "Reader sozlamalari yangilandi.\n\n"+inventoryText(inv))
+}
+
+func inventoryText(inv config.Inventory) string {
+	ports := make([]string, len(inv.Antennas))
+	for i, port := range inv.Antennas {
+		ports[i] = strconv.Itoa(port)
+	}
+	return fmt.Sprintf("Reader sozlamalari:\nQuvvat: %d dBm\nAntennalar: %s\nQ: %d, session: %d, scan time: %d, poll: %s",
+		inv.Power, strings.Join(ports, ", "), inv.Q, inv.Session, inv.ScanTime, inv.Poll)
+}
+
+// AntennaMonitor runs the reader's antenna check.
+type AntennaMonitor interface {
+	DiagnoseAntennas(ctx context.Context) ([]sdk.AntennaDiagnosis, error)
+}
+
+// SetAntennaMonitor enables /antcheck.
+func (b *Bot) SetAntennaMonitor(am AntennaMonitor) {
+	b.antennas = am
+}
+
+func (b *Bot) handleAntennaCheck(ctx context.Context, chatID int64) error {
+	if b.antennas == nil {
+		return b.sendMessage(ctx, chatID, "SDK reader yoqilmagan.")
+	}
+	if err := b.sendMessage(ctx, chatID, "Antennalar tekshirilmoqda..."); err != nil {
+		return err
+	}
+	results, err := b.antennas.DiagnoseAntennas(ctx)
+	if err != nil {
+		return b.sendMessage(ctx, chatID, "Antenna tekshiruvi bajarilmadi: "+err.Error())
+	}
+
+	labels := map[sdk.AntennaState]string{
+		sdk.AntennaOK:           "ishlayapti",
+		sdk.AntennaSilent:       "jim (teg o'qilmadi)",
+		sdk.AntennaDisconnected: "ulanmagan (antenna xatosi)",
+	}
+	var sb strings.Builder
+	sb.WriteString("Antenna tekshiruvi:")
+	for _, d := range results {
+		fmt.Fprintf(&sb, "\nAntenna %d: %s, o'qishlar=%d, xatolar=%d", d.Port, labels[d.State], d.Reads, d.Errors)
+	}
+	return b.sendMessage(ctx, chatID, sb.String())
+}
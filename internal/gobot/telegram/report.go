@@ -0,0 +1,139 @@
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"mime/multipart"
+	"strconv"
+	"time"
+
+	"new_era_go/internal/gobot/history"
+	"new_era_go/internal/gobot/report"
+)
+
+// SetReports enables /report from the history store and, when dailyAt >= 0,
+// a digest of the previous day sent to admin chats at that offset from
+// local midnight.
+func (b *Bot) SetReports(store *history.Store, dailyAt time.Duration) {
+	b.reports = store
+	b.digestAt = dailyAt
+}
+
+func (b *Bot) handleReport(ctx context.Context, chatID int64, args []string) error {
+	if b.reports == nil {
+		return b.sendMessage(ctx, chatID, "Hisobotlar yoqilmagan.")
+	}
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	label, from, to, ok := report.Range(name, time.Now())
+	if !ok {
+		return b.sendMessage(ctx, chatID, "Foydalanish: /report [today|yesterday|week]")
+	}
+	sum, err := report.Summarize(b.reports, label, from, to)
+	if err != nil {
+		return b.sendMessage(ctx, chatID, "Hisobot tayyorlanmadi: "+err.Error())
+	}
+	return b.sendReport(ctx, chatID, sum)
+}
+
+// sendReport sends the summary text followed by the per-EPC CSV.
+func (b *Bot) sendReport(ctx context.Context, chatID int64, sum report.Summary) error {
+	if err := b.sendMessage(ctx, chatID, sum.Text()); err != nil {
+		return err
+	}
+	if len(sum.EPCs) == 0 {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := sum.WriteCSV(&buf); err != nil {
+		return err
+	}
+	name := "rfid_report_" + sum.From.Format("2006-01-02")
+	if days := int(sum.To.Sub(sum.From).Round(24*time.Hour) / (24 * time.Hour)); days > 1 {
+		name += "_" + strconv.Itoa(days) + "d"
+	}
+	return b.sendDocument(ctx, chatID, name+".csv", buf.Bytes(), "EPC ro'yxati: "+strconv.Itoa(len(sum.EPCs)))
+}
+
+func (b *Bot) sendDocument(ctx context.Context, chatID int64, filename string, data []byte, caption string) error {
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	_ = mw.WriteField("chat_id", strconv.FormatInt(chatID, 10))
+	if caption != "" {
+		_ = mw.WriteField("caption", caption)
+	}
+	part, err := mw.CreateFormFile("document", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := mw.Close(); err != nil {
+		return err
+	}
+	return b.post(ctx, "sendDocument", mw.FormDataContentType(), &body, nil)
+}
+
+// runDigest sends yesterday's report every day at digestAt local time.
+func (b *Bot) runDigest(ctx context.Context) {
+	for {
+		next := nextDigest(time.Now(), b.digestAt)
+		log.Printf("[bot] next daily report at %s", next.Format(time.RFC3339))
+		if !sleepUntil(ctx, next) {
+			return
+		}
+		b.sendDigest(ctx)
+	}
+}
+
+func (b *Bot) sendDigest(ctx context.Context) {
+	label, from, to, _ := report.Range("yesterday", time.Now())
+	sum, err := report.Summarize(b.reports, label, from, to)
+	if err != nil {
+		log.Printf("[bot] daily report failed: %v", err)
+		return
+	}
+
+	b.mu.Lock()
+	chatIDs := b.adminChatsLocked()
+	enforce := b.enforce
+	b.mu.Unlock()
+	if !enforce {
+		chatIDs = b.snapshotChats()
+	}
+	if len(chatIDs) == 0 {
+		log.Printf("[bot] daily report skipped: no admin chats")
+		return
+	}
+	for _, chatID := range chatIDs {
+		if err := b.sendReport(ctx, chatID, sum); err != nil {
+			log.Printf("[bot] daily report chat=%d failed: %v", chatID, err)
+		}
+	}
+}
+
+// nextDigest returns the first local time after now that is at offset past midnight.
+func nextDigest(now time.Time, offset time.Duration) time.Time {
+	local := now.In(time.Local)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, time.Local)
+	next := midnight.Add(offset)
+	if !next.After(local) {
+		next = time.Date(local.Year(), local.Month(), local.Day()+1, 0, 0, 0, 0, time.Local).Add(offset)
+	}
+	return next
+}
+
+func sleepUntil(ctx context.Context, at time.Time) bool {
+	t := time.NewTimer(time.Until(at))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
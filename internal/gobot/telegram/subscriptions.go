@@ -0,0 +1,88 @@
+package telegram
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"new_era_go/internal/gobot/notify"
+)
+
+// Recipients implements notify.Sender: chats allowed to receive notifications
+// that have not muted category.
+func (b *Bot) Recipients(category string) []int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	out := make([]int64, 0, len(b.chats))
+	for chatID, rec := range b.chats {
+		if b.enforce && !b.roleLocked(chatID, chatID).atLeast(RoleViewer) {
+			continue
+		}
+		if slices.Contains(rec.Muted, category) {
+			continue
+		}
+		out = append(out, chatID)
+	}
+	return out
+}
+
+// SendNotification implements notify.Sender.
+func (b *Bot) SendNotification(ctx context.Context, chatID int64, text string) error {
+	return b.sendMessage(ctx, chatID, text)
+}
+
+// handleNotify shows or changes this chat's categories:
+// /notify, /notify <category|all> on|off.
+func (b *Bot) handleNotify(ctx context.Context, chatID int64, args []string) error {
+	if len(args) >= 2 {
+		category := strings.ToLower(args[0])
+		var on bool
+		switch strings.ToLower(args[1]) {
+		case "on", "1":
+			on = true
+		case "off", "0":
+			on = false
+		default:
+			return b.sendMessage(ctx, chatID, "Foydalanish: /notify <tur|all> on|off")
+		}
+
+		targets := []string{category}
+		if category == "all" {
+			targets = notify.Categories
+		} else if !notify.ValidCategory(category) {
+			return b.sendMessage(ctx, chatID, "Noma'lum tur: "+category+"\nTurlar: "+strings.Join(notify.Categories, ", "))
+		}
+
+		b.mu.Lock()
+		if rec, ok := b.chats[chatID]; ok {
+			for _, c := range targets {
+				rec.Muted = slices.DeleteFunc(rec.Muted, func(m string) bool { return m == c })
+				if !on {
+					rec.Muted = append(rec.Muted, c)
+				}
+			}
+		}
+		snapshot := b.snapshotRecordsLocked()
+		b.mu.Unlock()
+		b.persistChats(snapshot)
+	}
+
+	b.mu.Lock()
+	var muted []string
+	if rec, ok := b.chats[chatID]; ok {
+		muted = append(muted, rec.Muted...)
+	}
+	b.mu.Unlock()
+
+	var sb strings.Builder
+	sb.WriteString("Bildirishnomalar:\n")
+	for _, c := range notify.Categories {
+		state := "on"
+		if slices.Contains(muted, c) {
+			state = "off"
+		}
+		sb.WriteString(c + ": " + state + "\n")
+	}
+	sb.WriteString("\nO'zgartirish: /notify <tur|all> on|off")
+	return b.sendMessage(ctx, chatID, sb.String())
+}
@@ -0,0 +1,116 @@
+package reader18
+
+import (
+	"fmt"
+)
+
+// ReaderInfo is the decoded 0x21 response.
+type ReaderInfo struct {
+	Major, Minor byte // firmware version
+	Type         byte
+	Protocols    byte // supported protocol bitmask (Tr_Type)
+	Band         byte
+	MinChannel   byte
+	MaxChannel   byte
+	Power        byte
+	ScanTime     byte // x100 ms
+}
+
+// ParseReaderInfo decodes a GetReaderInfo response.
+// Data format: Version(2), Type(1), TrType(1), MaxFre(1), MinFre(1), Power(1), ScanTime(1).
+func ParseReaderInfo(frame Frame) (ReaderInfo, error) {
+	if frame.Command != CmdGetReaderInfo {
+		return ReaderInfo{}, fmt.Errorf("not reader-info frame")
+	}
+	if frame.Status != StatusSuccess {
+		return ReaderInfo{}, fmt.Errorf("reader-info status 0x%02X", frame.Status)
+	}
+	if len(frame.Data) < 8 {
+		return ReaderInfo{}, fmt.Errorf("reader-info payload too short")
+	}
+	d := frame.Data
+	band, minCh, maxCh := decodeRegion(d[4], d[5])
+	return ReaderInfo{
+		Major:      d[0],
+		Minor:      d[1],
+		Type:       d[2],
+		Protocols:  d[3],
+		Band:       band,
+		MinChannel: minCh,
+		MaxChannel: maxCh,
+		Power:      d[6],
+		ScanTime:   d[7],
+	}, nil
+}
+
+// FrequencyBand is one channel plan the reader firmware knows.
+type FrequencyBand struct {
+	ID       byte
+	Name     string
+	StartKHz int
+	StepKHz  int
+	Channels int
+}
+
+// ChannelKHz returns the centre frequency of channel n.
+func (b FrequencyBand) ChannelKHz(n int) int {
+	return b.StartKHz + n*b.StepKHz
+}
+
+// Bands lists the firmware channel plans, in the order BandForRange prefers
+// them on a tie.
+var Bands = []FrequencyBand{
+	{ID: 2, Name: "US", StartKHz: 902_750, StepKHz: 500, Channels: 50},
+	{ID: 4, Name: "EU", StartKHz: 865_100, StepKHz: 200, Channels: 15},
+	{ID: 3, Name: "Korea", StartKHz: 917_100, StepKHz: 200, Channels: 32},
+	{ID: 1, Name: "China 920", StartKHz: 920_125, StepKHz: 250, Channels: 20},
+	{ID: 8, Name: "China 840", StartKHz: 840_125, StepKHz: 250, Channels: 20},
+}
+
+// LookupBand returns the channel plan with the given ID.
+func LookupBand(id byte) (FrequencyBand, bool) {
+	for _, b := range Bands {
+		if b.ID == id {
+			return b, true
+		}
+	}
+	return FrequencyBand{}, false
+}
+
+// BandForRange picks the channel plan with the most channels inside
+// [lowKHz, highKHz] and returns it with the first and last such channel.
+func BandForRange(lowKHz, highKHz int) (FrequencyBand, byte, byte, error) {
+	var best FrequencyBand
+	bestMin, bestCount := 0, 0
+	for _, b := range Bands {
+		first, count := -1, 0
+		for n := 0; n < b.Channels; n++ {
+			if f := b.ChannelKHz(n); f >= lowKHz && f <= highKHz {
+				if first < 0 {
+					first = n
+				}
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestMin, bestCount = b, first, count
+		}
+	}
+	if bestCount == 0 {
+		return FrequencyBand{}, 0, 0, fmt.Errorf("no reader channel between %d and %d kHz", lowKHz, highKHz)
+	}
+	return best, byte(bestMin), byte(bestMin + bestCount - 1), nil
+}
+
+// SetRegionCommand restricts the reader to channels minCh..maxCh of band (0x22).
+// The band ID is split over the top two bits of MaxFre and MinFre.
+func SetRegionCommand(address, band, minCh, maxCh byte) []byte {
+	high := (band>>2)<<6 | maxCh&0x3F
+	low := (band&0x03)<<6 | minCh&0x3F
+	return SetFrequencyRangeCommand(address, high, low)
+}
+
+func decodeRegion(maxFre, minFre byte) (band, minCh, maxCh byte) {
+	band = (maxFre>>6)<<2 | minFre>>6
+	return band, minFre & 0x3F, maxFre & 0x3F
+}
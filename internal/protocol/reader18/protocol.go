@@ -7,6 +7,8 @@ import (
 // Command codes from UHFReader18 style protocol.
 const (
 	CmdInventory           byte = 0x01
+	CmdReadData            byte = 0x02
+	CmdWriteEPC            byte = 0x04
 	CmdInventorySingle     byte = 0x0F
 	CmdGetReaderInfo       byte = 0x21
 	CmdSetRegion           byte = 0x22
@@ -250,6 +252,19 @@ func ParseInventoryG2Tags(frame Frame) ([]InventoryG2Tag, error) {
 	return tags, nil
 }
 
+// ReplyAntenna returns the port named by the antenna byte of an inventory
+// reply, or 0 when the reply carries none (error replies are often empty).
+func ReplyAntenna(frame Frame) int {
+	if frame.Command != CmdInventory || len(frame.Data) == 0 {
+		return 0
+	}
+	mask := frame.Data[0]
+	if mask == 0 || mask&(mask-1) != 0 {
+		return 0
+	}
+	return antennaIDFromMask(mask)
+}
+
 // ParseSingleInventoryResult decodes single inventory payload.
 // Expected payload layout: Ant(1), Count(1), EPCLen(1), EPC(n)
 func ParseSingleInventoryResult(frame Frame) (SingleInventoryResult, error) {
@@ -2,6 +2,7 @@ package reader18
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -180,3 +181,80 @@ func TestParseSingleInventoryResultStatusSuccess(t *testing.T) {
 		t.Fatalf("unexpected tag count: got %d", result.TagCount)
 	}
 }
+
+func TestReadDataCommandRoundTrip(t *testing.T) {
+	packet, err := ReadDataCommand(0x00, []byte{0xE2, 0x00, 0x12, 0x34}, BankTID, 0, 6, 0x01020304)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !VerifyPacket(packet) {
+		t.Fatalf("bad crc: % X", packet)
+	}
+	want := []byte{0x02, 0xE2, 0x00, 0x12, 0x34, BankTID, 0x00, 0x06, 0x01, 0x02, 0x03, 0x04}
+	if got := packet[3 : len(packet)-2]; !bytes.Equal(got, want) {
+		t.Fatalf("payload = % X, want % X", got, want)
+	}
+	if _, err := ReadDataCommand(0x00, []byte{0xE2}, BankEPC, 0, 1, 0); err == nil {
+		t.Fatal("odd-length epc accepted")
+	}
+
+	words, err := ParseReadData(Frame{Command: CmdReadData, Status: StatusSuccess, Data: []byte{0xAA, 0xBB}})
+	if err != nil || !bytes.Equal(words, []byte{0xAA, 0xBB}) {
+		t.Fatalf("ParseReadData = % X, %v", words, err)
+	}
+	if _, err := ParseReadData(Frame{Command: CmdReadData, Status: StatusTagError, Data: []byte{0x0B}}); err == nil || !strings.Contains(err.Error(), "0x0B") {
+		t.Fatalf("tag error not reported: %v", err)
+	}
+}
+
+func TestWriteEPCCommandPayload(t *testing.T) {
+	packet, err := WriteEPCCommand(0xFF, []byte{0x30, 0x00, 0x00, 0x01}, 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []byte{0x02, 0x00, 0x00, 0x00, 0x00, 0x30, 0x00, 0x00, 0x01}
+	if packet[2] != CmdWriteEPC || !bytes.Equal(packet[3:len(packet)-2], want) {
+		t.Fatalf("packet = % X", packet)
+	}
+}
+
+func TestParseReaderInfoDecodesBand(t *testing.T) {
+	// EU (band 4): MaxFre=0x40|14, MinFre=0x00|0.
+	info, err := ParseReaderInfo(Frame{
+		Command: CmdGetReaderInfo,
+		Status:  StatusSuccess,
+		Data:    []byte{0x03, 0x01, 0x09, 0x03, 0x4E, 0x00, 0x1E, 0x0A},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if info.Major != 3 || info.Minor != 1 || info.Power != 30 || info.ScanTime != 10 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.Band != 4 || info.MinChannel != 0 || info.MaxChannel != 14 {
+		t.Fatalf("unexpected band: %+v", info)
+	}
+}
+
+func TestBandForRangeAndSetRegionCommand(t *testing.T) {
+	band, minCh, maxCh, err := BandForRange(902_000, 928_000)
+	if err != nil || band.ID != 2 || minCh != 0 || maxCh != 49 {
+		t.Fatalf("US range = %+v %d-%d, %v", band, minCh, maxCh, err)
+	}
+	band, minCh, maxCh, err = BandForRange(920_000, 925_000)
+	if err != nil || band.ID != 1 || minCh != 0 || maxCh != 19 {
+		t.Fatalf("920-925 range = %+v %d-%d, %v", band, minCh, maxCh, err)
+	}
+	if _, _, _, err := BandForRange(700_000, 710_000); err == nil {
+		t.Fatal("range without channels accepted")
+	}
+
+	packet := SetRegionCommand(0x00, 8, 0, 19)
+	if packet[2] != CmdSetRegion || packet[3] != 0x80|19 || packet[4] != 0x00 {
+		t.Fatalf("packet = % X", packet)
+	}
+	band8, minCh, maxCh := decodeRegion(packet[3], packet[4])
+	if band8 != 8 || minCh != 0 || maxCh != 19 {
+		t.Fatalf("decodeRegion = %d %d-%d", band8, minCh, maxCh)
+	}
+}
@@ -0,0 +1,91 @@
+package reader18
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Memory banks of a Gen2 tag.
+const (
+	BankReserved byte = 0x00
+	BankEPC      byte = 0x01
+	BankTID      byte = 0x02
+	BankUser     byte = 0x03
+)
+
+// StatusTagError is returned when the tag rejected an access command; the
+// first data byte carries the Gen2 error code.
+const StatusTagError byte = 0xFC
+
+// MaxReadWords is the most words one read-data command can return.
+const MaxReadWords = 120
+
+// CheckReadWords rejects word counts a read-data command cannot carry.
+func CheckReadWords(words int) error {
+	if words < 1 || words > MaxReadWords {
+		return fmt.Errorf("word count must be 1-%d, got %d", MaxReadWords, words)
+	}
+	return nil
+}
+
+// ReadDataCommand reads words from one bank of the tag with the given EPC (0x02).
+// Payload: ENum(1) + EPC(ENum*2) + Mem(1) + WordPtr(1) + Num(1) + Pwd(4).
+func ReadDataCommand(address byte, epc []byte, bank, wordPtr, words byte, password uint32) ([]byte, error) {
+	if len(epc) == 0 || len(epc)%2 != 0 || len(epc) > 62 {
+		return nil, fmt.Errorf("epc must be 2-62 bytes in whole words, got %d", len(epc))
+	}
+	if bank > BankUser {
+		return nil, fmt.Errorf("invalid memory bank %d", bank)
+	}
+	if err := CheckReadWords(int(words)); err != nil {
+		return nil, err
+	}
+	payload := make([]byte, 0, len(epc)+8)
+	payload = append(payload, byte(len(epc)/2))
+	payload = append(payload, epc...)
+	payload = append(payload, bank, wordPtr, words)
+	payload = binary.BigEndian.AppendUint32(payload, password)
+	return BuildCommand(address, CmdReadData, payload), nil
+}
+
+// WriteEPCCommand writes a new EPC to the single tag in the field (0x04).
+// Payload: ENum(1) + Pwd(4) + EPC(ENum*2).
+func WriteEPCCommand(address byte, epc []byte, password uint32) ([]byte, error) {
+	if len(epc) == 0 || len(epc)%2 != 0 || len(epc) > 30 {
+		return nil, fmt.Errorf("epc must be 2-30 bytes in whole words, got %d", len(epc))
+	}
+	payload := make([]byte, 0, len(epc)+5)
+	payload = append(payload, byte(len(epc)/2))
+	payload = binary.BigEndian.AppendUint32(payload, password)
+	payload = append(payload, epc...)
+	return BuildCommand(address, CmdWriteEPC, payload), nil
+}
+
+// ParseReadData returns the words read by a 0x02 response.
+func ParseReadData(frame Frame) ([]byte, error) {
+	if frame.Command != CmdReadData {
+		return nil, fmt.Errorf("not read-data frame")
+	}
+	if err := AccessError(frame); err != nil {
+		return nil, err
+	}
+	return append([]byte(nil), frame.Data...), nil
+}
+
+// AccessError converts the status of a tag access response to an error.
+func AccessError(frame Frame) error {
+	switch frame.Status {
+	case StatusSuccess:
+		return nil
+	case StatusTagError:
+		if len(frame.Data) > 0 {
+			return fmt.Errorf("tag error 0x%02X", frame.Data[0])
+		}
+		return fmt.Errorf("tag error")
+	case StatusNoTagOrTimeout:
+		return fmt.Errorf("no tag answered")
+	case StatusAntennaError:
+		return fmt.Errorf("antenna error")
+	}
+	return fmt.Errorf("command 0x%02X failed with status 0x%02X", frame.Command, frame.Status)
+}
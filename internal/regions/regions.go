@@ -1,5 +1,11 @@
 package regions
 
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
 // Region represents one UHF regulatory preset.
 type Region struct {
 	Code string
@@ -34,3 +40,28 @@ func DefaultIndex() int {
 	}
 	return 0
 }
+
+// Lookup returns the region with the given code, ignoring case.
+func Lookup(code string) (Region, bool) {
+	for _, region := range Catalog {
+		if strings.EqualFold(region.Code, strings.TrimSpace(code)) {
+			return region, true
+		}
+	}
+	return Region{}, false
+}
+
+// RangeKHz parses Band ("902-928 MHz") into its edges in kHz.
+func (r Region) RangeKHz() (low, high int, err error) {
+	band := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(r.Band), "MHz"))
+	lowText, highText, ok := strings.Cut(band, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("region %s: invalid band %q", r.Code, r.Band)
+	}
+	lowMHz, err1 := strconv.ParseFloat(strings.TrimSpace(lowText), 64)
+	highMHz, err2 := strconv.ParseFloat(strings.TrimSpace(highText), 64)
+	if err1 != nil || err2 != nil || lowMHz >= highMHz {
+		return 0, 0, fmt.Errorf("region %s: invalid band %q", r.Code, r.Band)
+	}
+	return int(lowMHz*1000 + 0.5), int(highMHz*1000 + 0.5), nil
+}
@@ -0,0 +1,19 @@
+package regions
+
+import "testing"
+
+func TestCatalogBandsParse(t *testing.T) {
+	for _, region := range Catalog {
+		if _, _, err := region.RangeKHz(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	jp, ok := Lookup("jp")
+	if !ok {
+		t.Fatal("JP not found")
+	}
+	low, high, _ := jp.RangeKHz()
+	if low != 916_800 || high != 923_400 {
+		t.Fatalf("JP range = %d-%d", low, high)
+	}
+}
@@ -10,15 +10,47 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+const (
+	botReconnectMin = 500 * time.Millisecond
+	botReconnectMax = 5 * time.Second
+	botPollInterval = time.Second
 )
 
+var (
+	errBotSyncDisabled = errors.New("bot sync disabled")
+	errBotConnClosed   = errors.New("bot connection closed")
+)
+
+// botSubscribeEvents are the bot event types the TUI follows over IPC.
+var botSubscribeEvents = []string{"stats", "submit", "refresh", "notification"}
+
+// botSyncClient keeps one multiplexed IPC connection to the bot. Requests carry
+// an id and are matched to responses by the read loop; pushed events and stats
+// deltas arrive on the same connection and are forwarded to the TUI as messages.
 type botSyncClient struct {
 	enabled    bool
 	source     string
 	socketPath string
 	timeout    time.Duration
 	queue      chan string
+	updates    chan tea.Msg
+	startOnce  sync.Once
+	nextID     atomic.Uint64
+
+	connMu  sync.Mutex
+	conn    net.Conn
+	done    chan struct{}
+	pending map[string]chan syncResponse
+	writeMu sync.Mutex
+
+	statsMu sync.Mutex
+	stats   botRuntimeStats
 
 	errMu     sync.Mutex
 	lastErrAt time.Time
@@ -56,19 +88,113 @@ func newBotSyncClient() *botSyncClient {
 		socketPath: envOr("BOT_SYNC_SOCKET", envOr("BOT_IPC_SOCKET", "/tmp/rfid-go-bot.sock")),
 		timeout:    time.Duration(timeoutMS) * time.Millisecond,
 		queue:      make(chan string, queueSize),
+		updates:    make(chan tea.Msg, 256),
 	}
 	go c.ingestWorker()
 	return c
 }
 
+// start launches the connection loop once; it is deferred until the TUI
+// actually listens for updates so tests building a Model do not dial the bot.
+func (c *botSyncClient) start() {
+	if !c.enabled {
+		return
+	}
+	c.startOnce.Do(func() {
+		go c.run()
+	})
+}
+
+func (c *botSyncClient) run() {
+	delay := botReconnectMin
+	for {
+		done, err := c.session()
+		if err != nil {
+			c.publish(botStatusMsg{Err: err, At: time.Now()})
+			time.Sleep(delay)
+			delay *= 2
+			if delay > botReconnectMax {
+				delay = botReconnectMax
+			}
+			continue
+		}
+		delay = botReconnectMin
+		<-done
+		c.publish(botStatusMsg{Err: errBotConnClosed, At: time.Now()})
+		time.Sleep(delay)
+	}
+}
+
+// session connects, subscribes to bot events and returns a channel closed
+// when the connection drops. Bots without subscribe support are polled instead.
+func (c *botSyncClient) session() (<-chan struct{}, error) {
+	_, done, err := c.connection()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.roundTrip(syncFrame{
+		Type:   "subscribe",
+		Source: c.source,
+		Events: botSubscribeEvents,
+	})
+	if err != nil {
+		if !strings.Contains(err.Error(), "unsupported type") {
+			c.closeConn()
+			return nil, err
+		}
+		go c.poll(done)
+		return done, nil
+	}
+	c.setStats(resp.Stats)
+	return done, nil
+}
+
+func (c *botSyncClient) poll(done <-chan struct{}) {
+	t := time.NewTicker(botPollInterval)
+	defer t.Stop()
+	for {
+		stats, err := c.status()
+		if err == nil {
+			c.setStats(stats)
+		}
+		select {
+		case <-done:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (c *botSyncClient) setStats(stats botRuntimeStats) {
+	c.statsMu.Lock()
+	c.stats = stats
+	c.statsMu.Unlock()
+	c.publish(botStatusMsg{Stats: stats, At: time.Now()})
+}
+
+// publish hands a message to the TUI. Status messages carry the full stats
+// snapshot, so dropping one when the TUI lags loses nothing permanent.
+func (c *botSyncClient) publish(msg tea.Msg) {
+	select {
+	case c.updates <- msg:
+	default:
+	}
+}
+
 func (c *botSyncClient) ingestWorker() {
 	for epc := range c.queue {
-		if err := c.sendFrame(syncFrame{
+		resp, err := c.roundTrip(syncFrame{
 			Type:   "epc",
 			Source: c.source,
 			EPC:    epc,
-		}); err != nil {
+		})
+		if err != nil {
 			c.logErrorRateLimited("bot ingest failed", err)
+			continue
+		}
+		for _, res := range resp.Results {
+			c.publish(botEventMsg{Kind: "ingest", EPC: res.EPC, Status: res.Action, Detail: res.Error, At: time.Now()})
 		}
 	}
 }
@@ -123,7 +249,7 @@ func (c *botSyncClient) sendFrame(frame syncFrame) error {
 
 func (c *botSyncClient) status() (botRuntimeStats, error) {
 	if !c.enabled {
-		return botRuntimeStats{}, errors.New("bot sync disabled")
+		return botRuntimeStats{}, errBotSyncDisabled
 	}
 	resp, err := c.roundTrip(syncFrame{
 		Type:   "status",
@@ -137,32 +263,49 @@ func (c *botSyncClient) status() (botRuntimeStats, error) {
 
 func (c *botSyncClient) roundTrip(frame syncFrame) (syncResponse, error) {
 	if !c.enabled {
-		return syncResponse{}, errors.New("bot sync disabled")
+		return syncResponse{}, errBotSyncDisabled
 	}
 
-	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
+	conn, _, err := c.connection()
 	if err != nil {
 		return syncResponse{}, err
 	}
-	defer conn.Close()
 
-	_ = conn.SetDeadline(time.Now().Add(c.timeout))
+	frame.ID = strconv.FormatUint(c.nextID.Add(1), 10)
+	ch := make(chan syncResponse, 1)
+	c.connMu.Lock()
+	if c.conn != conn {
+		c.connMu.Unlock()
+		return syncResponse{}, errBotConnClosed
+	}
+	c.pending[frame.ID] = ch
+	c.connMu.Unlock()
+	defer func() {
+		c.connMu.Lock()
+		delete(c.pending, frame.ID)
+		c.connMu.Unlock()
+	}()
 
 	body, _ := json.Marshal(frame)
 	body = append(body, '\n')
-	if _, err := conn.Write(body); err != nil {
-		return syncResponse{}, err
-	}
-
-	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	c.writeMu.Lock()
+	_ = conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	_, err = conn.Write(body)
+	c.writeMu.Unlock()
 	if err != nil {
+		c.dropConn(conn)
 		return syncResponse{}, err
 	}
 
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
 	var resp syncResponse
-	if err := json.Unmarshal(line, &resp); err != nil {
-		return syncResponse{}, err
+	select {
+	case resp = <-ch:
+	case <-timer.C:
+		return syncResponse{}, fmt.Errorf("bot ipc %s timeout", frame.Type)
 	}
+
 	if !resp.OK {
 		if strings.TrimSpace(resp.Error) == "" {
 			return syncResponse{}, errors.New("ipc response not ok")
@@ -175,6 +318,132 @@ func (c *botSyncClient) roundTrip(frame syncFrame) (syncResponse, error) {
 	return resp, nil
 }
 
+// connection returns the live connection, dialing a new one when needed.
+func (c *botSyncClient) connection() (net.Conn, chan struct{}, error) {
+	c.connMu.Lock()
+	defer c.connMu.Unlock()
+	if c.conn != nil {
+		return c.conn, c.done, nil
+	}
+
+	conn, err := net.DialTimeout("unix", c.socketPath, c.timeout)
+	if err != nil {
+		return nil, nil, err
+	}
+	c.conn = conn
+	c.done = make(chan struct{})
+	c.pending = make(map[string]chan syncResponse)
+	go c.readLoop(conn, c.done)
+	return conn, c.done, nil
+}
+
+func (c *botSyncClient) readLoop(conn net.Conn, done chan struct{}) {
+	defer close(done)
+	defer c.dropConn(conn)
+
+	sc := bufio.NewScanner(conn)
+	sc.Buffer(make([]byte, 0, 4096), 1<<20)
+	for sc.Scan() {
+		var resp syncResponse
+		if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			continue
+		}
+		if resp.Action == "event" {
+			c.handleEvent(resp.Event)
+			continue
+		}
+
+		c.connMu.Lock()
+		ch := c.pending[resp.ID]
+		c.connMu.Unlock()
+		if ch != nil {
+			select {
+			case ch <- resp:
+			default:
+			}
+		}
+	}
+}
+
+// dropConn forgets conn and fails every request still waiting on it.
+func (c *botSyncClient) dropConn(conn net.Conn) {
+	c.connMu.Lock()
+	if c.conn != conn {
+		c.connMu.Unlock()
+		return
+	}
+	c.conn = nil
+	pending := c.pending
+	c.pending = nil
+	c.connMu.Unlock()
+
+	_ = conn.Close()
+	for _, ch := range pending {
+		select {
+		case ch <- syncResponse{Error: errBotConnClosed.Error()}:
+		default:
+		}
+	}
+}
+
+func (c *botSyncClient) closeConn() {
+	c.connMu.Lock()
+	conn := c.conn
+	c.connMu.Unlock()
+	if conn != nil {
+		c.dropConn(conn)
+	}
+}
+
+func (c *botSyncClient) handleEvent(ev syncEvent) {
+	switch ev.Type {
+	case "stats":
+		// Deltas only carry changed fields; decoding onto the last snapshot merges them.
+		c.statsMu.Lock()
+		err := json.Unmarshal(ev.Data, &c.stats)
+		stats := c.stats
+		c.statsMu.Unlock()
+		if err == nil {
+			c.publish(botStatusMsg{Stats: stats, At: time.Now()})
+		}
+
+	case "submit":
+		var data struct {
+			EPC    string `json:"epc"`
+			Status string `json:"status"`
+			Error  string `json:"error"`
+		}
+		if json.Unmarshal(ev.Data, &data) == nil {
+			c.publish(botEventMsg{Kind: "submit", EPC: data.EPC, Status: data.Status, Detail: data.Error, At: ev.Time})
+		}
+
+	case "refresh":
+		var data struct {
+			Reason string `json:"reason"`
+			OK     bool   `json:"ok"`
+			EPCs   int    `json:"epcs"`
+			Error  string `json:"error"`
+		}
+		if json.Unmarshal(ev.Data, &data) == nil {
+			status := "ok"
+			detail := fmt.Sprintf("reason=%s epcs=%d", data.Reason, data.EPCs)
+			if !data.OK {
+				status = "error"
+				detail = fmt.Sprintf("reason=%s err=%s", data.Reason, data.Error)
+			}
+			c.publish(botEventMsg{Kind: "refresh", Status: status, Detail: detail, At: ev.Time})
+		}
+
+	case "notification":
+		var data struct {
+			Text string `json:"text"`
+		}
+		if json.Unmarshal(ev.Data, &data) == nil {
+			c.publish(botEventMsg{Kind: "notification", Detail: data.Text, At: ev.Time})
+		}
+	}
+}
+
 func (c *botSyncClient) logErrorRateLimited(prefix string, err error) {
 	if err == nil {
 		return
@@ -192,17 +461,35 @@ func (c *botSyncClient) logErrorRateLimited(prefix string, err error) {
 }
 
 type syncFrame struct {
+	ID     string   `json:"id,omitempty"`
 	Type   string   `json:"type"`
 	Source string   `json:"source,omitempty"`
 	EPC    string   `json:"epc,omitempty"`
 	EPCs   []string `json:"epcs,omitempty"`
+	Events []string `json:"events,omitempty"`
 }
 
 type syncResponse struct {
+	ID      string          `json:"id,omitempty"`
 	OK      bool            `json:"ok"`
+	Action  string          `json:"action,omitempty"`
 	Error   string          `json:"error,omitempty"`
 	Warning string          `json:"warning,omitempty"`
+	Results []syncResult    `json:"results,omitempty"`
 	Stats   botRuntimeStats `json:"stats"`
+	Event   syncEvent       `json:"event"`
+}
+
+type syncResult struct {
+	EPC    string `json:"epc"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+type syncEvent struct {
+	Type string          `json:"type"`
+	Time time.Time       `json:"time"`
+	Data json.RawMessage `json:"data"`
 }
 
 func envOr(key, fallback string) string {
@@ -225,6 +512,16 @@ func envInt(key string, fallback int) int {
 	return n
 }
 
+func envList(key string) []string {
+	var items []string
+	for _, part := range strings.Split(os.Getenv(key), ",") {
+		if part = strings.TrimSpace(part); part != "" {
+			items = append(items, part)
+		}
+	}
+	return items
+}
+
 func envBool(key string, fallback bool) bool {
 	raw := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
 	switch raw {
@@ -0,0 +1,97 @@
+package tui
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"new_era_go/internal/gobot/cache"
+	"new_era_go/internal/gobot/config"
+	"new_era_go/internal/gobot/ipc"
+	"new_era_go/internal/gobot/service"
+)
+
+func waitBotMsg(t *testing.T, c *botSyncClient, match func(tea.Msg) bool) tea.Msg {
+	t.Helper()
+	deadline := time.After(3 * time.Second)
+	for {
+		select {
+		case msg := <-c.updates:
+			if match(msg) {
+				return msg
+			}
+		case <-deadline:
+			t.Fatalf("timed out waiting for bot update")
+		}
+	}
+}
+
+func TestBotSyncClientMultiplexesRequestsAndEvents(t *testing.T) {
+	svc := service.New(config.Config{
+		RequestTimeout: time.Second,
+		WorkerCount:    1,
+		QueueSize:      16,
+		RecentSeenTTL:  time.Minute,
+	}, nil, cache.New())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sock := filepath.Join(t.TempDir(), "bot.sock")
+	go func() { _ = ipc.New(sock, svc, nil).Run(ctx) }()
+
+	c := &botSyncClient{
+		enabled:    true,
+		source:     "test",
+		socketPath: sock,
+		timeout:    time.Second,
+		queue:      make(chan string, 8),
+		updates:    make(chan tea.Msg, 64),
+	}
+	go c.ingestWorker()
+	c.start()
+
+	waitBotMsg(t, c, func(msg tea.Msg) bool {
+		st, ok := msg.(botStatusMsg)
+		return ok && st.Err == nil
+	})
+	if n := svc.Events().Subscribers(); n != 1 {
+		t.Fatalf("expected one bot subscription, got %d", n)
+	}
+
+	c.onNewEPC("E2000011")
+	ev := waitBotMsg(t, c, func(msg tea.Msg) bool {
+		_, ok := msg.(botEventMsg)
+		return ok
+	}).(botEventMsg)
+	if ev.Kind != "ingest" || ev.Status != "scan_inactive" {
+		t.Fatalf("unexpected ingest event: %+v", ev)
+	}
+
+	// No status request is sent after subscribe; the count must arrive as a pushed delta.
+	waitBotMsg(t, c, func(msg tea.Msg) bool {
+		st, ok := msg.(botStatusMsg)
+		return ok && st.Err == nil && st.Stats.ScanInactive == 1
+	})
+}
+
+func TestOnBotEventTracksRecentTagResults(t *testing.T) {
+	m := NewModel()
+	logs := len(m.logs)
+
+	m.onBotEvent(botEventMsg{Kind: "ingest", EPC: "e2001", Status: "queued"})
+	m.onBotEvent(botEventMsg{Kind: "ingest", EPC: "E2002", Status: "miss"})
+	m.onBotEvent(botEventMsg{Kind: "submit", EPC: "E2001", Status: "submitted"})
+
+	if got := m.botTagResults["E2001"]; got != "submitted" {
+		t.Fatalf("expected submit to replace ingest result, got %q", got)
+	}
+	if len(m.botRecentTags) != 2 || m.botRecentTags[0] != "E2001" {
+		t.Fatalf("expected E2001 moved to front, got %v", m.botRecentTags)
+	}
+	if len(m.logs) != logs+1 {
+		t.Fatalf("expected one submit log line, got %d", len(m.logs)-logs)
+	}
+}
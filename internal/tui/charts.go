@@ -0,0 +1,167 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	chartSeconds     = 60 // history kept per rate series
+	antennaRateSpan  = 10 // seconds averaged for the per-antenna bars
+	rssiHistoryLimit = 60 // RSSI samples kept per EPC
+)
+
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// rateSeries counts events per wall-clock second over the last chartSeconds.
+type rateSeries struct {
+	counts [chartSeconds]int
+	newest int64 // unix second held in counts[newest%chartSeconds]
+}
+
+func (s *rateSeries) add(now time.Time) {
+	sec := now.Unix()
+	switch {
+	case s.newest == 0 || sec-s.newest >= chartSeconds:
+		s.counts = [chartSeconds]int{}
+		s.newest = sec
+	case sec > s.newest:
+		for t := s.newest + 1; t <= sec; t++ {
+			s.counts[t%chartSeconds] = 0
+		}
+		s.newest = sec
+	case s.newest-sec >= chartSeconds:
+		return
+	}
+	s.counts[sec%chartSeconds]++
+}
+
+// window returns the counts of the n seconds ending at now, oldest first.
+func (s *rateSeries) window(now time.Time, n int) []int {
+	n = min(n, chartSeconds)
+	out := make([]int, n)
+	sec := now.Unix()
+	for i := range out {
+		t := sec - int64(n-1-i)
+		if t > s.newest || s.newest-t >= chartSeconds {
+			continue
+		}
+		out[i] = s.counts[t%chartSeconds]
+	}
+	return out
+}
+
+// readCharts feeds the Inventory Tune charts. Model holds it by pointer so
+// the per-message Model copy stays small.
+type readCharts struct {
+	total    rateSeries
+	antennas [8]rateSeries
+}
+
+func (c *readCharts) add(now time.Time, antenna int) {
+	c.total.add(now)
+	if antenna >= 1 && antenna <= len(c.antennas) {
+		c.antennas[antenna-1].add(now)
+	}
+}
+
+// chartLines renders tags/s, reads per antenna and the RSSI trend of the
+// selected (or last) EPC as plain text; paintLayout colors them.
+func (m Model) chartLines(now time.Time) []string {
+	if m.charts == nil {
+		return nil
+	}
+	width := clampInt(m.panelContentWidth()-34, 10, chartSeconds)
+	rates := m.charts.total.window(now, width+1)
+	rates = rates[:len(rates)-1] // the running second is still partial
+	peak := maxInt(rates)
+	lines := []string{
+		fmt.Sprintf("Live (last %ds)", width),
+		fmt.Sprintf("Reads/s %s now:%d peak:%d", sparkline(rates, 0, peak), rates[len(rates)-1], peak),
+	}
+
+	type antennaRate struct {
+		port int
+		rate float64
+	}
+	var bars []antennaRate
+	best := 0.0
+	for i := range m.charts.antennas {
+		reads := sumInts(m.charts.antennas[i].window(now, antennaRateSpan+1)[:antennaRateSpan])
+		if reads == 0 && m.inventoryAntMask&(1<<i) == 0 {
+			continue
+		}
+		rate := float64(reads) / antennaRateSpan
+		bars = append(bars, antennaRate{port: i + 1, rate: rate})
+		best = max(best, rate)
+	}
+	for _, bar := range bars {
+		lines = append(lines, fmt.Sprintf("ANT%d    %s %.1f/s", bar.port, hbar(bar.rate, best, 20), bar.rate))
+	}
+
+	epc := m.tagSelected
+	if epc == "" {
+		epc = m.lastTagEPC
+	}
+	if stat := m.tagStats[epc]; stat != nil && len(stat.RSSIHistory) > 0 {
+		history := stat.RSSIHistory[max(0, len(stat.RSSIHistory)-width):]
+		lo, hi := minInt(history), maxInt(history)
+		lines = append(lines, fmt.Sprintf("RSSI    %s last:%d min:%d max:%d %s",
+			sparkline(history, lo, hi), history[len(history)-1], lo, hi, trimText(epc, 16)))
+	}
+	return lines
+}
+
+// sparkline maps each value between lo and hi onto one block character.
+func sparkline(values []int, lo, hi int) string {
+	var b strings.Builder
+	top := len(sparkLevels) - 1
+	for _, v := range values {
+		level := 0
+		if hi > lo {
+			level = clampInt((v-lo)*top/(hi-lo), 0, top)
+		} else if v > 0 {
+			level = top / 2
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// hbar is a horizontal bar of value against full.
+func hbar(value, full float64, width int) string {
+	filled := 0
+	if full > 0 {
+		filled = clampInt(int(value/full*float64(width)+0.5), 0, width)
+	}
+	return strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+}
+
+func sumInts(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+func maxInt(values []int) int {
+	best := 0
+	for i, v := range values {
+		if i == 0 || v > best {
+			best = v
+		}
+	}
+	return best
+}
+
+func minInt(values []int) int {
+	best := 0
+	for i, v := range values {
+		if i == 0 || v < best {
+			best = v
+		}
+	}
+	return best
+}
@@ -15,7 +15,17 @@ import (
 )
 
 func runScanCmd(opts discovery.ScanOptions) tea.Cmd {
-	return func() tea.Msg {
+	// progress holds only the latest report; older ones are dropped.
+	progress := make(chan discovery.ScanProgress, 1)
+	opts.Progress = func(p discovery.ScanProgress) {
+		select {
+		case <-progress:
+		default:
+		}
+		progress <- p
+	}
+	scan := func() tea.Msg {
+		defer close(progress)
 		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Second)
 		defer cancel()
 		start := time.Now()
@@ -26,6 +36,18 @@ func runScanCmd(opts discovery.ScanOptions) tea.Cmd {
 			Duration:   time.Since(start),
 		}
 	}
+	return tea.Batch(scan, waitScanProgressCmd(progress))
+}
+
+// waitScanProgressCmd blocks until the running scan reports progress.
+func waitScanProgressCmd(ch <-chan discovery.ScanProgress) tea.Cmd {
+	return func() tea.Msg {
+		p, ok := <-ch
+		if !ok {
+			return nil
+		}
+		return scanProgressMsg{Progress: p, ch: ch}
+	}
 }
 
 func connectCmd(client *reader.Client, endpoint reader.Endpoint) tea.Cmd {
@@ -89,14 +111,27 @@ func probeTimeoutCmd(delay time.Duration) tea.Cmd {
 	return tea.Tick(delay, func(time.Time) tea.Msg { return probeTimeoutMsg{} })
 }
 
-func botStatusTickCmd(delay time.Duration) tea.Cmd {
-	if delay <= 0 {
-		delay = time.Second
+// botInitCmd starts bot sync; a disabled client reports once and stays quiet.
+func botInitCmd() tea.Cmd {
+	c := getBotSyncClient()
+	if !c.enabled {
+		return func() tea.Msg {
+			return botStatusMsg{Err: errBotSyncDisabled, At: time.Now()}
+		}
+	}
+	return waitBotUpdateCmd()
+}
+
+// waitBotUpdateCmd blocks until the bot client pushes the next status or event.
+func waitBotUpdateCmd() tea.Cmd {
+	c := getBotSyncClient()
+	if !c.enabled {
+		return nil
+	}
+	c.start()
+	return func() tea.Msg {
+		return <-c.updates
 	}
-	return tea.Tick(delay, func(at time.Time) tea.Msg {
-		stats, err := getBotSyncClient().status()
-		return botStatusMsg{Stats: stats, Err: err, At: at}
-	})
 }
 
 func waitPacketCmd(ch <-chan reader.Packet) tea.Cmd {
@@ -237,8 +272,10 @@ func listWindow(cursor, total, window int) (int, int) {
 }
 
 func (m *Model) pushLog(line string) {
-	stamp := time.Now().Format("15:04:05")
+	now := time.Now()
+	stamp := now.Format("15:04:05")
 	m.logs = append(m.logs, fmt.Sprintf("[%s] %s", stamp, line))
+	m.logSession(fmt.Sprintf("[%s] %s", now.Format("2006-01-02 15:04:05"), line))
 	if len(m.logs) > 240 {
 		m.logs = m.logs[len(m.logs)-240:]
 	}
@@ -0,0 +1,329 @@
+package tui
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"new_era_go/internal/regions"
+)
+
+const (
+	exportRowDir = iota
+	exportRowFormat
+	exportRowLog
+	exportRowRun
+	exportRowCount
+)
+
+// sessionLogLimit bounds the full session log kept for export; the Logs
+// page keeps only the latest lines.
+const sessionLogLimit = 50000
+
+// tagExport is one row of the tag export.
+type tagExport struct {
+	EPC         string      `json:"epc"`
+	Reads       int         `json:"reads"`
+	Antennas    map[int]int `json:"antennas"`
+	LastAntenna int         `json:"last_antenna"`
+	LastRSSI    int         `json:"last_rssi"`
+	MinRSSI     int         `json:"min_rssi"`
+	AvgRSSI     float64     `json:"avg_rssi"`
+	PeakRSSI    int         `json:"peak_rssi"`
+	FirstSeen   time.Time   `json:"first_seen"`
+	LastSeen    time.Time   `json:"last_seen"`
+	Bot         string      `json:"bot,omitempty"`
+}
+
+// sessionExport is the JSON tag export document.
+type sessionExport struct {
+	ExportedAt   time.Time   `json:"exported_at"`
+	SessionStart time.Time   `json:"session_start"`
+	Reader       string      `json:"reader,omitempty"`
+	Region       string      `json:"region,omitempty"`
+	Reads        int         `json:"reads"`
+	Tags         []tagExport `json:"tags"`
+}
+
+// logSession appends to the session log written by the export.
+func (m *Model) logSession(line string) {
+	m.sessionLog = append(m.sessionLog, line)
+	if len(m.sessionLog) >= 2*sessionLogLimit {
+		drop := len(m.sessionLog) - sessionLogLimit
+		m.sessionLog = append([]string(nil), m.sessionLog[drop:]...)
+		m.sessionLogDropped += drop
+	}
+}
+
+func (m Model) openExportPage() (tea.Model, tea.Cmd) {
+	m.activeScreen = screenExport
+	m.status = fmt.Sprintf("Export: %d EPC(s), %d log line(s)", len(m.tagStats), len(m.sessionLog))
+	return m, nil
+}
+
+func (m Model) updateExportKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "up", "k":
+		m.exportIndex = (m.exportIndex - 1 + exportRowCount) % exportRowCount
+		return m, nil
+	case "down", "j":
+		m.exportIndex = (m.exportIndex + 1) % exportRowCount
+		return m, nil
+	case "left", "h", "right", "l":
+		return m.toggleExportSetting()
+	case "e":
+		return m.runExport(), nil
+	case "enter":
+		return m.runExportAction()
+	}
+
+	if idx, ok := parseDigit(msg.String()); ok && idx < exportRowCount {
+		m.exportIndex = idx
+		return m.runExportAction()
+	}
+	return m, nil
+}
+
+func (m Model) runExportAction() (tea.Model, tea.Cmd) {
+	switch m.exportIndex {
+	case exportRowDir:
+		m.inputMode = inputModeExportDir
+		m.input.Prompt = "DIR> "
+		m.input.Placeholder = "logs/exports"
+		m.input.SetValue(m.exportDir)
+		m.input.CursorEnd()
+		m.input.Focus()
+		m.status = "Type the export directory and press Enter"
+		return m, nil
+	case exportRowRun:
+		return m.runExport(), nil
+	}
+	return m.toggleExportSetting()
+}
+
+func (m Model) toggleExportSetting() (tea.Model, tea.Cmd) {
+	switch m.exportIndex {
+	case exportRowFormat:
+		if m.exportFormat == "json" {
+			m.exportFormat = "csv"
+		} else {
+			m.exportFormat = "json"
+		}
+		m.status = "Export format: " + strings.ToUpper(m.exportFormat)
+	case exportRowLog:
+		m.exportLog = !m.exportLog
+		m.status = "Session log export " + strings.ToLower(onOff(m.exportLog))
+	default:
+		return m, nil
+	}
+	m.saveSettings()
+	return m, nil
+}
+
+func (m Model) updateExportDirInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		m.status = "Directory unchanged"
+		return m, nil
+	case "enter":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		if dir := strings.TrimSpace(m.input.Value()); dir != "" {
+			m.exportDir = dir
+			m.saveSettings()
+		}
+		m.status = "Export directory: " + m.exportDir
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// runExport writes the session tags, and the session log when enabled, to
+// timestamped files in the export directory.
+func (m Model) runExport() Model {
+	now := time.Now()
+	stamp := now.Format("20060102-150405")
+	if err := os.MkdirAll(m.exportDir, 0o755); err != nil {
+		m.status = "Export failed: " + err.Error()
+		return m
+	}
+
+	doc := m.sessionExport(now)
+	tagsPath := filepath.Join(m.exportDir, "session-"+stamp+"-tags."+m.exportFormat)
+	var err error
+	if m.exportFormat == "json" {
+		err = writeJSONFile(tagsPath, doc)
+	} else {
+		err = writeTagsCSV(tagsPath, doc.Tags)
+	}
+	if err != nil {
+		m.status = "Export failed: " + err.Error()
+		return m
+	}
+	written := []string{tagsPath}
+
+	if m.exportLog {
+		logPath := filepath.Join(m.exportDir, "session-"+stamp+"-log.txt")
+		if err := os.WriteFile(logPath, m.sessionLogText(doc), 0o644); err != nil {
+			m.status = "Session log export failed: " + err.Error()
+			return m
+		}
+		written = append(written, logPath)
+	}
+
+	m.lastExport = written
+	m.status = fmt.Sprintf("Exported %d tag(s) to %s", len(doc.Tags), strings.Join(written, ", "))
+	m.pushLog(fmt.Sprintf("export: %d tags -> %s", len(doc.Tags), strings.Join(written, ", ")))
+	return m
+}
+
+func (m Model) sessionExport(now time.Time) sessionExport {
+	doc := sessionExport{
+		ExportedAt:   now,
+		SessionStart: m.sessionStart,
+		Reader:       m.preferredEndpoint.Address(),
+		Reads:        m.tagReads,
+		Tags:         make([]tagExport, 0, len(m.tagStats)),
+	}
+	if m.preferredEndpoint.Host == "" {
+		doc.Reader = ""
+	}
+	if m.regionIndex >= 0 && m.regionIndex < len(regions.Catalog) {
+		doc.Region = regions.Catalog[m.regionIndex].Code
+	}
+	for _, stat := range m.tagStats {
+		row := tagExport{
+			EPC:         stat.EPC,
+			Reads:       stat.Count,
+			Antennas:    make(map[int]int),
+			LastAntenna: stat.Antenna,
+			LastRSSI:    stat.LastRSSI,
+			MinRSSI:     stat.MinRSSI,
+			PeakRSSI:    stat.PeakRSSI,
+			FirstSeen:   stat.FirstSeen,
+			LastSeen:    stat.LastSeen,
+			Bot:         m.botTagResults[stat.EPC],
+		}
+		for i, reads := range stat.AntennaReads {
+			if reads > 0 {
+				row.Antennas[i+1] = reads
+			}
+		}
+		if stat.RSSIReads > 0 {
+			row.AvgRSSI = float64(stat.RSSISum) / float64(stat.RSSIReads)
+		}
+		doc.Tags = append(doc.Tags, row)
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool {
+		a, b := doc.Tags[i], doc.Tags[j]
+		if !a.FirstSeen.Equal(b.FirstSeen) {
+			return a.FirstSeen.Before(b.FirstSeen)
+		}
+		return a.EPC < b.EPC
+	})
+	return doc
+}
+
+func writeTagsCSV(path string, tags []tagExport) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write([]string{"epc", "reads", "antennas", "last_antenna", "last_rssi", "min_rssi", "avg_rssi", "peak_rssi", "first_seen", "last_seen", "bot"})
+	for _, tag := range tags {
+		ports := make([]int, 0, len(tag.Antennas))
+		for port := range tag.Antennas {
+			ports = append(ports, port)
+		}
+		sort.Ints(ports)
+		antennas := make([]string, 0, len(ports))
+		for _, port := range ports {
+			antennas = append(antennas, fmt.Sprintf("%d:%d", port, tag.Antennas[port]))
+		}
+		_ = w.Write([]string{
+			tag.EPC,
+			strconv.Itoa(tag.Reads),
+			strings.Join(antennas, " "),
+			strconv.Itoa(tag.LastAntenna),
+			strconv.Itoa(tag.LastRSSI),
+			strconv.Itoa(tag.MinRSSI),
+			strconv.FormatFloat(tag.AvgRSSI, 'f', 1, 64),
+			strconv.Itoa(tag.PeakRSSI),
+			tag.FirstSeen.Format(time.RFC3339),
+			tag.LastSeen.Format(time.RFC3339),
+			tag.Bot,
+		})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
+
+func (m Model) sessionLogText(doc sessionExport) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# session %s - %s\n", doc.SessionStart.Format(time.RFC3339), doc.ExportedAt.Format(time.RFC3339))
+	fmt.Fprintf(&b, "# reader %s region %s tags %d reads %d\n", orDash(doc.Reader), orDash(doc.Region), len(doc.Tags), doc.Reads)
+	if m.sessionLogDropped > 0 {
+		fmt.Fprintf(&b, "# %d earlier line(s) dropped\n", m.sessionLogDropped)
+	}
+	for _, line := range m.sessionLog {
+		b.WriteString(line)
+		b.WriteByte('\n')
+	}
+	return []byte(b.String())
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func (m Model) exportPageLines() []string {
+	lines := []string{"Export Session"}
+	lines = append(lines, fmt.Sprintf("Tags: %d EPC(s), %d read(s) | log: %d line(s)", len(m.tagStats), m.tagReads, len(m.sessionLog)+m.sessionLogDropped))
+	lines = append(lines, "Started: "+m.sessionStart.Format("2006-01-02 15:04:05"))
+	if m.inputMode == inputModeExportDir {
+		lines = append(lines, m.input.View())
+	}
+	lines = append(lines, "")
+
+	rows := []string{
+		"Directory: " + m.exportDir,
+		"Format: " + strings.ToUpper(m.exportFormat),
+		"Session Log File: " + onOff(m.exportLog),
+		"Export Now",
+	}
+	for i, row := range rows {
+		prefix := "  "
+		if i == m.exportIndex {
+			prefix = "▶ "
+		}
+		lines = append(lines, fmt.Sprintf("%s%d. %s", prefix, i+1, row))
+	}
+
+	lines = append(lines, "")
+	if len(m.lastExport) == 0 {
+		lines = append(lines, "Last export: none")
+	} else {
+		lines = append(lines, "Last export:")
+		for _, path := range m.lastExport {
+			lines = append(lines, "  "+path)
+		}
+	}
+	return lines
+}
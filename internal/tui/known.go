@@ -0,0 +1,183 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"new_era_go/internal/discovery"
+)
+
+// openKnownRegistry loads the known-reader registry shared with the bot.
+// An empty BOT_READER_KNOWN_FILE disables it; a registry that cannot be read
+// is logged and scans run without it.
+func openKnownRegistry(logs []string) (*discovery.Registry, []string) {
+	path, set := os.LookupEnv("BOT_READER_KNOWN_FILE")
+	if !set {
+		path = "logs/known_readers.json"
+	}
+	if path = strings.TrimSpace(path); path == "" {
+		return nil, logs
+	}
+	registry, err := discovery.OpenRegistry(path)
+	if err != nil {
+		return nil, append(logs, "[startup] known readers ignored: "+err.Error())
+	}
+	return registry, logs
+}
+
+func (m Model) selectedCandidate() (discovery.Candidate, bool) {
+	if len(m.candidates) == 0 {
+		return discovery.Candidate{}, false
+	}
+	return m.candidates[m.deviceIndex], true
+}
+
+func (m Model) togglePinSelected() (tea.Model, tea.Cmd) {
+	selected, ok := m.selectedCandidate()
+	if !ok || m.scanOptions.Known == nil {
+		m.status = knownUnavailable(ok)
+		return m, nil
+	}
+	pinned := !selected.Pinned
+	if err := m.scanOptions.Known.Pin(selected, pinned); err != nil {
+		m.status = "Pin failed: " + err.Error()
+		return m, nil
+	}
+	m.candidates[m.deviceIndex].Known = true
+	m.candidates[m.deviceIndex].Pinned = pinned
+	if pinned {
+		m.status = "Pinned " + candidateName(selected)
+	} else {
+		m.status = "Unpinned " + candidateName(selected)
+	}
+	m.pushLog(strings.ToLower(m.status))
+	return m, nil
+}
+
+func (m Model) startRenameSelected() (tea.Model, tea.Cmd) {
+	selected, ok := m.selectedCandidate()
+	if !ok || m.scanOptions.Known == nil {
+		m.status = knownUnavailable(ok)
+		return m, nil
+	}
+	m.inputMode = inputModeLabel
+	m.input.Prompt = "LABEL> "
+	m.input.Placeholder = "e.g. Dock 1"
+	m.input.SetValue(selected.Label)
+	m.input.CursorEnd()
+	m.input.Focus()
+	m.status = "Type a label and press Enter (empty clears it)"
+	return m, nil
+}
+
+func (m Model) updateLabelInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		m.status = "Rename canceled"
+		return m, nil
+	case "enter":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		selected := m.candidates[m.deviceIndex]
+		label := strings.TrimSpace(m.input.Value())
+		if err := m.scanOptions.Known.Rename(selected, label); err != nil {
+			m.status = "Rename failed: " + err.Error()
+			return m, nil
+		}
+		m.candidates[m.deviceIndex].Known = true
+		m.candidates[m.deviceIndex].Label = label
+		m.status = fmt.Sprintf("%s:%d labeled %q", selected.Host, selected.Port, label)
+		m.pushLog("rename " + m.status)
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) forgetSelected() (tea.Model, tea.Cmd) {
+	selected, ok := m.selectedCandidate()
+	if !ok || m.scanOptions.Known == nil {
+		m.status = knownUnavailable(ok)
+		return m, nil
+	}
+	if !selected.Known {
+		m.status = candidateName(selected) + " is not a known reader"
+		return m, nil
+	}
+	if err := m.scanOptions.Known.Forget(discovery.CandidateKey(selected)); err != nil {
+		m.status = "Forget failed: " + err.Error()
+		return m, nil
+	}
+	m.candidates[m.deviceIndex].Known = false
+	m.candidates[m.deviceIndex].Pinned = false
+	m.candidates[m.deviceIndex].Label = ""
+	m.status = "Forgot " + candidateName(selected)
+	m.pushLog(strings.ToLower(m.status))
+	return m, nil
+}
+
+// forgetMissing drops every known reader that is not in the current list.
+func (m Model) forgetMissing() (tea.Model, tea.Cmd) {
+	if m.scanOptions.Known == nil {
+		m.status = knownUnavailable(true)
+		return m, nil
+	}
+	missing := m.missingKnownReaders()
+	for _, k := range missing {
+		if err := m.scanOptions.Known.Forget(k.Key()); err != nil {
+			m.status = "Forget failed: " + err.Error()
+			return m, nil
+		}
+	}
+	m.status = fmt.Sprintf("Forgot %d missing known reader(s)", len(missing))
+	m.pushLog(strings.ToLower(m.status))
+	return m, nil
+}
+
+// missingKnownReaders lists known readers the last scan did not return.
+func (m Model) missingKnownReaders() []discovery.KnownReader {
+	if m.scanOptions.Known == nil {
+		return nil
+	}
+	found := make(map[string]struct{}, len(m.candidates))
+	for _, c := range m.candidates {
+		if c.Known {
+			found[discovery.CandidateKey(c)] = struct{}{}
+		}
+	}
+	var missing []discovery.KnownReader
+	for _, k := range m.scanOptions.Known.List() {
+		if _, ok := found[k.Key()]; !ok {
+			missing = append(missing, k)
+		}
+	}
+	return missing
+}
+
+func knownUnavailable(hasSelection bool) string {
+	if !hasSelection {
+		return "No devices, press 's' to scan"
+	}
+	return "Known readers disabled (BOT_READER_KNOWN_FILE is empty or unreadable)"
+}
+
+func candidateName(c discovery.Candidate) string {
+	if c.Label != "" {
+		return fmt.Sprintf("%s (%s:%d)", c.Label, c.Host, c.Port)
+	}
+	return fmt.Sprintf("%s:%d", c.Host, c.Port)
+}
+
+func knownReaderName(k discovery.KnownReader) string {
+	if k.Label != "" {
+		return fmt.Sprintf("%s (%s:%d)", k.Label, k.Host, k.Port)
+	}
+	return fmt.Sprintf("%s:%d", k.Host, k.Port)
+}
@@ -1,6 +1,7 @@
 package tui
 
 import (
+	"path/filepath"
 	"time"
 
 	"github.com/charmbracelet/bubbles/textinput"
@@ -22,8 +23,20 @@ func NewModel() Model {
 	regionIdx := regions.DefaultIndex()
 
 	opts := discovery.DefaultOptions()
+	logs := []string{"[startup] scan requested"}
+	opts.Known, logs = openKnownRegistry(logs)
+	// Same sweep targets as the bot, from the shared .env.
+	opts.Targets = envList("BOT_READER_SCAN_TARGETS")
+	opts.Exclude = envList("BOT_READER_SCAN_EXCLUDE")
+	opts.Interfaces = envList("BOT_READER_SCAN_INTERFACES")
 
-	return Model{
+	settingsPath := settingsFilePath()
+	settings, err := loadSettings(settingsPath)
+	if err != nil {
+		logs = append(logs, "[startup] settings ignored: "+err.Error())
+	}
+
+	m := Model{
 		reader:            reader.NewClient(),
 		activeScreen:      screenHome,
 		homeIndex:         0,
@@ -42,7 +55,7 @@ func NewModel() Model {
 		input:             in,
 		inputMode:         inputModeNone,
 		status:            "Startup scan running...",
-		logs:              []string{"[startup] scan requested"},
+		logs:              logs,
 		botSocket:         envOr("BOT_SYNC_SOCKET", envOr("BOT_IPC_SOCKET", "/tmp/rfid-go-bot.sock")),
 		rxBytes:           0,
 		txBytes:           0,
@@ -68,18 +81,29 @@ func NewModel() Model {
 		inventoryAntIdx:   0,
 		lastTagEPC:        "",
 		seenTagEPC:        make(map[string]struct{}),
+		tagStats:          make(map[string]*tagStat),
+		charts:            &readCharts{},
+		botTagResults:     make(map[string]string),
 		protocolBuffer:    nil,
 		lastRawLogAt:      time.Time{},
 		awaitingProbe:     false,
 		width:             0,
 		height:            0,
+		settingsPath:      settingsPath,
+		sessionStart:      time.Now(),
+		sessionLog:        append([]string(nil), logs...),
+		exportDir:         filepath.Join(envOr("BOT_LOG_DIR", "logs"), "exports"),
+		exportFormat:      "csv",
+		exportLog:         true,
 	}
+	m.applySettings(settings)
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
 	return tea.Batch(
 		runScanCmd(m.scanOptions),
-		botStatusTickCmd(300*time.Millisecond),
+		botInitCmd(),
 	)
 }
 
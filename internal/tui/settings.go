@@ -0,0 +1,408 @@
+package tui
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"new_era_go/internal/reader"
+	"new_era_go/internal/regions"
+)
+
+// inventoryPreset is a named set of Inventory Tune values. The built-in
+// presets are fixed; user presets live in the settings file.
+type inventoryPreset struct {
+	Name           string `json:"name"`
+	QValue         byte   `json:"q"`
+	Session        byte   `json:"session"`
+	Target         byte   `json:"target"`
+	ScanTime       byte   `json:"scan_time"`
+	NoTagAB        int    `json:"no_tag_ab"`
+	PollIntervalMS int    `json:"poll_interval_ms"`
+	AntennaMask    byte   `json:"antenna_mask"`
+}
+
+var builtinPresets = []inventoryPreset{
+	{Name: "fast", QValue: 4, Session: 1, ScanTime: 1, NoTagAB: 4, PollIntervalMS: 40, AntennaMask: 0x01},
+	{Name: "balanced", QValue: 4, Session: 1, ScanTime: 2, NoTagAB: 4, PollIntervalMS: 70, AntennaMask: 0x01},
+	{Name: "long-range", QValue: 4, Session: 2, ScanTime: 8, NoTagAB: 5, PollIntervalMS: 120, AntennaMask: 0x01},
+}
+
+// tuiSettings is the settings file. Every field is optional so a file from
+// an older build, or one edited by hand, still loads.
+type tuiSettings struct {
+	QValue         *byte             `json:"q,omitempty"`
+	Session        *byte             `json:"session,omitempty"`
+	Target         *byte             `json:"target,omitempty"`
+	ScanTime       *byte             `json:"scan_time,omitempty"`
+	NoTagAB        *int              `json:"no_tag_ab,omitempty"`
+	PollIntervalMS *int              `json:"poll_interval_ms,omitempty"`
+	AntennaMask    *byte             `json:"antenna_mask,omitempty"`
+	PhaseFreq      bool              `json:"phase_freq,omitempty"`
+	Region         string            `json:"region,omitempty"`
+	Preset         string            `json:"preset,omitempty"`
+	Endpoint       *settingsEndpoint `json:"endpoint,omitempty"`
+	Presets        []inventoryPreset `json:"presets,omitempty"`
+	ExportDir      string            `json:"export_dir,omitempty"`
+	ExportFormat   string            `json:"export_format,omitempty"`
+	ExportLog      *bool             `json:"export_log,omitempty"`
+}
+
+type settingsEndpoint struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+// settingsFilePath returns BOT_TUI_SETTINGS_FILE, else settings.json in the
+// user config dir ($XDG_CONFIG_HOME/st8508-tui on Linux). An empty
+// BOT_TUI_SETTINGS_FILE disables the file.
+func settingsFilePath() string {
+	if path, set := os.LookupEnv("BOT_TUI_SETTINGS_FILE"); set {
+		return strings.TrimSpace(path)
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "st8508-tui", "settings.json")
+}
+
+// loadSettings reads the settings file. A missing file is empty settings.
+func loadSettings(path string) (tuiSettings, error) {
+	var s tuiSettings
+	if path == "" {
+		return s, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return s, err
+	}
+	if err := json.Unmarshal(data, &s); err != nil {
+		return tuiSettings{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// writeJSONFile replaces path with v through a temp file, like the
+// known-reader registry.
+func writeJSONFile(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// applySettings copies loaded settings over the defaults, clamped to the
+// ranges the Inventory Tune page allows.
+func (m *Model) applySettings(s tuiSettings) {
+	if s.QValue != nil {
+		m.inventoryQValue = byte(clampInt(int(*s.QValue), 0, 15))
+	}
+	if s.Session != nil {
+		m.inventorySession = byte(clampInt(int(*s.Session), 0, 3))
+	}
+	if s.Target != nil {
+		m.inventoryTarget = *s.Target & 0x01
+	}
+	if s.ScanTime != nil {
+		m.inventoryScanTime = byte(clampInt(int(*s.ScanTime), 1, 255))
+	}
+	if s.NoTagAB != nil {
+		m.inventoryNoTagAB = clampInt(*s.NoTagAB, 0, 255)
+	}
+	if s.PollIntervalMS != nil {
+		m.inventoryInterval = time.Duration(clampInt(*s.PollIntervalMS, 20, 1000)) * time.Millisecond
+	}
+	if s.AntennaMask != nil && *s.AntennaMask != 0 {
+		m.inventoryAntMask = *s.AntennaMask
+	}
+	m.showPhaseFreq = s.PhaseFreq
+	for i, region := range regions.Catalog {
+		if strings.EqualFold(region.Code, s.Region) {
+			m.regionIndex = i
+			m.regionCursor = i
+		}
+	}
+	m.activePreset = s.Preset
+	if s.Endpoint != nil && s.Endpoint.Host != "" && s.Endpoint.Port > 0 {
+		m.preferredEndpoint = reader.Endpoint{Host: s.Endpoint.Host, Port: s.Endpoint.Port}
+	}
+	m.userPresets, _ = mergePresets(nil, s.Presets)
+	if s.ExportDir != "" {
+		m.exportDir = s.ExportDir
+	}
+	if s.ExportFormat == "csv" || s.ExportFormat == "json" {
+		m.exportFormat = s.ExportFormat
+	}
+	if s.ExportLog != nil {
+		m.exportLog = *s.ExportLog
+	}
+}
+
+func (m Model) currentSettings() tuiSettings {
+	pollMS := int(m.inventoryInterval / time.Millisecond)
+	s := tuiSettings{
+		QValue:         &m.inventoryQValue,
+		Session:        &m.inventorySession,
+		Target:         &m.inventoryTarget,
+		ScanTime:       &m.inventoryScanTime,
+		NoTagAB:        &m.inventoryNoTagAB,
+		PollIntervalMS: &pollMS,
+		AntennaMask:    &m.inventoryAntMask,
+		PhaseFreq:      m.showPhaseFreq,
+		Preset:         m.activePreset,
+		Presets:        m.userPresets,
+		ExportDir:      m.exportDir,
+		ExportFormat:   m.exportFormat,
+		ExportLog:      &m.exportLog,
+	}
+	if m.regionIndex >= 0 && m.regionIndex < len(regions.Catalog) {
+		s.Region = regions.Catalog[m.regionIndex].Code
+	}
+	if m.preferredEndpoint.Host != "" {
+		s.Endpoint = &settingsEndpoint{Host: m.preferredEndpoint.Host, Port: m.preferredEndpoint.Port}
+	}
+	return s
+}
+
+// saveSettings writes the settings file after a change. Failures are logged
+// once per run so a read-only config dir does not flood the log.
+func (m *Model) saveSettings() {
+	if m.settingsPath == "" {
+		return
+	}
+	if err := writeJSONFile(m.settingsPath, m.currentSettings()); err != nil {
+		if !m.settingsSaveFailed {
+			m.pushLog("settings save failed: " + err.Error())
+		}
+		m.settingsSaveFailed = true
+		return
+	}
+	m.settingsSaveFailed = false
+}
+
+func (m Model) lookupPreset(name string) (inventoryPreset, bool) {
+	for _, preset := range builtinPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	for _, preset := range m.userPresets {
+		if preset.Name == name {
+			return preset, true
+		}
+	}
+	return inventoryPreset{}, false
+}
+
+func (m Model) presetFromSettings(name string) inventoryPreset {
+	return inventoryPreset{
+		Name:           name,
+		QValue:         m.inventoryQValue,
+		Session:        m.inventorySession,
+		Target:         m.inventoryTarget,
+		ScanTime:       m.inventoryScanTime,
+		NoTagAB:        m.inventoryNoTagAB,
+		PollIntervalMS: int(m.inventoryInterval / time.Millisecond),
+		AntennaMask:    m.inventoryAntMask,
+	}
+}
+
+func isBuiltinPreset(name string) bool {
+	for _, preset := range builtinPresets {
+		if preset.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// mergePresets adds incoming presets to presets, replacing those with the
+// same name. Presets without a name, or named like a built-in, are skipped.
+func mergePresets(presets, incoming []inventoryPreset) ([]inventoryPreset, int) {
+	presets = slices.Clone(presets)
+	added := 0
+	for _, preset := range incoming {
+		preset.Name = strings.TrimSpace(preset.Name)
+		if preset.Name == "" || isBuiltinPreset(preset.Name) {
+			continue
+		}
+		preset.QValue = byte(clampInt(int(preset.QValue), 0, 15))
+		preset.Session = byte(clampInt(int(preset.Session), 0, 3))
+		preset.Target &= 0x01
+		preset.ScanTime = byte(clampInt(int(preset.ScanTime), 1, 255))
+		preset.NoTagAB = clampInt(preset.NoTagAB, 0, 255)
+		preset.PollIntervalMS = clampInt(preset.PollIntervalMS, 20, 1000)
+		if preset.AntennaMask == 0 {
+			preset.AntennaMask = 0x01
+		}
+		idx := slices.IndexFunc(presets, func(p inventoryPreset) bool { return p.Name == preset.Name })
+		if idx >= 0 {
+			presets[idx] = preset
+		} else {
+			presets = append(presets, preset)
+		}
+		added++
+	}
+	return presets, added
+}
+
+// preferredIndex picks the reader used last time when the scan found it,
+// else the best candidate.
+func (m Model) preferredIndex() int {
+	if m.preferredEndpoint.Host != "" {
+		for i, c := range m.candidates {
+			if c.Host == m.preferredEndpoint.Host && c.Port == m.preferredEndpoint.Port {
+				return i
+			}
+		}
+	}
+	return preferredCandidateIndex(m.candidates)
+}
+
+// selectedUserPreset returns the user preset under the Inventory Tune cursor.
+func (m Model) selectedUserPreset() (int, bool) {
+	idx := m.inventoryIndex - invTuneCount
+	return idx, idx >= 0 && idx < len(m.userPresets)
+}
+
+func (m Model) startPresetInput(mode inputMode) (tea.Model, tea.Cmd) {
+	m.inputMode = mode
+	m.input.SetValue("")
+	switch mode {
+	case inputModePresetName:
+		m.input.Prompt = "NAME> "
+		m.input.Placeholder = "e.g. dock-door"
+		m.status = "Name the preset and press Enter (Esc cancels)"
+	case inputModePresetExport:
+		m.input.Prompt = "FILE> "
+		m.input.Placeholder = "presets.json"
+		m.input.SetValue("presets.json")
+		m.status = "Export user presets to file (Enter writes, Esc cancels)"
+	case inputModePresetImport:
+		m.input.Prompt = "FILE> "
+		m.input.Placeholder = "presets.json"
+		m.input.SetValue("presets.json")
+		m.status = "Import presets from file (Enter reads, Esc cancels)"
+	}
+	m.input.CursorEnd()
+	m.input.Focus()
+	return m, nil
+}
+
+// updatePresetInput handles the preset name and import/export file prompts.
+func (m Model) updatePresetInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		m.status = "Canceled"
+		return m, nil
+	case "enter":
+		mode := m.inputMode
+		value := strings.TrimSpace(m.input.Value())
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		if value == "" {
+			m.status = "Canceled (empty)"
+			return m, nil
+		}
+		switch mode {
+		case inputModePresetName:
+			m = m.saveUserPreset(value)
+		case inputModePresetExport:
+			m = m.exportPresets(value)
+		case inputModePresetImport:
+			m = m.importPresets(value)
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+func (m Model) saveUserPreset(name string) Model {
+	if isBuiltinPreset(name) {
+		m.status = fmt.Sprintf("%q is a built-in preset, choose another name", name)
+		return m
+	}
+	m.userPresets, _ = mergePresets(m.userPresets, []inventoryPreset{m.presetFromSettings(name)})
+	m.activePreset = name
+	m.saveSettings()
+	m.status = "Preset saved: " + name
+	m.pushLog("preset saved: " + name)
+	return m
+}
+
+func (m Model) deleteUserPreset() Model {
+	idx, ok := m.selectedUserPreset()
+	if !ok {
+		m.status = "Select a user preset to delete"
+		return m
+	}
+	name := m.userPresets[idx].Name
+	m.userPresets = slices.Delete(slices.Clone(m.userPresets), idx, idx+1)
+	if m.activePreset == name {
+		m.activePreset = ""
+	}
+	m.inventoryIndex = min(m.inventoryIndex, m.inventoryRowCount()-1)
+	m.saveSettings()
+	m.status = "Preset deleted: " + name
+	m.pushLog("preset deleted: " + name)
+	return m
+}
+
+// exportPresets writes the user presets as a JSON array another site can
+// import.
+func (m Model) exportPresets(path string) Model {
+	if len(m.userPresets) == 0 {
+		m.status = "No user presets to export"
+		return m
+	}
+	if err := writeJSONFile(path, m.userPresets); err != nil {
+		m.status = "Export failed: " + err.Error()
+		return m
+	}
+	m.status = fmt.Sprintf("Exported %d preset(s) to %s", len(m.userPresets), path)
+	m.pushLog(strings.ToLower(m.status))
+	return m
+}
+
+func (m Model) importPresets(path string) Model {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		m.status = "Import failed: " + err.Error()
+		return m
+	}
+	var incoming []inventoryPreset
+	if err := json.Unmarshal(data, &incoming); err != nil {
+		m.status = fmt.Sprintf("Import failed: %s: %v", path, err)
+		return m
+	}
+	var added int
+	m.userPresets, added = mergePresets(m.userPresets, incoming)
+	m.saveSettings()
+	m.status = fmt.Sprintf("Imported %d preset(s) from %s", added, path)
+	m.pushLog(strings.ToLower(m.status))
+	return m
+}
@@ -62,6 +62,9 @@ var (
 	verifiedStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("120")).
 			Bold(true)
+
+	chartStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("81"))
 )
 
 func paintLayout(layout string) string {
@@ -92,6 +95,8 @@ func paintLayout(layout string) string {
 			lines[i] = statusInfoStyle.Render(line)
 		case strings.Contains(line, "│ ▶ "):
 			lines[i] = selectedLineStyle.Render(line)
+		case strings.ContainsAny(line, string(sparkLevels)+"░"):
+			lines[i] = chartStyle.Render(line)
 		case strings.Contains(line, "Back to Home"):
 			lines[i] = backLineStyle.Render(line)
 		case strings.Contains(line, "[VERIFIED]"):
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// tagStat is one EPC seen during this TUI session.
+type tagStat struct {
+	EPC       string
+	Count     int
+	Antenna   int // antenna of the latest read
+	LastRSSI  int // 0 when the latest read carried no RSSI
+	PeakRSSI  int
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// RSSIHistory holds the latest reads that carried an RSSI, oldest first.
+	RSSIHistory []int
+	// MinRSSI, RSSISum and RSSIReads cover every read that carried an RSSI.
+	MinRSSI      int
+	RSSISum      int
+	RSSIReads    int
+	AntennaReads [8]int
+}
+
+type tagSortKey int
+
+const (
+	tagSortLastSeen tagSortKey = iota
+	tagSortCount
+	tagSortRSSI
+	tagSortEPC
+	tagSortFirstSeen
+	tagSortKeyCount
+)
+
+var tagSortNames = [tagSortKeyCount]string{"last seen", "reads", "peak rssi", "epc", "first seen"}
+
+// recordTagRead updates the Tags table for every read, not only new EPCs.
+func (m *Model) recordTagRead(epc string, antenna, rssi int) {
+	if epc == "" {
+		return
+	}
+	if m.tagStats == nil {
+		m.tagStats = make(map[string]*tagStat)
+	}
+	now := time.Now()
+	stat, ok := m.tagStats[epc]
+	if !ok {
+		stat = &tagStat{EPC: epc, FirstSeen: now}
+		m.tagStats[epc] = stat
+	}
+	stat.Count++
+	stat.Antenna = antenna
+	stat.LastRSSI = rssi
+	stat.PeakRSSI = max(stat.PeakRSSI, rssi)
+	stat.LastSeen = now
+	if antenna >= 1 && antenna <= len(stat.AntennaReads) {
+		stat.AntennaReads[antenna-1]++
+	}
+	if rssi > 0 {
+		if stat.RSSIReads == 0 || rssi < stat.MinRSSI {
+			stat.MinRSSI = rssi
+		}
+		stat.RSSISum += rssi
+		stat.RSSIReads++
+		if len(stat.RSSIHistory) >= 2*rssiHistoryLimit {
+			stat.RSSIHistory = append([]int(nil), stat.RSSIHistory[len(stat.RSSIHistory)-rssiHistoryLimit:]...)
+		}
+		stat.RSSIHistory = append(stat.RSSIHistory, rssi)
+	}
+	m.tagReads++
+	if m.charts == nil {
+		m.charts = &readCharts{}
+	}
+	m.charts.add(now, antenna)
+}
+
+// visibleTags returns the filtered rows in the current sort order.
+func (m Model) visibleTags() []*tagStat {
+	filter := strings.ToUpper(strings.TrimSpace(m.tagFilter))
+	rows := make([]*tagStat, 0, len(m.tagStats))
+	for _, stat := range m.tagStats {
+		if filter != "" && !strings.Contains(stat.EPC, filter) &&
+			!strings.Contains(strings.ToUpper(m.botTagLabel(stat.EPC)), filter) {
+			continue
+		}
+		rows = append(rows, stat)
+	}
+
+	less := func(a, b *tagStat) bool {
+		switch m.tagSort {
+		case tagSortCount:
+			if a.Count != b.Count {
+				return a.Count > b.Count
+			}
+		case tagSortRSSI:
+			if a.PeakRSSI != b.PeakRSSI {
+				return a.PeakRSSI > b.PeakRSSI
+			}
+		case tagSortFirstSeen:
+			if !a.FirstSeen.Equal(b.FirstSeen) {
+				return a.FirstSeen.After(b.FirstSeen)
+			}
+		case tagSortLastSeen:
+			if !a.LastSeen.Equal(b.LastSeen) {
+				return a.LastSeen.After(b.LastSeen)
+			}
+		}
+		return a.EPC < b.EPC
+	}
+	sort.Slice(rows, func(i, j int) bool {
+		if m.tagSortAsc {
+			return less(rows[j], rows[i])
+		}
+		return less(rows[i], rows[j])
+	})
+	return rows
+}
+
+// selectedTagIndex finds the selected EPC in rows; the selection follows the
+// EPC while rows re-sort during a scan.
+func (m Model) selectedTagIndex(rows []*tagStat) int {
+	for i, stat := range rows {
+		if stat.EPC == m.tagSelected {
+			return i
+		}
+	}
+	return 0
+}
+
+// botTagLabel condenses the bot's ingest/submit status for the table.
+func (m Model) botTagLabel(epc string) string {
+	switch status := m.botTagResults[epc]; status {
+	case "":
+		return "-"
+	case "queued", "queued_or_dropped":
+		return "hit"
+	default:
+		return status
+	}
+}
+
+func (m Model) openTagsPage() (tea.Model, tea.Cmd) {
+	m.activeScreen = screenTags
+	m.status = fmt.Sprintf("Tags: %d EPC(s)", len(m.tagStats))
+	return m, nil
+}
+
+func (m Model) updateTagKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.visibleTags()
+	idx := m.selectedTagIndex(rows)
+	move := func(to int) {
+		if len(rows) > 0 {
+			m.tagSelected = rows[clampInt(to, 0, len(rows)-1)].EPC
+		}
+	}
+
+	switch msg.String() {
+	case "up", "k":
+		move(idx - 1)
+	case "down", "j":
+		move(idx + 1)
+	case "pgup":
+		move(idx - m.tagViewSize())
+	case "pgdown":
+		move(idx + m.tagViewSize())
+	case "home", "g":
+		move(0)
+	case "end", "G":
+		move(len(rows) - 1)
+	case "s":
+		m.tagSort = (m.tagSort + 1) % tagSortKeyCount
+		m.status = "Tags sorted by " + m.tagSortLabel()
+	case "r":
+		m.tagSortAsc = !m.tagSortAsc
+		m.status = "Tags sorted by " + m.tagSortLabel()
+	case "/":
+		m.inputMode = inputModeTagFilter
+		m.input.Prompt = "FIND> "
+		m.input.Placeholder = "EPC part or bot result"
+		m.input.SetValue(m.tagFilter)
+		m.input.CursorEnd()
+		m.input.Focus()
+		m.status = "Type to filter tags, Enter keeps, Esc clears"
+	case "c":
+		m.tagStats = make(map[string]*tagStat)
+		m.tagReads = 0
+		m.tagSelected = ""
+		m.charts = &readCharts{}
+		m.status = "Tag table cleared"
+		m.pushLog("tag table cleared")
+	case "e":
+		return m.openExportPage()
+	}
+	return m, nil
+}
+
+// updateTagFilterInput filters the table on every keystroke.
+func (m Model) updateTagFilterInput(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.tagFilter = ""
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		m.status = "Tag filter cleared"
+		return m, nil
+	case "enter":
+		m.inputMode = inputModeNone
+		m.input.Blur()
+		m.status = fmt.Sprintf("Tag filter: %q (%d match)", m.tagFilter, len(m.visibleTags()))
+		return m, nil
+	}
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	m.tagFilter = m.input.Value()
+	return m, cmd
+}
+
+func (m Model) tagSortLabel() string {
+	if m.tagSortAsc {
+		return tagSortNames[m.tagSort] + " (reversed)"
+	}
+	return tagSortNames[m.tagSort]
+}
+
+func (m Model) tagsPageLines() []string {
+	lines := []string{"Tags"}
+	rows := m.visibleTags()
+	summary := fmt.Sprintf("EPCs: %d", len(m.tagStats))
+	if m.tagFilter != "" {
+		summary = fmt.Sprintf("EPCs: %d of %d | filter: %s", len(rows), len(m.tagStats), m.tagFilter)
+	}
+	lines = append(lines, fmt.Sprintf("%s | reads: %d | sort: %s", summary, m.tagReads, m.tagSortLabel()))
+
+	if m.inputMode == inputModeTagFilter {
+		lines = append(lines, m.input.View())
+	}
+	if len(rows) == 0 {
+		if len(m.tagStats) == 0 {
+			return append(lines, "", "No tags yet", "Start reading from Control")
+		}
+		return append(lines, "", "No tag matches the filter")
+	}
+
+	idx := m.selectedTagIndex(rows)
+	start, end := listWindow(idx, len(rows), m.tagViewSize())
+	lines = append(lines, "")
+	lines = append(lines, fmt.Sprintf("  %-24s %6s %3s %9s %8s %8s  %s", "EPC", "READS", "ANT", "RSSI/PEAK", "FIRST", "LAST", "BOT"))
+	for i := start; i < end; i++ {
+		stat := rows[i]
+		prefix := "  "
+		if i == idx {
+			prefix = "▶ "
+		}
+		lines = append(lines, fmt.Sprintf("%s%-24s %6d %3d %9s %8s %8s  %s",
+			prefix, trimText(stat.EPC, 24), stat.Count, stat.Antenna, rssiPair(stat),
+			formatShortTime(stat.FirstSeen), formatShortTime(stat.LastSeen), m.botTagLabel(stat.EPC)))
+	}
+	lines = append(lines, fmt.Sprintf("Rows: %d-%d of %d", start+1, end, len(rows)))
+
+	selected := rows[idx]
+	lines = append(lines, "")
+	lines = append(lines, "EPC: "+selected.EPC)
+	lines = append(lines, fmt.Sprintf("Reads: %d | Antenna: %d | RSSI last:%s peak:%s",
+		selected.Count, selected.Antenna, rssiText(selected.LastRSSI), rssiText(selected.PeakRSSI)))
+	lines = append(lines, fmt.Sprintf("First: %s | Last: %s (%s ago)",
+		formatShortTime(selected.FirstSeen), formatShortTime(selected.LastSeen),
+		time.Since(selected.LastSeen).Round(time.Second)))
+	bot := "Bot: no result yet"
+	if status, ok := m.botTagResults[selected.EPC]; ok {
+		bot = fmt.Sprintf("Bot: %s (%s)", m.botTagLabel(selected.EPC), status)
+	}
+	lines = append(lines, bot)
+	if history := selected.RSSIHistory; len(history) > 0 {
+		history = history[max(0, len(history)-rssiHistoryLimit):]
+		lines = append(lines, "RSSI: "+sparkline(history, minInt(history), maxInt(history)))
+	}
+	return lines
+}
+
+func (m Model) tagViewSize() int {
+	if m.height <= 0 {
+		return 8
+	}
+	size := m.height - 22
+	if size < 4 {
+		size = 4
+	}
+	if size > 30 {
+		size = 30
+	}
+	return size
+}
+
+func rssiPair(stat *tagStat) string {
+	return rssiText(stat.LastRSSI) + "/" + rssiText(stat.PeakRSSI)
+}
+
+func rssiText(rssi int) string {
+	if rssi <= 0 {
+		return "-"
+	}
+	return fmt.Sprint(rssi)
+}
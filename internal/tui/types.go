@@ -19,6 +19,8 @@ const (
 	screenRegions
 	screenLogs
 	screenHelp
+	screenTags
+	screenExport
 )
 
 type inputMode int
@@ -26,6 +28,12 @@ type inputMode int
 const (
 	inputModeNone inputMode = iota
 	inputModeRawHex
+	inputModeLabel
+	inputModeTagFilter
+	inputModePresetName
+	inputModePresetExport
+	inputModePresetImport
+	inputModeExportDir
 )
 
 type menuItem struct {
@@ -48,6 +56,7 @@ var homeMenu = []menuItem{
 	{Label: "Quick Connect", Desc: "Scan LAN and connect to best reader"},
 	{Label: "Devices", Desc: "Browse discovered reader endpoints"},
 	{Label: "Control", Desc: "Start/stop reading and run commands"},
+	{Label: "Tags", Desc: "Live table of EPCs read this session"},
 	{Label: "Inventory Tune", Desc: "Q/session/target/antenna performance settings"},
 	{Label: "Regions", Desc: "Choose RF region preset"},
 	{Label: "Logs", Desc: "Inspect recent events"},
@@ -63,9 +72,15 @@ var controlMenu = []menuItem{
 	{Label: "Rescan + Quick Connect", Desc: "Find and reconnect"},
 	{Label: "Clear Logs", Desc: "Keep only new events"},
 	{Label: "Inventory Tune", Desc: "Open inventory parameter page"},
+	{Label: "Export Session", Desc: "Write tags and session log to files"},
 	{Label: "Back To Home", Desc: "Return to home page"},
 }
 
+type scanProgressMsg struct {
+	Progress discovery.ScanProgress
+	ch       <-chan discovery.ScanProgress
+}
+
 type scanFinishedMsg struct {
 	Candidates []discovery.Candidate
 	Err        error
@@ -108,6 +123,16 @@ type botStatusMsg struct {
 	At    time.Time
 }
 
+// botEventMsg is a pushed bot result: an ingest action or submit status for
+// an EPC, a cache refresh, or a user notification.
+type botEventMsg struct {
+	Kind   string
+	EPC    string
+	Status string
+	Detail string
+	At     time.Time
+}
+
 type botRuntimeStats struct {
 	CacheSize      int       `json:"cache_size"`
 	DraftCount     int       `json:"draft_count"`
@@ -142,6 +167,7 @@ type Model struct {
 
 	scanOptions  discovery.ScanOptions
 	scanning     bool
+	scanProgress discovery.ScanProgress
 	candidates   []discovery.Candidate
 	lastScanTime time.Duration
 
@@ -157,6 +183,9 @@ type Model struct {
 	botLastErr  string
 	botSocket   string
 
+	botTagResults map[string]string
+	botRecentTags []string
+
 	connectQueue       []reader.Endpoint
 	connectAttempt     int
 	connectActionLabel string
@@ -191,6 +220,34 @@ type Model struct {
 	lastRawLogAt      time.Time
 	awaitingProbe     bool
 
+	// Tags page: every EPC read this session.
+	tagStats    map[string]*tagStat
+	tagReads    int
+	tagSelected string
+	tagSort     tagSortKey
+	tagSortAsc  bool
+	tagFilter   string
+
+	// Read-rate and RSSI charts on the Inventory Tune page.
+	charts *readCharts
+
+	// Settings file and user presets; see settings.go.
+	settingsPath       string
+	settingsSaveFailed bool
+	userPresets        []inventoryPreset
+	activePreset       string
+	preferredEndpoint  reader.Endpoint
+
+	// Export page and the full session log it writes.
+	sessionStart      time.Time
+	sessionLog        []string
+	sessionLogDropped int
+	exportIndex       int
+	exportDir         string
+	exportFormat      string
+	exportLog         bool
+	lastExport        []string
+
 	width  int
 	height int
 }
@@ -32,6 +32,18 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		if m.inputMode == inputModeRawHex {
 			return m.updateRawInput(msg)
 		}
+		if m.inputMode == inputModeLabel {
+			return m.updateLabelInput(msg)
+		}
+		if m.inputMode == inputModeTagFilter {
+			return m.updateTagFilterInput(msg)
+		}
+		if m.inputMode == inputModePresetName || m.inputMode == inputModePresetExport || m.inputMode == inputModePresetImport {
+			return m.updatePresetInput(msg)
+		}
+		if m.inputMode == inputModeExportDir {
+			return m.updateExportDirInput(msg)
+		}
 		return m.updateKey(msg)
 
 	case botStatusMsg:
@@ -45,7 +57,17 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.botLastErr = ""
 			m.botLastSync = msg.At
 		}
-		return m, botStatusTickCmd(time.Second)
+		return m, waitBotUpdateCmd()
+
+	case botEventMsg:
+		m.onBotEvent(msg)
+		return m, waitBotUpdateCmd()
+
+	case scanProgressMsg:
+		if m.scanning {
+			m.scanProgress = msg.Progress
+		}
+		return m, waitScanProgressCmd(msg.ch)
 
 	case scanFinishedMsg:
 		return m.onScanFinished(msg)
@@ -192,6 +214,7 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 func (m Model) onScanFinished(msg scanFinishedMsg) (tea.Model, tea.Cmd) {
 	m.scanning = false
+	m.scanProgress = discovery.ScanProgress{}
 	m.lastScanTime = msg.Duration
 
 	if msg.Err != nil && !errors.Is(msg.Err, context.DeadlineExceeded) && !errors.Is(msg.Err, context.Canceled) {
@@ -225,7 +248,7 @@ func (m Model) onScanFinished(msg scanFinishedMsg) (tea.Model, tea.Cmd) {
 
 	if m.pendingConnect {
 		m.pendingConnect = false
-		idx := preferredCandidateIndex(m.candidates)
+		idx := m.preferredIndex()
 		if idx < 0 {
 			m.status = "No reader endpoint found."
 			m.pushLog("quick connect skipped: no endpoints")
@@ -263,6 +286,10 @@ func (m Model) onConnectFinished(msg connectFinishedMsg) (tea.Model, tea.Cmd) {
 	m.awaitingProbe = false
 	m.status = "Connected: " + msg.Endpoint.Address()
 	m.pushLog("connected: " + msg.Endpoint.Address())
+	if m.preferredEndpoint != msg.Endpoint {
+		m.preferredEndpoint = msg.Endpoint
+		m.saveSettings()
+	}
 	base := []tea.Cmd{
 		waitPacketCmd(m.reader.Packets()),
 		waitReaderErrCmd(m.reader.Errors()),
@@ -303,8 +330,7 @@ func (m Model) onConnectFinished(msg connectFinishedMsg) (tea.Model, tea.Cmd) {
 		base = append(base, sendNamedCmd(m.reader, "probe-info", packet))
 	case 3:
 		m.pendingAction = noPendingAction
-		m.inputMode = inputModeRawHex
-		m.input.Focus()
+		m.focusRawInput()
 		m.status = "Connected. Raw mode ready"
 	default:
 		packet := reader18.GetReaderInfoCommand(m.inventoryAddress)
@@ -393,6 +419,7 @@ func (m *Model) handleProtocolFrame(frame reader18.Frame) {
 				m.lastTagEPC = epcText
 				m.lastTagAntenna = tag.Antenna
 				m.lastTagRSSI = tag.RSSI
+				m.recordTagRead(epcText, tag.Antenna, tag.RSSI)
 				if _, exists := m.seenTagEPC[epcText]; exists {
 					continue
 				}
@@ -472,6 +499,7 @@ func (m *Model) handleProtocolFrame(frame reader18.Frame) {
 				m.lastTagAntenna = int(result.Antenna)
 				m.lastTagRSSI = 0
 				m.inventoryNoTagHit = 0
+				m.recordTagRead(epcText, int(result.Antenna), 0)
 				if m.seenTagEPC == nil {
 					m.seenTagEPC = make(map[string]struct{})
 				}
@@ -571,6 +599,10 @@ func (m Model) updateKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.updateLogKeys(msg)
 	case screenHelp:
 		return m.updateHelpKeys(msg)
+	case screenTags:
+		return m.updateTagKeys(msg)
+	case screenExport:
+		return m.updateExportKeys(msg)
 	default:
 		return m, nil
 	}
@@ -606,17 +638,19 @@ func (m Model) runHomeAction(index int) (tea.Model, tea.Cmd) {
 		m.activeScreen = screenControl
 		m.status = "Control"
 	case 3:
+		return m.openTagsPage()
+	case 4:
 		m.activeScreen = screenInventory
 		m.status = "Inventory Tune"
-	case 4:
+	case 5:
 		m.activeScreen = screenRegions
 		m.regionCursor = m.regionIndex
 		m.status = "Regions"
-	case 5:
+	case 6:
 		m.activeScreen = screenLogs
 		m.logScroll = 0
 		m.status = "Logs"
-	case 6:
+	case 7:
 		m.activeScreen = screenHelp
 		m.status = "Help"
 	}
@@ -632,7 +666,7 @@ func (m Model) runQuickConnect() (tea.Model, tea.Cmd) {
 	}
 
 	if len(m.candidates) > 0 {
-		idx := preferredCandidateIndex(m.candidates)
+		idx := m.preferredIndex()
 		if idx < 0 {
 			m.status = "No reader in cache. Rescanning..."
 			m.pushLog("quick connect requires at least one endpoint")
@@ -674,9 +708,20 @@ func (m Model) updateDeviceKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		m.connectActionLabel = ""
 		m.status = "Scanning LAN..."
 		m.pushLog("manual scan")
-		return m, runScanCmd(m.scanOptions)
+		// A manual scan sweeps the LAN even when known readers answer.
+		opts := m.scanOptions
+		opts.ForceSweep = true
+		return m, runScanCmd(opts)
 	case "a":
 		return m.runQuickConnect()
+	case "p":
+		return m.togglePinSelected()
+	case "r":
+		return m.startRenameSelected()
+	case "x":
+		return m.forgetSelected()
+	case "X":
+		return m.forgetMissing()
 	case "enter":
 		return m.connectSelectedDevice()
 	}
@@ -708,6 +753,10 @@ func (m Model) updateControlKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m, nil
 	case "/":
 		return m.enterRawMode()
+	case "t":
+		return m.openTagsPage()
+	case "e":
+		return m.openExportPage()
 	case "enter":
 		return m.runControlAction(m.controlIndex)
 	}
@@ -734,17 +783,26 @@ const (
 	invTunePresetFast
 	invTunePresetBalanced
 	invTunePresetLongRange
-	invTuneCount
+	invTuneSavePreset
+	invTuneExportPresets
+	invTuneImportPresets
+	invTuneCount // user presets follow the fixed rows
 )
 
+func (m Model) inventoryRowCount() int {
+	return invTuneCount + len(m.userPresets)
+}
+
 func (m Model) updateInventoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	switch msg.String() {
 	case "up", "k":
-		m.inventoryIndex = (m.inventoryIndex - 1 + invTuneCount) % invTuneCount
+		m.inventoryIndex = (m.inventoryIndex - 1 + m.inventoryRowCount()) % m.inventoryRowCount()
 		return m, nil
 	case "down", "j":
-		m.inventoryIndex = (m.inventoryIndex + 1) % invTuneCount
+		m.inventoryIndex = (m.inventoryIndex + 1) % m.inventoryRowCount()
 		return m, nil
+	case "d", "delete":
+		return m.deleteUserPreset(), nil
 	case "left", "h":
 		return m.adjustInventorySetting(-1)
 	case "right", "l":
@@ -754,7 +812,7 @@ func (m Model) updateInventoryKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	}
 
 	if idx, ok := parseDigit(msg.String()); ok {
-		if idx < invTuneCount {
+		if idx < m.inventoryRowCount() {
 			m.inventoryIndex = idx
 			if idx >= invTuneApply {
 				return m.runInventoryAction()
@@ -796,7 +854,12 @@ func (m Model) adjustInventorySetting(delta int) (tea.Model, tea.Cmd) {
 		m.status = fmt.Sprintf("Poll interval set to %s, effective cycle %s", m.inventoryInterval, m.effectiveInventoryInterval())
 	default:
 		m.status = "Select a parameter row to edit"
+		return m, nil
+	}
+	if m.inventoryIndex != invTunePhaseFreq {
+		m.activePreset = ""
 	}
+	m.saveSettings()
 	return m, nil
 }
 
@@ -840,41 +903,37 @@ func (m Model) runInventoryAction() (tea.Model, tea.Cmd) {
 	case invTunePresetLongRange:
 		m = m.applyInventoryPreset("long-range")
 		return m, nil
+	case invTuneSavePreset:
+		return m.startPresetInput(inputModePresetName)
+	case invTuneExportPresets:
+		return m.startPresetInput(inputModePresetExport)
+	case invTuneImportPresets:
+		return m.startPresetInput(inputModePresetImport)
+	}
+	if idx, ok := m.selectedUserPreset(); ok {
+		m = m.applyInventoryPreset(m.userPresets[idx].Name)
+		return m, nil
 	}
 
 	return m.adjustInventorySetting(1)
 }
 
 func (m Model) applyInventoryPreset(name string) Model {
-	switch name {
-	case "fast":
-		m.inventoryQValue = 4
-		m.inventorySession = 1
-		m.inventoryTarget = 0
-		m.inventoryScanTime = 1
-		m.inventoryNoTagAB = 4
-		m.inventoryInterval = 40 * time.Millisecond
-		m.inventoryAntMask = 0x01
-		m.status = "Preset applied: fast"
-	case "balanced":
-		m.inventoryQValue = 4
-		m.inventorySession = 1
-		m.inventoryTarget = 0
-		m.inventoryScanTime = 2
-		m.inventoryNoTagAB = 4
-		m.inventoryInterval = 70 * time.Millisecond
-		m.inventoryAntMask = 0x01
-		m.status = "Preset applied: balanced"
-	case "long-range":
-		m.inventoryQValue = 4
-		m.inventorySession = 2
-		m.inventoryTarget = 0
-		m.inventoryScanTime = 8
-		m.inventoryNoTagAB = 5
-		m.inventoryInterval = 120 * time.Millisecond
-		m.inventoryAntMask = 0x01
-		m.status = "Preset applied: long-range"
-	}
+	preset, ok := m.lookupPreset(name)
+	if !ok {
+		m.status = "Unknown preset: " + name
+		return m
+	}
+	m.inventoryQValue = preset.QValue
+	m.inventorySession = preset.Session
+	m.inventoryTarget = preset.Target
+	m.inventoryScanTime = preset.ScanTime
+	m.inventoryNoTagAB = preset.NoTagAB
+	m.inventoryInterval = time.Duration(preset.PollIntervalMS) * time.Millisecond
+	m.inventoryAntMask = preset.AntennaMask
+	m.activePreset = name
+	m.saveSettings()
+	m.status = "Preset applied: " + name
 	m.pushLog(fmt.Sprintf("preset %s: q=%d s=%d target=%d scan=%d poll=%s effective=%s mask=0x%02X", name, m.inventoryQValue, m.inventorySession, m.inventoryTarget, m.inventoryScanTime, m.inventoryInterval, m.effectiveInventoryInterval(), m.inventoryAntMask))
 	return m
 }
@@ -984,6 +1043,9 @@ func (m Model) runControlAction(index int) (tea.Model, tea.Cmd) {
 		return m, nil
 
 	case 8:
+		return m.openExportPage()
+
+	case 9:
 		m.activeScreen = screenHome
 		m.status = "Home"
 		return m, nil
@@ -997,8 +1059,7 @@ func (m Model) enterRawMode() (tea.Model, tea.Cmd) {
 		m.status = "Reader not connected"
 		return m, nil
 	}
-	m.inputMode = inputModeRawHex
-	m.input.Focus()
+	m.focusRawInput()
 	m.status = "Raw mode: enter hex and press Enter"
 	if strings.TrimSpace(m.input.Value()) == "" {
 		m.input.SetValue("")
@@ -1006,6 +1067,15 @@ func (m Model) enterRawMode() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// focusRawInput switches the shared text input to raw hex entry; the label,
+// preset and export inputs reuse it with their own prompts.
+func (m *Model) focusRawInput() {
+	m.inputMode = inputModeRawHex
+	m.input.Prompt = "HEX> "
+	m.input.Placeholder = "04 00 21 D9 6A"
+	m.input.Focus()
+}
+
 func (m Model) updateRegionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	total := len(regions.Catalog)
 	if total == 0 {
@@ -1024,6 +1094,7 @@ func (m Model) updateRegionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		selected := regions.Catalog[m.regionIndex]
 		m.status = fmt.Sprintf("Region selected: %s (%s)", selected.Code, selected.Band)
 		m.pushLog("region selected: " + selected.Code)
+		m.saveSettings()
 		return m, nil
 	}
 
@@ -1033,6 +1104,7 @@ func (m Model) updateRegionKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		selected := regions.Catalog[m.regionIndex]
 		m.status = fmt.Sprintf("Region selected: %s (%s)", selected.Code, selected.Band)
 		m.pushLog("region selected: " + selected.Code)
+		m.saveSettings()
 	}
 	return m, nil
 }
@@ -1099,7 +1171,7 @@ func (m Model) requestConnectionForAction(action int, actionName string) (tea.Mo
 	}
 
 	if len(m.candidates) > 0 {
-		idx := preferredCandidateIndex(m.candidates)
+		idx := m.preferredIndex()
 		if idx < 0 {
 			if m.scanning {
 				m.pendingConnect = true
@@ -1287,6 +1359,52 @@ func (m *Model) onNoTagObserved() {
 	}
 }
 
+const botRecentTagLimit = 4
+
+// onBotEvent records pushed bot results so the control page can show them next to the tags.
+func (m *Model) onBotEvent(msg botEventMsg) {
+	switch msg.Kind {
+	case "ingest", "submit":
+		epc := strings.ToUpper(strings.TrimSpace(msg.EPC))
+		if epc == "" || msg.Status == "" {
+			return
+		}
+		if m.botTagResults == nil {
+			m.botTagResults = make(map[string]string)
+		}
+		m.botTagResults[epc] = msg.Status
+		m.botRecentTags = append([]string{epc}, removeString(m.botRecentTags, epc)...)
+		if len(m.botRecentTags) > botRecentTagLimit {
+			m.botRecentTags = m.botRecentTags[:botRecentTagLimit]
+		}
+		if msg.Kind == "submit" {
+			line := fmt.Sprintf("bot submit epc=%s status=%s", epc, msg.Status)
+			if msg.Detail != "" {
+				line += " err=" + trimText(msg.Detail, 48)
+			}
+			m.pushLog(line)
+		}
+
+	case "refresh":
+		if msg.Status != "ok" {
+			m.pushLog("bot cache refresh failed: " + msg.Detail)
+		}
+
+	case "notification":
+		m.pushLog("bot: " + trimText(strings.ReplaceAll(msg.Detail, "\n", " | "), 96))
+	}
+}
+
+func removeString(list []string, value string) []string {
+	out := list[:0:0]
+	for _, item := range list {
+		if item != value {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
 func nextInventoryAntenna(mask byte, start int) (byte, int) {
 	if mask == 0 {
 		mask = 0x01
@@ -1,13 +1,27 @@
 package tui
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
 
 	"new_era_go/internal/discovery"
 	reader18 "new_era_go/internal/protocol/reader18"
 	"new_era_go/internal/reader"
 )
 
+// TestMain keeps tests away from the user's settings file; tests that need
+// one set BOT_TUI_SETTINGS_FILE themselves.
+func TestMain(m *testing.M) {
+	os.Setenv("BOT_TUI_SETTINGS_FILE", "")
+	os.Exit(m.Run())
+}
+
 func TestStartReadingQueuesScanWhenDisconnectedAndNoCandidates(t *testing.T) {
 	m := NewModel()
 	m.scanning = false
@@ -133,3 +147,269 @@ func TestLegacyInventoryDoesNotInflateUniqueCounter(t *testing.T) {
 		t.Fatalf("expected unique tag total unchanged, got %d", m.inventoryTagTotal)
 	}
 }
+
+func TestDevicesPinRenameAndForgetKnownReader(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "known.json")
+	t.Setenv("BOT_READER_KNOWN_FILE", path)
+	m := NewModel()
+	if m.scanOptions.Known == nil {
+		t.Fatal("expected known-reader registry to be loaded")
+	}
+	m.scanning = false
+	m.activeScreen = screenDevices
+	m.candidates = []discovery.Candidate{{Host: "192.168.1.50", Port: 6000, Verified: true}}
+
+	key := func(m Model, msg tea.KeyMsg) Model {
+		next, _ := m.Update(msg)
+		return next.(Model)
+	}
+	runes := func(s string) tea.KeyMsg { return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)} }
+
+	m = key(m, runes("p"))
+	m = key(m, runes("r"))
+	if m.inputMode != inputModeLabel {
+		t.Fatalf("expected label input, got mode %d", m.inputMode)
+	}
+	m = key(m, runes("Dock 1"))
+	m = key(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if got := m.candidates[0]; !got.Pinned || !got.Known || got.Label != "Dock 1" {
+		t.Fatalf("unexpected candidate after pin+rename: %+v", got)
+	}
+
+	reg, err := discovery.OpenRegistry(path)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	if list := reg.List(); len(list) != 1 || !list[0].Pinned || list[0].Label != "Dock 1" {
+		t.Fatalf("expected pinned, labeled reader on disk, got %+v", list)
+	}
+
+	m = key(m, runes("x"))
+	if m.candidates[0].Known {
+		t.Fatalf("expected reader to be forgotten, status %q", m.status)
+	}
+	reg, _ = discovery.OpenRegistry(path)
+	if len(reg.List()) != 0 {
+		t.Fatalf("expected empty registry, got %+v", reg.List())
+	}
+}
+
+func TestScanProgressUpdatesDevicesPage(t *testing.T) {
+	m := NewModel()
+	m.activeScreen = screenDevices
+	ch := make(chan discovery.ScanProgress)
+	next, cmd := m.Update(scanProgressMsg{Progress: discovery.ScanProgress{Probed: 5, Total: 10, Found: 1, ETA: 3 * time.Second}, ch: ch})
+	m = next.(Model)
+	if cmd == nil {
+		t.Fatal("expected to keep waiting for progress")
+	}
+	line := m.scanProgressLine()
+	if !strings.Contains(line, "[##########..........] 5/10 hosts, 1 open, ETA 3s") {
+		t.Fatalf("unexpected progress line %q", line)
+	}
+	if !strings.Contains(m.metaLine(), "RUNNING 50%") {
+		t.Fatalf("expected percentage in meta line, got %q", m.metaLine())
+	}
+
+	next, _ = m.Update(scanFinishedMsg{})
+	if got := next.(Model).scanProgress; got.Total != 0 {
+		t.Fatalf("expected progress reset after scan, got %+v", got)
+	}
+}
+
+func TestTagsPageSortsFiltersAndShowsBotResult(t *testing.T) {
+	m := NewModel()
+	m.inventoryRunning = true
+	read := func(epc []byte, rssi byte) {
+		data := append([]byte{0x01, 0x01, byte(len(epc))}, epc...)
+		m.handleProtocolFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusSuccess, Data: append(data, rssi)})
+	}
+	read([]byte{0xAA, 0x01}, 0x30)
+	read([]byte{0xBB, 0x02}, 0x50)
+	read([]byte{0xAA, 0x01}, 0x40)
+	m.onBotEvent(botEventMsg{Kind: "ingest", EPC: "AA01", Status: "queued"})
+
+	if got := m.tagStats["AA01"]; got == nil || got.Count != 2 || got.PeakRSSI != 0x40 || got.LastRSSI != 0x40 {
+		t.Fatalf("AA01 stats: %+v", got)
+	}
+
+	next, _ := m.runHomeAction(3)
+	m = next.(Model)
+	if m.activeScreen != screenTags {
+		t.Fatalf("home item 4 opened screen %d", m.activeScreen)
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	m = next.(Model)
+	if rows := m.visibleTags(); m.tagSort != tagSortCount || rows[0].EPC != "AA01" {
+		t.Fatalf("sort by reads: %v first=%s", m.tagSort, rows[0].EPC)
+	}
+	page := strings.Join(m.tagsPageLines(), "\n")
+	if !strings.Contains(page, "EPC: AA01") || !strings.Contains(page, "Bot: hit (queued)") {
+		t.Fatalf("detail pane missing:\n%s", page)
+	}
+
+	// Search as you type: each key narrows the table before Enter.
+	for _, key := range []string{"/", "b", "b"} {
+		next, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)})
+		m = next.(Model)
+	}
+	if m.inputMode != inputModeTagFilter || m.activeScreen != screenTags {
+		t.Fatal("typing b in the filter must not leave the page")
+	}
+	if rows := m.visibleTags(); len(rows) != 1 || rows[0].EPC != "BB02" {
+		t.Fatalf("filtered rows: %d", len(rows))
+	}
+	next, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m = next.(Model)
+	if m.tagFilter != "" || len(m.visibleTags()) != 2 {
+		t.Fatal("esc did not clear the filter")
+	}
+}
+
+func TestRateSeriesWindowAndChartLines(t *testing.T) {
+	var s rateSeries
+	base := time.Unix(1_700_000_000, 0)
+	s.add(base)
+	s.add(base)
+	s.add(base.Add(2 * time.Second))
+	if got := s.window(base.Add(2*time.Second), 4); !slices.Equal(got, []int{0, 2, 0, 1}) {
+		t.Fatalf("window = %v", got)
+	}
+	s.add(base.Add(chartSeconds * time.Second))
+	if got := s.window(base.Add(chartSeconds*time.Second), chartSeconds); got[0] != 0 || got[chartSeconds-1] != 1 {
+		t.Fatalf("old seconds not expired: %v", got)
+	}
+	if got := sparkline([]int{0, 4, 8}, 0, 8); got != "▁▄█" {
+		t.Fatalf("sparkline = %q", got)
+	}
+
+	m := NewModel()
+	m.inventoryAntMask = 0x01
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		m.charts.add(now.Add(-time.Second), 1)
+	}
+	m.charts.add(now.Add(-time.Second), 2)
+	m.recordTagRead("AA01", 1, 0x30)
+	m.recordTagRead("AA01", 1, 0x50)
+	m.tagSelected = "AA01"
+
+	page := strings.Join(m.chartLines(now), "\n")
+	for _, want := range []string{"Reads/s", "now:7", "ANT1", "0.6/s", "ANT2", "0.1/s", "RSSI", "last:80 min:48 max:80 AA01"} {
+		if !strings.Contains(page, want) {
+			t.Fatalf("chart lines missing %q:\n%s", want, page)
+		}
+	}
+}
+
+func TestSettingsPersistAcrossRunsWithUserPresets(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("BOT_TUI_SETTINGS_FILE", filepath.Join(dir, "settings.json"))
+	key := func(m Model, keys ...string) Model {
+		for _, k := range keys {
+			msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(k)}
+			if k == "enter" {
+				msg = tea.KeyMsg{Type: tea.KeyEnter}
+			}
+			next, _ := m.Update(msg)
+			m = next.(Model)
+		}
+		return m
+	}
+
+	m := NewModel()
+	m.activeScreen = screenInventory
+	m = m.applyInventoryPreset("long-range")
+	m.inventoryIndex = invTuneQValue
+	m = key(m, "l", "l")
+	if m.inventoryQValue != 6 || m.activePreset != "" {
+		t.Fatalf("q=%d preset=%q after editing", m.inventoryQValue, m.activePreset)
+	}
+	m.inventoryIndex = invTuneSavePreset
+	m = key(m, "enter", "d", "o", "c", "k", "enter")
+	if len(m.userPresets) != 1 || m.userPresets[0].Name != "dock" || m.activePreset != "dock" {
+		t.Fatalf("user presets: %+v active=%q", m.userPresets, m.activePreset)
+	}
+	next, _ := m.onConnectFinished(connectFinishedMsg{Endpoint: reader.Endpoint{Host: "192.168.1.50", Port: 6000}})
+	m = next.(Model)
+
+	restored := NewModel()
+	if restored.inventoryQValue != 6 || restored.inventorySession != 2 || restored.inventoryScanTime != 8 {
+		t.Fatalf("restored q=%d s=%d scan=%d", restored.inventoryQValue, restored.inventorySession, restored.inventoryScanTime)
+	}
+	if restored.activePreset != "dock" || len(restored.userPresets) != 1 {
+		t.Fatalf("restored presets: %+v active=%q", restored.userPresets, restored.activePreset)
+	}
+	restored.candidates = []discovery.Candidate{{Host: "192.168.1.9", Port: 6000, Verified: true}, {Host: "192.168.1.50", Port: 6000}}
+	if idx := restored.preferredIndex(); idx != 1 {
+		t.Fatalf("preferred index = %d, want the last connected reader", idx)
+	}
+
+	// Export, then import at another site with a clashing built-in name.
+	exported := filepath.Join(dir, "share.json")
+	restored = restored.exportPresets(exported)
+	other := NewModel()
+	other.userPresets = nil
+	other = other.importPresets(exported)
+	if len(other.userPresets) != 1 || other.userPresets[0].QValue != 6 {
+		t.Fatalf("imported presets: %+v", other.userPresets)
+	}
+	other = other.saveUserPreset("fast")
+	if len(other.userPresets) != 1 {
+		t.Fatal("a user preset must not shadow a built-in")
+	}
+}
+
+func TestExportWritesSessionTagsAndLog(t *testing.T) {
+	m := NewModel()
+	m.inventoryRunning = true
+	read := func(epc []byte, antenna, rssi byte) {
+		data := append([]byte{antenna, 0x01, byte(len(epc))}, epc...)
+		m.handleProtocolFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusSuccess, Data: append(data, rssi)})
+	}
+	read([]byte{0xAA, 0x01}, 0x01, 0x30)
+	read([]byte{0xAA, 0x01}, 0x02, 0x50)
+	read([]byte{0xBB, 0x02}, 0x01, 0x40)
+	m.onBotEvent(botEventMsg{Kind: "ingest", EPC: "AA01", Status: "queued"})
+	for i := 0; i < 300; i++ {
+		m.pushLog("filler")
+	}
+
+	next, _ := m.runControlAction(8)
+	m = next.(Model)
+	if m.activeScreen != screenExport {
+		t.Fatalf("control item 9 opened screen %d", m.activeScreen)
+	}
+	m.exportDir = t.TempDir()
+	m = m.runExport()
+	if len(m.lastExport) != 2 {
+		t.Fatalf("exported %v, status %q", m.lastExport, m.status)
+	}
+	csvData, err := os.ReadFile(m.lastExport[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(csvData)), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "epc,reads,antennas,") ||
+		!strings.HasPrefix(lines[1], "AA01,2,1:1 2:1,2,80,48,64.0,80,") || !strings.HasSuffix(lines[1], ",queued") {
+		t.Fatalf("csv export:\n%s", csvData)
+	}
+	logData, err := os.ReadFile(m.lastExport[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Count(string(logData), "filler"); got != 300 {
+		t.Fatalf("session log has %d filler lines, want all 300 (screen log keeps %d)", got, len(m.logs))
+	}
+
+	m.exportFormat = "json"
+	m.exportLog = false
+	m = m.runExport()
+	jsonData, err := os.ReadFile(m.lastExport[0])
+	if err != nil || len(m.lastExport) != 1 {
+		t.Fatalf("json export %v: %v", m.lastExport, err)
+	}
+	if !strings.Contains(string(jsonData), `"antennas": {`) || !strings.Contains(string(jsonData), `"epc": "BB02"`) {
+		t.Fatalf("json export:\n%s", jsonData)
+	}
+}
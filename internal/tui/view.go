@@ -122,6 +122,10 @@ func (m Model) pageLines() []string {
 		lines = m.logsPageLines()
 	case screenHelp:
 		lines = m.helpPageLines()
+	case screenTags:
+		lines = m.tagsPageLines()
+	case screenExport:
+		lines = m.exportPageLines()
 	default:
 		lines = []string{"Unknown page"}
 	}
@@ -150,7 +154,7 @@ func (m Model) devicesPageLines() []string {
 	lines := []string{"Devices"}
 	verifiedCount := countVerifiedCandidates(m.candidates)
 	if m.scanning {
-		lines = append(lines, "Scan: running...")
+		lines = append(lines, m.scanProgressLine())
 	} else {
 		lines = append(lines, fmt.Sprintf("Scan: idle (last %s)", m.lastScanTime.Round(time.Millisecond)))
 	}
@@ -171,15 +175,28 @@ func (m Model) devicesPageLines() []string {
 			prefix = "▶ "
 		}
 		marker := ""
+		if candidate.Pinned {
+			marker += " [PINNED]"
+		} else if candidate.Known {
+			marker += " [KNOWN]"
+		}
 		if candidate.Verified {
-			marker = " [VERIFIED]"
+			marker += " [VERIFIED]"
+		}
+		lines = append(lines, fmt.Sprintf("%s%d. %s (score:%d)%s", prefix, i+1, candidateName(candidate), candidate.Score, marker))
+	}
+
+	if missing := m.missingKnownReaders(); len(missing) > 0 {
+		names := make([]string, 0, len(missing))
+		for _, k := range missing {
+			names = append(names, knownReaderName(k))
 		}
-		lines = append(lines, fmt.Sprintf("%s%d. %s:%d (score:%d)%s", prefix, i+1, candidate.Host, candidate.Port, candidate.Score, marker))
+		lines = append(lines, "Known, not answering: "+trimText(strings.Join(names, ", "), 72))
 	}
 
 	selected := m.candidates[m.deviceIndex]
 	lines = append(lines, "")
-	lines = append(lines, fmt.Sprintf("Selected: %s:%d", selected.Host, selected.Port))
+	lines = append(lines, fmt.Sprintf("Selected: %s", candidateName(selected)))
 	lines = append(lines, "Reason: "+selected.Reason)
 	if selected.Verified {
 		lines = append(lines, fmt.Sprintf("Protocol: %s  addr:0x%02X", selected.Protocol, selected.ReaderAddress))
@@ -187,6 +204,9 @@ func (m Model) devicesPageLines() []string {
 	if selected.Banner != "" {
 		lines = append(lines, "Banner: "+trimText(selected.Banner, 64))
 	}
+	if m.inputMode == inputModeLabel {
+		lines = append(lines, "", m.input.View())
+	}
 	return lines
 }
 
@@ -214,6 +234,9 @@ func (m Model) controlPageLines() []string {
 		lines = append(lines, fmt.Sprintf("Bot Cache: %d EPC | draft:%d | refresh:%s", m.botStats.CacheSize, m.botStats.DraftCount, formatShortTime(m.botStats.LastRefreshAt)))
 		lines = append(lines, fmt.Sprintf("Bot Submit: ok:%d not_found:%d err:%d", m.botStats.SubmittedOK, m.botStats.SubmitNotFound, m.botStats.SubmitErrors))
 		lines = append(lines, fmt.Sprintf("Bot Seen: total:%d hit:%d miss:%d inactive:%d", m.botStats.SeenTotal, m.botStats.CacheHits, m.botStats.CacheMisses, m.botStats.ScanInactive))
+		for _, epc := range m.botRecentTags {
+			lines = append(lines, fmt.Sprintf("  %s -> %s", trimText(epc, 28), m.botTagResults[epc]))
+		}
 	} else {
 		lines = append(lines, "Bot status: unavailable")
 		if strings.TrimSpace(m.botLastErr) != "" {
@@ -232,7 +255,11 @@ func (m Model) controlPageLines() []string {
 	lines = append(lines, fmt.Sprintf("Inventory: %s | rounds:%d | unique-tags:%d", invState, m.inventoryRounds, m.inventoryTagTotal))
 	lines = append(lines, fmt.Sprintf("Protocol: Reader18 | addr:%s | poll:%s | cycle:%s", addr, m.inventoryInterval, m.effectiveInventoryInterval()))
 	if m.lastTagEPC != "" {
-		lines = append(lines, fmt.Sprintf("Last Tag: %s | Ant:%d | RSSI:%d", trimText(m.lastTagEPC, 28), m.lastTagAntenna, m.lastTagRSSI))
+		line := fmt.Sprintf("Last Tag: %s | Ant:%d | RSSI:%d", trimText(m.lastTagEPC, 28), m.lastTagAntenna, m.lastTagRSSI)
+		if result, ok := m.botTagResults[strings.ToUpper(m.lastTagEPC)]; ok {
+			line += " | Bot:" + result
+		}
+		lines = append(lines, line)
 		if m.showPhaseFreq {
 			lines = append(lines, "Phase/Freq: n/a (not present in cmd 0x01 frame)")
 		}
@@ -264,6 +291,14 @@ func (m Model) controlPageLines() []string {
 func (m Model) inventoryPageLines() []string {
 	lines := []string{"Inventory Tune"}
 	lines = append(lines, "Use h/l or left/right to change values, Enter to run action")
+	preset := m.activePreset
+	if preset == "" {
+		preset = "custom"
+	}
+	lines = append(lines, fmt.Sprintf("Preset: %s | user presets: %d", preset, len(m.userPresets)))
+	if m.inputMode == inputModePresetName || m.inputMode == inputModePresetExport || m.inputMode == inputModePresetImport {
+		lines = append(lines, m.input.View())
+	}
 	lines = append(lines, "")
 
 	rows := []string{
@@ -280,6 +315,13 @@ func (m Model) inventoryPageLines() []string {
 		"Fast Preset",
 		"Balanced Preset",
 		"Long Range Preset",
+		"Save Current As Preset",
+		"Export User Presets",
+		"Import Presets",
+	}
+	for _, preset := range m.userPresets {
+		rows = append(rows, fmt.Sprintf("User Preset: %s (q=%d s=%d scan=%d poll=%dms mask=0x%02X)",
+			preset.Name, preset.QValue, preset.Session, preset.ScanTime, preset.PollIntervalMS, preset.AntennaMask))
 	}
 
 	start, end := listWindow(m.inventoryIndex, len(rows), m.inventoryViewSize())
@@ -294,6 +336,11 @@ func (m Model) inventoryPageLines() []string {
 
 	lines = append(lines, "")
 	lines = append(lines, fmt.Sprintf("Rows: %d-%d of %d", start+1, end, len(rows)))
+	if charts := m.chartLines(time.Now()); len(charts) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, charts...)
+		lines = append(lines, "")
+	}
 	lines = append(lines, "Tip: Session 2/3 + A/B switch helps for far tags")
 	lines = append(lines, "Speed tip: keep Scan Time low (1-3) for realtime reads")
 	return lines
@@ -350,9 +397,12 @@ func (m Model) helpPageLines() []string {
 		"1) Home -> Quick Connect",
 		"2) Control -> Start Reading",
 		"3) Put tag near antenna",
-		"4) Logs -> verify responses",
+		"4) Tags -> watch reads (/ filter, s sort)",
 		"5) Control -> Stop Reading",
+		"6) Logs -> verify responses",
+		"7) Control -> e exports tags and session log",
 		"",
+		"Known readers are checked before the LAN sweep; Devices -> s forces a sweep",
 		"Global keys: q quit, m home, b back",
 		"Move keys: j/k or up/down",
 		"Select key: enter",
@@ -367,10 +417,12 @@ func (m Model) tabsLine() string {
 		{name: "Home", screen: screenHome},
 		{name: "Devices", screen: screenDevices},
 		{name: "Control", screen: screenControl},
+		{name: "Tags", screen: screenTags},
 		{name: "Tune", screen: screenInventory},
 		{name: "Regions", screen: screenRegions},
 		{name: "Logs", screen: screenLogs},
 		{name: "Help", screen: screenHelp},
+		{name: "Export", screen: screenExport},
 	}
 
 	parts := make([]string, 0, len(tabs))
@@ -398,6 +450,9 @@ func (m Model) metaLine() string {
 	scanState := "IDLE"
 	if m.scanning {
 		scanState = "RUNNING"
+		if p := m.scanProgress; p.Total > 0 {
+			scanState = fmt.Sprintf("RUNNING %d%%", p.Probed*100/p.Total)
+		}
 	}
 
 	return fmt.Sprintf("Reader %s | Region %s | Scan %s | Verified %d/%d", connection, regionCode, scanState, countVerifiedCandidates(m.candidates), len(m.candidates))
@@ -407,22 +462,33 @@ func (m Model) footerLine() string {
 	if m.inputMode == inputModeRawHex {
 		return "[Enter] Send  [Esc] Cancel  [0/b] Back  [q] Exit"
 	}
+	if m.inputMode == inputModeTagFilter {
+		return "[Enter] Keep Filter  [Esc] Clear Filter"
+	}
+	if m.inputMode == inputModeLabel || m.inputMode == inputModePresetName ||
+		m.inputMode == inputModePresetExport || m.inputMode == inputModePresetImport || m.inputMode == inputModeExportDir {
+		return "[Enter] Save  [Esc] Cancel"
+	}
 
 	switch m.activeScreen {
 	case screenHome:
-		return "[1..7] Open  [Enter] Open  [0/b] Back  [q] Exit"
+		return "[1..8] Open  [Enter] Open  [0/b] Back  [q] Exit"
 	case screenDevices:
-		return "[Enter] Connect  [s] Scan  [a] Quick Connect  [0/b] Back"
+		return "[Enter] Connect  [s] Scan  [a] Quick  [p] Pin  [r] Rename  [x/X] Forget  [0/b] Back"
 	case screenControl:
-		return "[Enter] Run  [/] Raw Hex  [0/b] Back"
+		return "[Enter] Run  [/] Raw Hex  [t] Tags  [e] Export  [0/b] Back"
 	case screenInventory:
-		return "[h/l] Change  [Enter] Apply/Action  [0/b] Back"
+		return "[h/l] Change  [Enter] Apply/Action  [d] Delete Preset  [0/b] Back"
 	case screenRegions:
 		return "[Enter] Select Region  [0/b] Back"
 	case screenLogs:
 		return "[Up/Down] Scroll  [c] Clear  [0/b] Back"
 	case screenHelp:
 		return "[0/b] Back  [m] Home  [q] Exit"
+	case screenTags:
+		return "[/] Filter  [s] Sort  [r] Reverse  [c] Clear  [e] Export  [PgUp/PgDn] Page  [0/b] Back"
+	case screenExport:
+		return "[Enter] Edit/Action  [h/l] Toggle  [e] Export Now  [0/b] Back"
 	default:
 		return "[0/b] Back  [q] Exit"
 	}
@@ -603,6 +669,26 @@ func targetLabel(target byte) string {
 	return "B"
 }
 
+func (m Model) scanProgressLine() string {
+	p := m.scanProgress
+	if p.Total == 0 {
+		return "Scan: running..."
+	}
+	line := fmt.Sprintf("Scan: %s %d/%d hosts, %d open", progressBar(p.Probed, p.Total, 20), p.Probed, p.Total, p.Found)
+	if p.ETA > 0 {
+		line += ", ETA " + p.ETA.Round(time.Second).String()
+	}
+	return line
+}
+
+func progressBar(done, total, width int) string {
+	filled := 0
+	if total > 0 {
+		filled = min(done*width/total, width)
+	}
+	return "[" + strings.Repeat("#", filled) + strings.Repeat(".", width-filled) + "]"
+}
+
 func onOff(value bool) string {
 	if value {
 		return "ON"
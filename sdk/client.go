@@ -31,6 +31,14 @@ type Client struct {
 	readerAddr    byte
 	targetValue   byte
 	lastTagEPC    string
+	lastFrameAt   time.Time
+	frameRound    int // value of rounds when the latest frame arrived
+	health        [8]antennaStats
+	pendingInv    []int // ports of inventory rounds not answered yet, oldest first
+	pendingSingle []int // same for the single-tag fallback commands
+	pinnedPort    int   // set by DiagnoseAntennas to hold rounds on one port
+	diagMu        sync.Mutex
+	cmdMu         sync.Mutex // serializes Exchange and Raw
 
 	tags     chan TagEvent
 	statuses chan StatusEvent
@@ -105,6 +113,14 @@ func DefaultScanOptions() ScanOptions {
 // Discover scans LAN for probable reader endpoints.
 func (c *Client) Discover(ctx context.Context, opts ScanOptions) ([]Candidate, error) {
 	internalOpts := toInternalScanOptions(opts)
+	if opts.KnownFile != "" {
+		registry, err := discovery.OpenRegistry(opts.KnownFile)
+		if err != nil {
+			c.emitStatus("known readers ignored: " + err.Error())
+		} else {
+			internalOpts.Known = registry
+		}
+	}
 	internalCandidates, err := discovery.Scan(ctx, internalOpts)
 	candidates := make([]Candidate, 0, len(internalCandidates))
 	for _, candidate := range internalCandidates {
@@ -238,6 +254,12 @@ func (c *Client) StartInventory(ctx context.Context) error {
 	c.noTagHit = 0
 	c.antIdx = 0
 	c.lastTagEPC = ""
+	c.lastFrameAt = time.Time{}
+	c.frameRound = 0
+	c.health = [8]antennaStats{}
+	c.pendingInv = nil
+	c.pendingSingle = nil
+	c.pinnedPort = 0
 	c.targetValue = c.cfg.Target
 	if c.readerAddr == 0 {
 		c.readerAddr = c.cfg.ReaderAddress
@@ -291,12 +313,14 @@ func (c *Client) Stats() Stats {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 	return Stats{
-		Running:     c.inventoryOn,
-		Rounds:      c.rounds,
-		UniqueTags:  c.uniqueTags,
-		LastTagEPC:  c.lastTagEPC,
-		ReaderAddr:  c.readerAddr,
-		TargetValue: c.targetValue,
+		Running:          c.inventoryOn,
+		Rounds:           c.rounds,
+		LastFrameAt:      c.lastFrameAt,
+		RoundsSinceFrame: c.rounds - c.frameRound,
+		UniqueTags:       c.uniqueTags,
+		LastTagEPC:       c.lastTagEPC,
+		ReaderAddr:       c.readerAddr,
+		TargetValue:      c.targetValue,
 	}
 }
 
@@ -392,6 +416,8 @@ func (c *Client) consumePacket(data []byte) {
 
 func (c *Client) consumeFrame(frame reader18.Frame) {
 	c.mu.Lock()
+	c.lastFrameAt = time.Now()
+	c.frameRound = c.rounds
 	if c.cfg.AutoAddress {
 		c.readerAddr = frame.Address
 	}
@@ -408,6 +434,7 @@ func (c *Client) consumeFrame(frame reader18.Frame) {
 }
 
 func (c *Client) handleInventoryFrame(frame reader18.Frame) {
+	port := c.replyPort(&c.pendingInv, frame)
 	tags, err := reader18.ParseInventoryG2Tags(frame)
 	if err != nil {
 		if strings.Contains(err.Error(), "truncated") || strings.Contains(err.Error(), "invalid") {
@@ -429,10 +456,19 @@ func (c *Client) handleInventoryFrame(frame reader18.Frame) {
 		}
 		return
 	}
+	if frame.Status == reader18.StatusAntennaError {
+		c.observeAntennaError(port)
+		return
+	}
 	c.observeNoTag(frame.Status)
 }
 
 func (c *Client) handleInventorySingleFrame(frame reader18.Frame) {
+	port := c.replyPort(&c.pendingSingle, frame)
+	if frame.Status == reader18.StatusAntennaError {
+		c.observeAntennaError(port)
+		return
+	}
 	result, err := reader18.ParseSingleInventoryResult(frame)
 	if err != nil {
 		return
@@ -468,14 +504,23 @@ func (c *Client) observeNoTag(status byte) {
 	}
 }
 
+// observeAntennaError charges an antenna error (0xF8) to port.
+func (c *Client) observeAntennaError(port int) {
+	c.mu.Lock()
+	c.noteAntennaError(port, time.Now())
+	c.mu.Unlock()
+}
+
 func (c *Client) recordTag(source string, antenna int, rssi int, epc []byte) {
 	epcText := strings.ToUpper(hex.EncodeToString(epc))
 	if epcText == "" {
 		return
 	}
 
+	now := time.Now()
 	c.mu.Lock()
 	c.noTagHit = 0
+	c.noteAntennaRead(antenna, now)
 	_, exists := c.seen[epcText]
 	if !exists {
 		c.seen[epcText] = struct{}{}
@@ -487,7 +532,7 @@ func (c *Client) recordTag(source string, antenna int, rssi int, epc []byte) {
 	c.mu.Unlock()
 
 	c.emitTag(TagEvent{
-		When:       time.Now(),
+		When:       now,
 		Source:     source,
 		EPC:        epcText,
 		Antenna:    antenna,
@@ -509,8 +554,17 @@ func (c *Client) nextInventoryCommand() (inventory []byte, single []byte, interv
 	c.cfg = cfg
 	c.rounds++
 
-	antenna, nextIdx := nextInventoryAntenna(cfg.AntennaMask, c.antIdx)
-	c.antIdx = nextIdx
+	var antenna byte
+	if c.pinnedPort > 0 {
+		antenna = byte(0x80 | (c.pinnedPort - 1))
+	} else {
+		var nextIdx int
+		antenna, nextIdx = nextInventoryAntenna(cfg.AntennaMask, c.antIdx)
+		c.antIdx = nextIdx
+	}
+	port := int(antenna&0x07) + 1
+	c.noteAntennaRound(port)
+	c.pendingInv = notePending(c.pendingInv, port)
 
 	inventory = reader18.InventoryG2Command(
 		c.readerAddr,
@@ -524,6 +578,7 @@ func (c *Client) nextInventoryCommand() (inventory []byte, single []byte, interv
 	)
 	if cfg.SingleFallbackEach > 0 && c.rounds%cfg.SingleFallbackEach == 0 {
 		single = reader18.InventorySingleTagCommand(c.readerAddr)
+		c.pendingSingle = notePending(c.pendingSingle, port)
 	}
 	return inventory, single, cfg.EffectiveInterval(), true
 }
@@ -627,6 +682,20 @@ func toInternalScanOptions(opts ScanOptions) discovery.ScanOptions {
 		Timeout:               opts.Timeout,
 		Concurrency:           opts.Concurrency,
 		HostLimitPerInterface: opts.HostLimitPerInterface,
+		ForceSweep:            opts.ForceSweep,
+		Targets:               append([]string{}, opts.Targets...),
+		Exclude:               append([]string{}, opts.Exclude...),
+		Interfaces:            append([]string{}, opts.Interfaces...),
+		Progress:              toInternalProgress(opts.Progress),
+	}
+}
+
+func toInternalProgress(fn func(ScanProgress)) func(discovery.ScanProgress) {
+	if fn == nil {
+		return nil
+	}
+	return func(p discovery.ScanProgress) {
+		fn(ScanProgress{Probed: p.Probed, Total: p.Total, Found: p.Found, Elapsed: p.Elapsed, ETA: p.ETA})
 	}
 }
 
@@ -640,5 +709,9 @@ func fromInternalCandidate(candidate discovery.Candidate) Candidate {
 		Verified:      candidate.Verified,
 		ReaderAddress: candidate.ReaderAddress,
 		Protocol:      candidate.Protocol,
+		MAC:           candidate.MAC,
+		Known:         candidate.Known,
+		Label:         candidate.Label,
+		Pinned:        candidate.Pinned,
 	}
 }
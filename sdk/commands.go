@@ -0,0 +1,336 @@
+package sdk
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+	"new_era_go/internal/regions"
+)
+
+// DefaultCommandTimeout bounds how long a command waits for its answer.
+const DefaultCommandTimeout = 2 * time.Second
+
+// Response is one frame the reader sent back.
+type Response struct {
+	Address byte
+	Command byte
+	Status  byte
+	Data    []byte
+	Raw     []byte
+}
+
+// Err reports a non-success status.
+func (r Response) Err() error {
+	if r.Status == reader18.StatusSuccess {
+		return nil
+	}
+	return fmt.Errorf("command 0x%02X failed with status 0x%02X", r.Command, r.Status)
+}
+
+// ReaderInfo is what the reader reports about itself (command 0x21).
+type ReaderInfo struct {
+	Firmware   string
+	Type       byte
+	Protocols  byte
+	BandID     byte
+	Band       string // empty when the firmware band is unknown
+	MinChannel int
+	MaxChannel int
+	MinFreqKHz int
+	MaxFreqKHz int
+	Power      byte
+	ScanTime   byte // x100 ms
+}
+
+// MemoryBank is a Gen2 tag memory bank.
+type MemoryBank byte
+
+const (
+	BankReserved MemoryBank = MemoryBank(reader18.BankReserved)
+	BankEPC      MemoryBank = MemoryBank(reader18.BankEPC)
+	BankTID      MemoryBank = MemoryBank(reader18.BankTID)
+	BankUser     MemoryBank = MemoryBank(reader18.BankUser)
+)
+
+var bankNames = []string{"reserved", "epc", "tid", "user"}
+
+func (b MemoryBank) String() string {
+	if int(b) < len(bankNames) {
+		return bankNames[b]
+	}
+	return fmt.Sprintf("bank%d", byte(b))
+}
+
+// ParseMemoryBank accepts a bank name (reserved, epc, tid, user) or number.
+func ParseMemoryBank(raw string) (MemoryBank, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	for i, name := range bankNames {
+		if raw == name || raw == fmt.Sprint(i) {
+			return MemoryBank(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown memory bank %q", raw)
+}
+
+// Exchange sends one command frame and returns the first answer to the same
+// command. Commands are serialized and refused while inventory runs, since
+// the inventory loop owns the reader's answers.
+func (c *Client) Exchange(ctx context.Context, packet []byte, timeout time.Duration) (Response, error) {
+	if len(packet) < 3 {
+		return Response{}, fmt.Errorf("command frame too short")
+	}
+	command := packet[2]
+	var match Response
+	found := false
+	err := c.exchange(ctx, packet, timeout, func(r Response) bool {
+		if r.Command != command {
+			return false
+		}
+		match, found = r, true
+		return true
+	})
+	if err != nil {
+		return Response{}, err
+	}
+	if !found {
+		return Response{}, fmt.Errorf("no answer to command 0x%02X within %s", command, timeout)
+	}
+	return match, nil
+}
+
+// Raw sends any bytes and returns every frame received during wait.
+func (c *Client) Raw(ctx context.Context, packet []byte, wait time.Duration) ([]Response, error) {
+	var out []Response
+	err := c.exchange(ctx, packet, wait, func(r Response) bool {
+		out = append(out, r)
+		return false
+	})
+	return out, err
+}
+
+// ReaderInfo queries firmware, band, power and scan time.
+func (c *Client) ReaderInfo(ctx context.Context) (ReaderInfo, error) {
+	resp, err := c.Exchange(ctx, reader18.GetReaderInfoCommand(c.currentReaderAddress()), DefaultCommandTimeout)
+	if err != nil {
+		return ReaderInfo{}, err
+	}
+	info, err := reader18.ParseReaderInfo(toFrame(resp))
+	if err != nil {
+		return ReaderInfo{}, err
+	}
+	out := ReaderInfo{
+		Firmware:   fmt.Sprintf("%d.%d", info.Major, info.Minor),
+		Type:       info.Type,
+		Protocols:  info.Protocols,
+		BandID:     info.Band,
+		MinChannel: int(info.MinChannel),
+		MaxChannel: int(info.MaxChannel),
+		Power:      info.Power,
+		ScanTime:   info.ScanTime,
+	}
+	if band, ok := reader18.LookupBand(info.Band); ok {
+		out.Band = band.Name
+		out.MinFreqKHz = band.ChannelKHz(out.MinChannel)
+		out.MaxFreqKHz = band.ChannelKHz(out.MaxChannel)
+	}
+	return out, nil
+}
+
+// SetOutputPower sets RF power (0-30 dBm) and keeps it for later inventory.
+func (c *Client) SetOutputPower(ctx context.Context, dbm int) error {
+	if dbm < 0 || dbm > 0x1E {
+		return fmt.Errorf("power must be 0-30, got %d", dbm)
+	}
+	if err := c.command(ctx, reader18.SetOutputPowerCommand(c.currentReaderAddress(), byte(dbm))); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cfg.OutputPower = byte(dbm)
+	c.mu.Unlock()
+	return nil
+}
+
+// SetScanTime sets the firmware inventory time in 100 ms units.
+func (c *Client) SetScanTime(ctx context.Context, units int) error {
+	if units < 1 || units > 0xFF {
+		return fmt.Errorf("scan time must be 1-255, got %d", units)
+	}
+	if err := c.command(ctx, reader18.SetScanTimeCommand(c.currentReaderAddress(), byte(units))); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cfg.ScanTime = byte(units)
+	c.mu.Unlock()
+	return nil
+}
+
+// SetAntennas enables the antenna ports in mask (bit 0 is port 1).
+func (c *Client) SetAntennas(ctx context.Context, mask byte) error {
+	if mask == 0 {
+		return fmt.Errorf("at least one antenna is required")
+	}
+	if err := c.command(ctx, reader18.SetAntennaMuxCommand(c.currentReaderAddress(), mask)); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	c.cfg.AntennaMask = mask
+	c.mu.Unlock()
+	return nil
+}
+
+// SetRegion restricts the reader to the channels of a regions.Catalog entry
+// that fall inside its band.
+func (c *Client) SetRegion(ctx context.Context, code string) error {
+	region, ok := regions.Lookup(code)
+	if !ok {
+		return fmt.Errorf("unknown region %q", code)
+	}
+	low, high, err := region.RangeKHz()
+	if err != nil {
+		return err
+	}
+	band, minCh, maxCh, err := reader18.BandForRange(low, high)
+	if err != nil {
+		return fmt.Errorf("region %s: %w", region.Code, err)
+	}
+	if err := c.command(ctx, reader18.SetRegionCommand(c.currentReaderAddress(), band.ID, minCh, maxCh)); err != nil {
+		return err
+	}
+	c.emitStatus(fmt.Sprintf("region %s applied: %s channels %d-%d", region.Code, band.Name, minCh, maxCh))
+	return nil
+}
+
+// ReadMemory reads words from one bank of the tag with the given EPC (hex).
+func (c *Client) ReadMemory(ctx context.Context, epc string, bank MemoryBank, wordPtr, words int, password uint32) ([]byte, error) {
+	epcBytes, err := decodeEPC(epc)
+	if err != nil {
+		return nil, err
+	}
+	if wordPtr < 0 || wordPtr > 0xFF {
+		return nil, fmt.Errorf("word pointer must be 0-255, got %d", wordPtr)
+	}
+	if err := reader18.CheckReadWords(words); err != nil {
+		return nil, err
+	}
+	packet, err := reader18.ReadDataCommand(c.currentReaderAddress(), epcBytes, byte(bank), byte(wordPtr), byte(words), password)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Exchange(ctx, packet, DefaultCommandTimeout)
+	if err != nil {
+		return nil, err
+	}
+	return reader18.ParseReadData(toFrame(resp))
+}
+
+// WriteEPC writes a new EPC (hex) to the single tag in the field.
+func (c *Client) WriteEPC(ctx context.Context, epc string, password uint32) error {
+	epcBytes, err := decodeEPC(epc)
+	if err != nil {
+		return err
+	}
+	packet, err := reader18.WriteEPCCommand(c.currentReaderAddress(), epcBytes, password)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Exchange(ctx, packet, DefaultCommandTimeout)
+	if err != nil {
+		return err
+	}
+	return reader18.AccessError(toFrame(resp))
+}
+
+// command sends a setting and fails unless the reader acknowledges it.
+func (c *Client) command(ctx context.Context, packet []byte) error {
+	resp, err := c.Exchange(ctx, packet, DefaultCommandTimeout)
+	if err != nil {
+		return err
+	}
+	return resp.Err()
+}
+
+// exchange sends packet and hands each parsed answer to accept until it
+// returns true or timeout elapses.
+func (c *Client) exchange(ctx context.Context, packet []byte, timeout time.Duration, accept func(Response) bool) error {
+	c.cmdMu.Lock()
+	defer c.cmdMu.Unlock()
+
+	c.mu.RLock()
+	running := c.inventoryOn
+	autoAddr := c.cfg.AutoAddress
+	c.mu.RUnlock()
+	if running {
+		return fmt.Errorf("stop inventory before sending commands")
+	}
+	packets := c.transport.Packets()
+	if packets == nil {
+		return fmt.Errorf("not connected")
+	}
+	if timeout <= 0 {
+		timeout = DefaultCommandTimeout
+	}
+
+	// Answers to earlier fire-and-forget commands are not ours.
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-packets:
+			if !ok {
+				return fmt.Errorf("reader connection closed")
+			}
+		default:
+			drained = true
+		}
+	}
+	if err := c.transport.SendRaw(packet, timeout); err != nil {
+		return err
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	var buffer []byte
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			return nil
+		case p, ok := <-packets:
+			if !ok {
+				return fmt.Errorf("reader connection closed")
+			}
+			var frames []reader18.Frame
+			frames, buffer = reader18.ParseFrames(append(buffer, p.Data...))
+			for _, frame := range frames {
+				c.mu.Lock()
+				c.lastFrameAt = time.Now()
+				if autoAddr {
+					c.readerAddr = frame.Address
+				}
+				c.mu.Unlock()
+				if accept(fromFrame(frame)) {
+					return nil
+				}
+			}
+		}
+	}
+}
+
+func decodeEPC(epc string) ([]byte, error) {
+	raw, err := hex.DecodeString(strings.ReplaceAll(strings.TrimSpace(epc), " ", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid epc %q: %w", epc, err)
+	}
+	return raw, nil
+}
+
+func fromFrame(f reader18.Frame) Response {
+	return Response{Address: f.Address, Command: f.Command, Status: f.Status, Data: f.Data, Raw: f.Raw}
+}
+
+func toFrame(r Response) reader18.Frame {
+	return reader18.Frame{Address: r.Address, Command: r.Command, Status: r.Status, Data: r.Data, Raw: r.Raw}
+}
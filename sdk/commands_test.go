@@ -0,0 +1,119 @@
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+)
+
+// fakeReader answers reader-info and read-data commands. Each answer is
+// preceded by an unrelated inventory frame the client must skip.
+func fakeReader(t *testing.T) Endpoint {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf := make([]byte, 256)
+		for {
+			n, err := conn.Read(buf)
+			if err != nil {
+				return
+			}
+			if n < 5 {
+				continue
+			}
+			var payload []byte
+			switch buf[2] {
+			case reader18.CmdGetReaderInfo:
+				payload = []byte{reader18.StatusSuccess, 0x02, 0x05, 0x09, 0x03, 0x4E, 0x00, 0x1A, 0x0A}
+			case reader18.CmdReadData:
+				payload = []byte{reader18.StatusSuccess, 0xE2, 0x80, 0x11, 0x05}
+			default:
+				payload = []byte{reader18.StatusCmdError}
+			}
+			noise := reader18.BuildCommand(0x00, reader18.CmdInventory, []byte{reader18.StatusNoTag})
+			reply := reader18.BuildCommand(0x00, buf[2], payload)
+			_, _ = conn.Write(append(noise, reply...))
+		}
+	}()
+	addr := ln.Addr().(*net.TCPAddr)
+	return Endpoint{Host: addr.IP.String(), Port: addr.Port}
+}
+
+func TestCommandsWaitForMatchingAnswer(t *testing.T) {
+	endpoint := fakeReader(t)
+	c := NewClient()
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Connect(ctx, endpoint, time.Second); err != nil {
+		t.Fatal(err)
+	}
+
+	info, err := c.ReaderInfo(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Firmware != "2.5" || info.Band != "EU" || info.MaxChannel != 14 || info.Power != 26 {
+		t.Fatalf("unexpected info: %+v", info)
+	}
+	if info.MinFreqKHz != 865_100 || info.MaxFreqKHz != 867_900 {
+		t.Fatalf("unexpected frequencies: %d-%d", info.MinFreqKHz, info.MaxFreqKHz)
+	}
+
+	words, err := c.ReadMemory(ctx, "E2000017", BankTID, 0, 2, 0)
+	if err != nil || !bytes.Equal(words, []byte{0xE2, 0x80, 0x11, 0x05}) {
+		t.Fatalf("ReadMemory = % X, %v", words, err)
+	}
+
+	if err := c.SetRegion(ctx, "US"); err == nil {
+		t.Fatal("rejected region reported as applied")
+	}
+
+	c.mu.Lock()
+	c.inventoryOn = true
+	c.mu.Unlock()
+	if _, err := c.ReaderInfo(ctx); err == nil {
+		t.Fatal("command accepted while inventory runs")
+	}
+	c.mu.Lock()
+	c.inventoryOn = false
+	c.mu.Unlock()
+}
+
+func TestParseMemoryBank(t *testing.T) {
+	for raw, want := range map[string]MemoryBank{"tid": BankTID, "USER": BankUser, "1": BankEPC} {
+		got, err := ParseMemoryBank(raw)
+		if err != nil || got != want {
+			t.Fatalf("ParseMemoryBank(%q) = %v, %v", raw, got, err)
+		}
+	}
+	if _, err := ParseMemoryBank("4"); err == nil {
+		t.Fatal("bank 4 accepted")
+	}
+}
+
+func TestReadMemoryWordCountMatchesProtocol(t *testing.T) {
+	c := NewClient()
+	for _, words := range []int{0, 121, 300} {
+		_, err := c.ReadMemory(context.Background(), "E2000017", BankTID, 0, words, 0)
+		want := reader18.CheckReadWords(words)
+		if err == nil || want == nil || err.Error() != want.Error() {
+			t.Fatalf("ReadMemory(%d words) = %v, want %v", words, err, want)
+		}
+	}
+	if _, err := reader18.ReadDataCommand(0x00, []byte{0xE2, 0x00}, reader18.BankTID, 0, 121, 0); err == nil || err.Error() != reader18.CheckReadWords(121).Error() {
+		t.Fatalf("ReadDataCommand(121 words) = %v", err)
+	}
+}
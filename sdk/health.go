@@ -0,0 +1,248 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+)
+
+// AntennaHealth summarizes inventory results for one antenna port since
+// inventory started.
+type AntennaHealth struct {
+	Port        int
+	Rounds      uint64 // inventory rounds addressed to this port
+	Reads       uint64 // tag reads reported on this port
+	Errors      uint64 // antenna error (0xF8) responses
+	LastRead    time.Time
+	LastError   time.Time
+	ReadsPerMin int // reads during the last 60 seconds
+}
+
+// ErrorRate is the share of rounds on this port answered with an antenna error.
+func (h AntennaHealth) ErrorRate() float64 {
+	if h.Rounds == 0 {
+		return 0
+	}
+	return float64(h.Errors) / float64(h.Rounds)
+}
+
+// AntennaState is the outcome of DiagnoseAntennas for one port.
+type AntennaState string
+
+const (
+	AntennaOK           AntennaState = "ok"
+	AntennaSilent       AntennaState = "silent"
+	AntennaDisconnected AntennaState = "disconnected"
+)
+
+// AntennaDiagnosis is the result of dwelling on one antenna port.
+type AntennaDiagnosis struct {
+	Port   int
+	State  AntennaState
+	Rounds uint64
+	Reads  uint64
+	Errors uint64
+}
+
+// antennaStats is the per-port counter kept under Client.mu.
+type antennaStats struct {
+	rounds    uint64
+	reads     uint64
+	errors    uint64
+	lastRead  time.Time
+	lastError time.Time
+	minute    minuteCounter
+}
+
+// minuteCounter counts events over the last 60 seconds in per-second buckets.
+type minuteCounter struct {
+	counts [60]int
+	stamps [60]int64
+}
+
+func (m *minuteCounter) add(now time.Time) {
+	sec := now.Unix()
+	i := sec % 60
+	if m.stamps[i] != sec {
+		m.stamps[i] = sec
+		m.counts[i] = 0
+	}
+	m.counts[i]++
+}
+
+func (m *minuteCounter) total(now time.Time) int {
+	sec := now.Unix()
+	total := 0
+	for i := range m.counts {
+		if sec-m.stamps[i] < 60 {
+			total += m.counts[i]
+		}
+	}
+	return total
+}
+
+// AntennaHealth returns counters for every port in the antenna mask and any
+// other port that reported activity, ordered by port.
+func (c *Client) AntennaHealth() []AntennaHealth {
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var out []AntennaHealth
+	for idx := range c.health {
+		st := &c.health[idx]
+		inMask := c.cfg.AntennaMask&(byte(1)<<idx) != 0
+		if !inMask && st.rounds == 0 && st.reads == 0 {
+			continue
+		}
+		out = append(out, AntennaHealth{
+			Port:        idx + 1,
+			Rounds:      st.rounds,
+			Reads:       st.reads,
+			Errors:      st.errors,
+			LastRead:    st.lastRead,
+			LastError:   st.lastError,
+			ReadsPerMin: st.minute.total(now),
+		})
+	}
+	return out
+}
+
+// DiagnoseAntennas pins the running inventory to each port in AntennaMask for
+// dwell and reports which ports read tags, stay silent or answer with antenna
+// errors (usually an unplugged cable). Inventory must be running.
+func (c *Client) DiagnoseAntennas(ctx context.Context, dwell time.Duration) ([]AntennaDiagnosis, error) {
+	if dwell <= 0 {
+		dwell = time.Second
+	}
+	c.diagMu.Lock()
+	defer c.diagMu.Unlock()
+
+	c.mu.Lock()
+	if !c.inventoryOn {
+		c.mu.Unlock()
+		return nil, fmt.Errorf("inventory not running")
+	}
+	mask := c.cfg.AntennaMask
+	settle := c.cfg.EffectiveInterval()
+	c.mu.Unlock()
+	defer c.pinAntenna(0)
+
+	var out []AntennaDiagnosis
+	for idx := 0; idx < 8; idx++ {
+		if mask&(byte(1)<<idx) == 0 {
+			continue
+		}
+		port := idx + 1
+		c.pinAntenna(port)
+		// Let an answer to a round on the previous port drain first.
+		if !sleepCtx(ctx, settle) {
+			return out, ctx.Err()
+		}
+		before := c.antennaSnapshot(idx)
+		if !sleepCtx(ctx, dwell) {
+			return out, ctx.Err()
+		}
+		after := c.antennaSnapshot(idx)
+
+		d := AntennaDiagnosis{
+			Port:   port,
+			Rounds: after.rounds - before.rounds,
+			Reads:  after.reads - before.reads,
+			Errors: after.errors - before.errors,
+		}
+		switch {
+		case d.Reads > 0:
+			d.State = AntennaOK
+		case d.Errors > 0:
+			d.State = AntennaDisconnected
+		default:
+			d.State = AntennaSilent
+		}
+		out = append(out, d)
+	}
+	return out, nil
+}
+
+func (c *Client) pinAntenna(port int) {
+	c.mu.Lock()
+	c.pinnedPort = port
+	c.mu.Unlock()
+}
+
+func (c *Client) antennaSnapshot(idx int) antennaStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.health[idx]
+}
+
+// noteAntennaRound, noteAntennaRead and noteAntennaError must be called with c.mu held.
+func (c *Client) noteAntennaRound(port int) {
+	if port >= 1 && port <= 8 {
+		c.health[port-1].rounds++
+	}
+}
+
+func (c *Client) noteAntennaRead(port int, now time.Time) {
+	if port < 1 || port > 8 {
+		return
+	}
+	st := &c.health[port-1]
+	st.reads++
+	st.lastRead = now
+	st.minute.add(now)
+}
+
+func (c *Client) noteAntennaError(port int, now time.Time) {
+	if port < 1 || port > 8 {
+		return
+	}
+	st := &c.health[port-1]
+	st.errors++
+	st.lastError = now
+}
+
+// maxPendingRounds bounds the ports remembered for unanswered rounds; when
+// replies go missing the oldest entries are dropped.
+const maxPendingRounds = 32
+
+// notePending must be called with c.mu held.
+func notePending(pending []int, port int) []int {
+	if len(pending) >= maxPendingRounds {
+		pending = pending[1:]
+	}
+	return append(pending, port)
+}
+
+// replyPort returns the port of the round frame answers. Rounds are sent
+// without waiting for the reply, so the port is taken from the frame's
+// antenna byte when present and otherwise from the oldest unanswered round
+// in pending. The round stays pending while more frames of it follow.
+func (c *Client) replyPort(pending *[]int, frame reader18.Frame) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	port := 0
+	if len(*pending) > 0 {
+		port = (*pending)[0]
+		if frame.Status != 0x03 { // 0x03: more frames of this reply follow
+			*pending = (*pending)[1:]
+		}
+	}
+	if p := reader18.ReplyAntenna(frame); p > 0 {
+		port = p
+	}
+	return port
+}
+
+func sleepCtx(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
@@ -0,0 +1,89 @@
+package sdk
+
+import (
+	"testing"
+	"time"
+
+	reader18 "new_era_go/internal/protocol/reader18"
+)
+
+func TestAntennaHealthAttributesErrorsAndReads(t *testing.T) {
+	c := NewClient()
+	cfg := c.InventoryConfig()
+	cfg.AntennaMask = 0x03
+	c.SetInventoryConfig(cfg)
+	c.inventoryOn = true
+
+	// Round on port 1 answered with an antenna error.
+	if _, _, _, ok := c.nextInventoryCommand(); !ok {
+		t.Fatal("inventory command not produced")
+	}
+	c.consumeFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusAntennaError})
+
+	// Round on port 2 reads one tag (antenna mask 0x02 in the reply).
+	c.nextInventoryCommand()
+	c.consumeFrame(reader18.Frame{
+		Command: reader18.CmdInventory,
+		Status:  reader18.StatusSuccess,
+		Data:    []byte{0x02, 0x01, 0x02, 0xAB, 0xCD, 0x40},
+	})
+
+	health := c.AntennaHealth()
+	if len(health) != 2 {
+		t.Fatalf("expected two ports, got %+v", health)
+	}
+	if h := health[0]; h.Port != 1 || h.Errors != 1 || h.Reads != 0 || h.ErrorRate() != 1 {
+		t.Fatalf("port 1 health: %+v", h)
+	}
+	if h := health[1]; h.Port != 2 || h.Reads != 1 || h.ReadsPerMin != 1 || h.LastRead.IsZero() {
+		t.Fatalf("port 2 health: %+v", h)
+	}
+}
+
+func TestAntennaErrorsFollowPipelinedRounds(t *testing.T) {
+	c := NewClient()
+	cfg := c.InventoryConfig()
+	cfg.AntennaMask = 0x07
+	c.SetInventoryConfig(cfg)
+	c.inventoryOn = true
+
+	// Three rounds (ports 1, 2, 3) go out before the first reply arrives.
+	for i := 0; i < 3; i++ {
+		c.nextInventoryCommand()
+	}
+	// Port 1 answers in two frames; only the last one closes the round.
+	c.consumeFrame(reader18.Frame{Command: reader18.CmdInventory, Status: 0x03, Data: []byte{0x01, 0x01, 0x02, 0xAB, 0xCD, 0x40}})
+	c.consumeFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusAntennaError})
+	// Port 2 reports an error, port 3 names its antenna in the reply.
+	c.consumeFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusAntennaError})
+	c.consumeFrame(reader18.Frame{Command: reader18.CmdInventory, Status: reader18.StatusAntennaError, Data: []byte{0x04}})
+
+	health := c.AntennaHealth()
+	if len(health) != 3 {
+		t.Fatalf("expected three ports, got %+v", health)
+	}
+	for _, h := range health {
+		if h.Errors != 1 {
+			t.Fatalf("port %d: expected one error, got %+v", h.Port, health)
+		}
+	}
+	if len(c.pendingInv) != 0 {
+		t.Fatalf("rounds left pending: %v", c.pendingInv)
+	}
+}
+
+func TestMinuteCounterDropsOldBuckets(t *testing.T) {
+	var m minuteCounter
+	start := time.Unix(1_700_000_000, 0)
+	m.add(start)
+	m.add(start.Add(30 * time.Second))
+	if got := m.total(start.Add(59 * time.Second)); got != 2 {
+		t.Fatalf("total within a minute = %d", got)
+	}
+	if got := m.total(start.Add(75 * time.Second)); got != 1 {
+		t.Fatalf("total after the first read expired = %d", got)
+	}
+	if got := m.total(start.Add(10 * time.Minute)); got != 0 {
+		t.Fatalf("total after a quiet period = %d", got)
+	}
+}
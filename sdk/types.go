@@ -24,6 +24,29 @@ type ScanOptions struct {
 	Timeout               time.Duration
 	Concurrency           int
 	HostLimitPerInterface int
+	// KnownFile is the known-reader registry; readers in it are verified
+	// before the LAN sweep, which runs only when none answers or ForceSweep
+	// is set. Empty disables the registry.
+	KnownFile  string
+	ForceSweep bool
+	// Targets replaces the local interface sweep with CIDRs, ranges
+	// ("10.20.0.10-50") or addresses; Exclude lists hosts never probed and
+	// Interfaces limits the local sweep to the named interfaces.
+	Targets    []string
+	Exclude    []string
+	Interfaces []string
+	// Progress is called from one goroutine during the sweep and once at its
+	// end. It must not block.
+	Progress func(ScanProgress)
+}
+
+// ScanProgress reports how many hosts a LAN sweep has probed.
+type ScanProgress struct {
+	Probed  int
+	Total   int
+	Found   int
+	Elapsed time.Duration
+	ETA     time.Duration
 }
 
 // Candidate is one discovered endpoint with scoring/verification metadata.
@@ -36,6 +59,12 @@ type Candidate struct {
 	Verified      bool
 	ReaderAddress byte
 	Protocol      string
+	// MAC is carried over from a known-reader entry that has one.
+	MAC string
+	// Known, Label and Pinned come from the known-reader registry.
+	Known  bool
+	Label  string
+	Pinned bool
 }
 
 // InventoryConfig controls how the reader performs inventory polling.
@@ -130,4 +159,7 @@ type Stats struct {
 	LastTagEPC  string
 	ReaderAddr  byte
 	TargetValue byte
+	// LastFrameAt and RoundsSinceFrame show whether the reader still answers.
+	LastFrameAt      time.Time
+	RoundsSinceFrame int
 }